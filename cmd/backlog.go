@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/andreoliwa/logseq-go"
 	"github.com/spf13/cobra"
 )
 
@@ -17,27 +24,154 @@ var backlogCmd = &cobra.Command{ //nolint:exhaustruct,gochecknoglobals
 
 If partial page names are provided, only page titles that contain the provided names are processed.
 
-Each line on the "backlog" page that includes references to other pages or tags generates a separate backlog.
+Each line on the "backlog" page (backlog.config_page in the config file) that includes references
+to other pages or tags generates a separate backlog.
 The first page in the line determines the name of the backlog page.
 Tasks are retrieved from all provided pages or tags.
 This setup enables users to rearrange tasks using the arrow keys and manage task states (start/stop)
-directly within the interface.`,
-	Run: func(_ *cobra.Command, args []string) {
-		path := os.Getenv("LOGSEQ_GRAPH_PATH")
-		logseqAPI := logseqapi.NewLogseqAPI(path,
-			os.Getenv("LOGSEQ_HOST_URL"), os.Getenv("LOGSEQ_API_TOKEN"))
+directly within the interface.
+
+With --config-file (backlog.config_file in the config file), the backlogs are declared in a TOML or YAML file
+instead, with their icon, input pages, excluded pages, custom query, focus inclusion and section order.
+
+With --watch, the backlogs are processed, then processed again each time the journals or pages of the graph change:
+after a burst of writes, only the backlogs whose input pages or tagged tasks changed run.
+The focus page is only processed when all the backlogs run, e.g. when the backlog config changes.
+
+The input pages of a backlog are queried by --workers goroutines at the same time (backlog.workers in the config file).
+
+With --fill-focus (backlog.fill_focus in the config file), the top ranked tasks of each backlog are put
+in its Focus section, up to --focus-per-backlog per backlog and --focus-limit on the Focus page,
+after the overdue tasks with priority A (backlog.focus_overdue_a). They are marked with 🤖
+and taken out again when they no longer qualify. When only some backlogs are processed, only those are filled,
+and the focus tasks of the others count towards --focus-limit. With --dry-run, the changes are shown instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		workers, err := strconv.Atoi(flagOrConfig(cmd, "workers", config.KeyBacklogWorkers))
+		if err != nil || workers < 1 {
+			fmt.Println("Invalid backlog.workers value: it must be a positive number")
+			exit(1)
+		}
+
+		backlog.SetQueryWorkers(workers)
+		backlog.SetFocusPolicy(focusPolicy(cmd))
+
+		path := configValue(config.KeyGraphPath)
 		graph := logseqapi.OpenGraphFromPath(path)
-		reader := backlog.NewPageConfigReader(graph, "backlog")
-		proc := backlog.NewBacklog(graph, logseqAPI, reader, time.Now)
+		configFile := flagOrConfig(cmd, "config-file", config.KeyBacklogConfigFile)
+		reader := newBacklogConfigReader(graph, configFile)
+		// A new API for each run: the offline index is built once per API, from the files as they are then.
+		newProc := func() backlog.Backlog {
+			return backlog.NewBacklog(graph, newLogseqAPI(path, true), reader, time.Now)
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			err = watchBacklogs(graph.Directory(), newProc, reader, args, configFile)
+		} else {
+			err = newProc().ProcessAll(args)
+		}
 
-		err := proc.ProcessAll(args)
 		if err != nil {
 			fmt.Println(err)
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
 
+// focusPolicy returns the policy of --fill-focus from the flags and the config file, or nil when it is off.
+func focusPolicy(cmd *cobra.Command) *backlog.FocusPolicy {
+	fill, err := strconv.ParseBool(flagOrConfig(cmd, "fill-focus", config.KeyBacklogFillFocus))
+	if err != nil {
+		fmt.Println("Invalid backlog.fill_focus value: it must be true or false")
+		exit(1)
+	}
+
+	if !fill {
+		return nil
+	}
+
+	perBacklog, err := strconv.Atoi(flagOrConfig(cmd, "focus-per-backlog", config.KeyBacklogFocusPer))
+	if err != nil || perBacklog < 0 {
+		fmt.Println("Invalid backlog.focus_per_backlog value: it must be zero or a positive number")
+		exit(1)
+	}
+
+	limit, err := strconv.Atoi(flagOrConfig(cmd, "focus-limit", config.KeyBacklogFocusLimit))
+	if err != nil || limit < 0 {
+		fmt.Println("Invalid backlog.focus_limit value: it must be zero (no limit) or a positive number")
+		exit(1)
+	}
+
+	overdueA, err := strconv.ParseBool(configValue(config.KeyBacklogFocusOverdueA))
+	if err != nil {
+		fmt.Println("Invalid backlog.focus_overdue_a value: it must be true or false")
+		exit(1)
+	}
+
+	return &backlog.FocusPolicy{PerBacklog: perBacklog, Limit: limit, OverduePriorityA: overdueA}
+}
+
+// watchBacklogs processes the backlogs on every change of the graph files in dir, until interrupted.
+func watchBacklogs(dir string, newProc func() backlog.Backlog, reader backlog.ConfigReader, partialNames []string,
+	configFile string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Each run is a run of its own in the undo journal, so "lqd undo" restores the last one only.
+	newRun := func() backlog.Backlog {
+		persist.NextRun()
+
+		return newProc()
+	}
+
+	return backlog.Watch(ctx, newRun, reader, partialNames, backlog.WatchOptions{ //nolint:wrapcheck
+		Dir:        dir,
+		ConfigPage: configValue(config.KeyBacklogConfigPage),
+		ConfigFile: configFile,
+		Debounce:   backlog.DefaultWatchDebounce,
+	})
+}
+
+// newBacklogConfigReader returns the reader of the backlog config: the config file when one is given,
+// otherwise the backlog config page of the graph.
+func newBacklogConfigReader(graph *logseq.Graph, configFile string) backlog.ConfigReader {
+	return backlogConfigReader(graph, configFile, configValue(config.KeyBacklogConfigPage))
+}
+
+// newGraphBacklogConfigReader is newBacklogConfigReader for a graph profile other than the selected one.
+func newGraphBacklogConfigReader(graph *logseq.Graph, target config.Graph) backlog.ConfigReader {
+	configPage := target.BacklogConfigPage
+	if configPage == "" {
+		configPage = configValue(config.KeyBacklogConfigPage)
+	}
+
+	return backlogConfigReader(graph, target.BacklogConfigFile, configPage)
+}
+
+func backlogConfigReader(graph *logseq.Graph, configFile, configPage string) backlog.ConfigReader {
+	if configFile != "" {
+		return backlog.NewFileConfigReader(configFile, configPage)
+	}
+
+	return backlog.NewPageConfigReader(graph, configPage)
+}
+
 func init() {
 	rootCmd.AddCommand(backlogCmd)
+
+	backlogCmd.Flags().String("config-file", "",
+		"TOML or YAML file declaring the backlogs, instead of the backlog config page")
+	defaultWorkers, _ := strconv.Atoi(config.Default(config.KeyBacklogWorkers))
+
+	backlogCmd.Flags().Int("workers", defaultWorkers, "How many input pages of a backlog are queried at the same time")
+	backlogCmd.Flags().Bool("watch", false,
+		"Keep running and process the backlogs again when the journals or pages change")
+	backlogCmd.Flags().Bool("fill-focus", false,
+		"Put the top ranked tasks of each backlog in its Focus section, within the focus limits")
+	defaultPerBacklog, _ := strconv.Atoi(config.Default(config.KeyBacklogFocusPer))
+	defaultLimit, _ := strconv.Atoi(config.Default(config.KeyBacklogFocusLimit))
+
+	backlogCmd.Flags().Int("focus-per-backlog", defaultPerBacklog,
+		"How many focus tasks a backlog can have with --fill-focus")
+	backlogCmd.Flags().Int("focus-limit", defaultLimit,
+		"How many tasks the Focus page can hold with --fill-focus (0 for no limit)")
 }
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// configFile is set by the global --config flag.
+var configFile string //nolint:gochecknoglobals
+
+// graphFlag is set by the global --graph flag.
+var graphFlag string //nolint:gochecknoglobals
+
+// loadedConfig caches the config file of the current run.
+var loadedConfig *config.Config //nolint:gochecknoglobals
+
+// configPath returns the config file chosen with --config, or the default location.
+func configPath() string {
+	if configFile != "" {
+		return configFile
+	}
+
+	return config.DefaultPath()
+}
+
+// loadConfig reads the config file once per path and selects the graph profile chosen with --graph,
+// LQD_GRAPH or logseq.graph.
+func loadConfig() (*config.Config, error) {
+	_, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if graphFlag != "" {
+		err = loadedConfig.SelectGraph(graphFlag, config.SourceFlag)
+	} else {
+		err = loadedConfig.SelectDefaultGraph()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to select graph: %w", err)
+	}
+
+	return loadedConfig, nil
+}
+
+// loadConfigFile reads the config file once per path, without selecting a graph profile.
+func loadConfigFile() (*config.Config, error) {
+	path := configPath()
+	if loadedConfig != nil && loadedConfig.Path() == path {
+		return loadedConfig, nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	loadedConfig = cfg
+
+	return cfg, nil
+}
+
+// appConfig returns the config of the current run, exiting if the file cannot be read:
+// running with half of the user's settings silently ignored would be worse.
+func appConfig() *config.Config {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println(err)
+		exit(1)
+	}
+
+	return cfg
+}
+
+// configValue returns the value of a setting, resolved from env var > config file > default.
+func configValue(key string) string {
+	return appConfig().Get(key)
+}
+
+// currentGraph returns the selected graph profile, or an unnamed one built from the global settings.
+func currentGraph() config.Graph {
+	return appConfig().CurrentGraph()
+}
+
+// flagOrConfig returns the value of a flag when it was given on the command line,
+// otherwise the value of the config setting.
+func flagOrConfig(cmd *cobra.Command, flagName, key string) string {
+	if flag := cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+
+	return configValue(key)
+}
+
+// NewConfigCmd creates the config command, which shows and edits the lqd config file.
+func NewConfigCmd(out io.Writer) *cobra.Command {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	cmd := &cobra.Command{ //nolint:exhaustruct
+		Use:   "config",
+		Short: "Show and edit the lqd config file",
+		Long: `Show and edit the lqd config file (TOML).
+
+The file is read from --config, $LQD_CONFIG, $XDG_CONFIG_HOME/lqd/config.toml or ~/.config/lqd/config.toml.
+Settings are resolved in this order: command-line flag > environment variable > config file > default.`,
+	}
+
+	cmd.AddCommand(newConfigShowCmd(out), newConfigGetCmd(out), newConfigSetCmd(out))
+
+	return cmd
+}
+
+func newConfigShowCmd(out io.Writer) *cobra.Command {
+	return &cobra.Command{ //nolint:exhaustruct
+		Use:   "show",
+		Short: "Show all settings with their values and where they come from",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "# %s\n", cfg.Path())
+
+			writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd
+
+			for _, setting := range config.Settings() {
+				value, source := cfg.Resolve(setting.Key)
+				if setting.Key == config.KeyGraph && graphFlag != "" {
+					value, source = graphFlag, config.SourceFlag
+				}
+
+				if setting.Secret && value != "" {
+					value = "********"
+				}
+
+				env := setting.Env
+				if env == "" {
+					env = "-"
+				}
+
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", setting.Key, quoteValue(value), source, env)
+			}
+
+			err = writeGraphProfiles(writer, cfg)
+			if err != nil {
+				return err
+			}
+
+			err = writer.Flush()
+			if err != nil {
+				return fmt.Errorf("failed to write settings: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newConfigGetCmd(out io.Writer) *cobra.Command {
+	return &cobra.Command{ //nolint:exhaustruct
+		Use:   "get key",
+		Short: "Print the resolved value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			if _, _, ok := config.SplitGraphKey(args[0]); ok {
+				value, _ := cfg.FileValue(args[0])
+				fmt.Fprintln(out, value)
+
+				return nil
+			}
+
+			_, err = config.Lookup(args[0])
+			if err != nil {
+				return fmt.Errorf("%w (run 'lqd config show' to list the keys)", err)
+			}
+
+			fmt.Fprintln(out, cfg.Get(args[0]))
+
+			return nil
+		},
+	}
+}
+
+func newConfigSetCmd(out io.Writer) *cobra.Command {
+	return &cobra.Command{ //nolint:exhaustruct
+		Use:   "set key value",
+		Short: "Write a setting to the config file",
+		Long: `Write a setting to the config file.
+List settings (e.g. tidy-up.forbidden_refs) take a comma-separated value.
+Graph profiles are edited with graphs.NAME.FIELD keys, e.g. "lqd config set graphs.work.path ~/logseq/work".`,
+		Args: cobra.ExactArgs(2), //nolint:mnd
+		RunE: func(_ *cobra.Command, args []string) error {
+			// No graph selection here: "set" must work to create the profile that LQD_GRAPH points to.
+			cfg, err := loadConfigFile()
+			if err != nil {
+				return err
+			}
+
+			err = cfg.Set(args[0], args[1])
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			err = cfg.Save()
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			fmt.Fprintf(out, "%s = %s written to %s\n", args[0], quoteValue(args[1]), cfg.Path())
+
+			return nil
+		},
+	}
+}
+
+// writeGraphProfiles lists the graph profiles, marking the selected one.
+func writeGraphProfiles(writer io.Writer, cfg *config.Config) error {
+	graphs, err := cfg.Graphs()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	selected := cfg.CurrentGraph().Name
+
+	for _, graph := range graphs {
+		marker := "profile"
+		if graph.Name == selected {
+			marker = "selected"
+		}
+
+		fmt.Fprintf(writer, "graphs.%s.path\t%s\t%s\t-\n", graph.Name, quoteValue(graph.Path), marker)
+	}
+
+	return nil
+}
+
+// quoteValue quotes empty values and values with spaces, so they stand out in the output.
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return fmt.Sprintf("%q", value)
+	}
+
+	return value
+}
+
+func init() { //nolint:gochecknoinits
+	rootCmd.AddCommand(NewConfigCmd(nil))
+}
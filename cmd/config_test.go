@@ -0,0 +1,62 @@
+package cmd_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/cmd"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+)
+
+func runConfigCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	command := cmd.NewConfigCmd(&buf)
+	command.SetArgs(args)
+	command.SetOut(&buf)
+	command.SetErr(&buf)
+
+	err := command.Execute()
+
+	return buf.String(), err
+}
+
+func TestConfigCmd_SetGetShow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("LQD_CONFIG", path)
+	t.Setenv("POCKETBASE_PASSWORD", "")
+
+	out, err := runConfigCmd(t, "set", config.KeyBacklogConfigPage, "my backlogs")
+	require.NoError(t, err)
+	assert.Contains(t, out, path)
+
+	_, err = runConfigCmd(t, "set", config.KeyPocketBasePassword, "secret")
+	require.NoError(t, err)
+
+	out, err = runConfigCmd(t, "get", config.KeyBacklogConfigPage)
+	require.NoError(t, err)
+	assert.Equal(t, "my backlogs\n", out)
+
+	out, err = runConfigCmd(t, "show")
+	require.NoError(t, err)
+	assert.Regexp(t, `backlog\.config_page\s+"my backlogs"\s+file`, out)
+	assert.Regexp(t, `groom\.older_than\s+"1 year"\s+default`, out)
+	assert.Regexp(t, `pocketbase\.password\s+\*+\s+file\s+POCKETBASE_PASSWORD`, out)
+	assert.NotContains(t, out, "secret")
+}
+
+func TestConfigCmd_UnknownKey(t *testing.T) {
+	t.Setenv("LQD_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+
+	_, err := runConfigCmd(t, "get", "nope.key")
+	require.ErrorIs(t, err, config.ErrUnknownKey)
+
+	_, err = runConfigCmd(t, "set", "nope.key", "x")
+	require.ErrorIs(t, err, config.ErrUnknownKey)
+}
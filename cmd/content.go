@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"log"
-	"os"
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal"
 	"github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +19,7 @@ var contentCmd = &cobra.Command{ //nolint:exhaustruct,gochecknoglobals
 Pipe your content via stdin.
 For now, it will be appended at the end of the current journal page.`,
 	Run: func(_ *cobra.Command, _ []string) {
-		graph := api.OpenGraphFromPath(os.Getenv("LOGSEQ_GRAPH_PATH"))
+		graph := api.OpenGraphFromPath(configValue(config.KeyGraphPath))
 		stdin := internal.ReadFromStdin()
 
 		var targetDate time.Time
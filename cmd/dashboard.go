@@ -21,6 +21,7 @@ import (
 
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-doctor/internal/dashboard"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/andreoliwa/logseq-doctor/internal/pocketbase"
@@ -28,16 +29,16 @@ import (
 )
 
 const (
-	defaultServePort     = 8091
-	defaultPocketBaseURL = "http://127.0.0.1:8090"
-	pbReadyTimeout       = 10 * time.Second
-	shutdownTimeout      = 5 * time.Second
-	readHeaderTimeout    = 5 * time.Second
+	defaultServePort  = 8091
+	pbReadyTimeout    = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	readHeaderTimeout = 5 * time.Second
 )
 
 func init() { //nolint:gochecknoinits
 	rootCmd.AddCommand(dashboardCmd)
-	dashboardCmd.Flags().IntP("port", "p", defaultServePort, "HTTP server port (also LQD_SERVE_PORT env var)")
+	dashboardCmd.Flags().IntP("port", "p", defaultServePort,
+		"HTTP server port (also LQD_SERVE_PORT env var or dashboard.port in the config file)")
 	dashboardCmd.Flags().Bool("status", false, "Also start lqd-statusbar as a background subprocess")
 }
 
@@ -57,16 +58,22 @@ Environment variables:
   POCKETBASE_USERNAME  PocketBase admin email
   POCKETBASE_PASSWORD  PocketBase admin password
   LOGSEQ_GRAPH_PATH    Path to Logseq graph (required for write-back)
-  LQD_SERVE_PORT       HTTP server port (default 8091)`,
+  LQD_SERVE_PORT       HTTP server port (default 8091)
+
+The same settings can be stored in the config file, see "lqd config show".`,
 	RunE: runDashboard,
 }
 
 func runDashboard(cmd *cobra.Command, _ []string) error {
 	port := ResolvePort(cmd)
-	pbURL := ResolveEnvWithDefault("POCKETBASE_URL", defaultPocketBaseURL)
-	pbUser := os.Getenv("POCKETBASE_USERNAME")
-	pbPass := os.Getenv("POCKETBASE_PASSWORD")
-	graphPath := os.Getenv("LOGSEQ_GRAPH_PATH")
+	pbURL := configValue(config.KeyPocketBaseURL)
+	pbUser := configValue(config.KeyPocketBaseUsername)
+	pbPass := configValue(config.KeyPocketBasePassword)
+
+	graphs, err := dashboardGraphs()
+	if err != nil {
+		return err
+	}
 
 	statusFlag, _ := cmd.Flags().GetBool("status")
 	if statusFlag {
@@ -109,7 +116,7 @@ func runDashboard(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	mux := BuildHTTPMux(pbURL, token, graphPath)
+	mux := BuildGraphsHTTPMux(pbURL, token, graphs)
 	uiURL := fmt.Sprintf("http://localhost:%d", port)
 
 	fmt.Fprintf(os.Stderr, "Backlog UI ready at %s\n", uiURL)
@@ -117,14 +124,18 @@ func runDashboard(cmd *cobra.Command, _ []string) error {
 	return startHTTPServer(cmd.Context(), port, mux)
 }
 
-// ResolvePort returns the effective port: flag > env var > default.
+// ResolvePort returns the effective port: flag > env var > config file > flag default.
+// Invalid values in the env var or in the config file are ignored.
 func ResolvePort(cmd *cobra.Command) int {
 	port, _ := cmd.Flags().GetInt("port")
+	if cmd.Flags().Changed("port") {
+		return port
+	}
 
-	if envPort := os.Getenv("LQD_SERVE_PORT"); envPort != "" {
-		p, convErr := strconv.Atoi(envPort)
+	if value := configValue(config.KeyDashboardPort); value != "" {
+		p, convErr := strconv.Atoi(value)
 		if convErr == nil {
-			port = p
+			return p
 		}
 	}
 
@@ -154,8 +165,61 @@ func authenticate(pbURL, pbUser, pbPass string) (string, error) {
 	return pb.Token(), nil
 }
 
-// BuildHTTPMux creates the HTTP mux with all routes registered.
+// dashboardGraphs returns the graphs the dashboard can switch between: the selected one first,
+// then the other graph profiles of the config file.
+func dashboardGraphs() ([]config.Graph, error) {
+	cfg := appConfig()
+	current := cfg.CurrentGraph()
+
+	profiles, err := cfg.Graphs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph profiles: %w", err)
+	}
+
+	graphs := make([]config.Graph, 0, len(profiles)+1)
+	if current.Name != "" || current.Path != "" || len(profiles) == 0 {
+		graphs = append(graphs, current)
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == current.Name {
+			continue
+		}
+
+		graph, graphErr := cfg.Graph(profile.Name)
+		if graphErr != nil {
+			return nil, fmt.Errorf("failed to read graph profile: %w", graphErr)
+		}
+
+		graphs = append(graphs, graph)
+	}
+
+	return graphs, nil
+}
+
+// findDashboardGraph returns the graph with the given profile name, or the first (default) graph for an empty name.
+func findDashboardGraph(graphs []config.Graph, name string) (config.Graph, bool) {
+	for _, graph := range graphs {
+		if name == "" || graph.Name == name {
+			return graph, true
+		}
+	}
+
+	return config.Graph{}, false //nolint:exhaustruct
+}
+
+// BuildHTTPMux creates the HTTP mux with all routes registered, for a single graph without a profile.
 func BuildHTTPMux(pbURL, token, graphPath string) *http.ServeMux {
+	return BuildGraphsHTTPMux(pbURL, token, []config.Graph{{ //nolint:exhaustruct
+		Path:                 graphPath,
+		PocketBaseCollection: pocketbase.TasksCollection,
+		BacklogConfigFile:    configValue(config.KeyBacklogConfigFile),
+	}})
+}
+
+// BuildGraphsHTTPMux creates the HTTP mux with all routes registered.
+// The first graph is the default; the UI switches to the others with the graph query parameter.
+func BuildGraphsHTTPMux(pbURL, token string, graphs []config.Graph) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /", func(writer http.ResponseWriter, _ *http.Request) {
@@ -173,31 +237,42 @@ func BuildHTTPMux(pbURL, token, graphPath string) *http.ServeMux {
 		_, _ = writer.Write(backlogCSS)
 	})
 
-	mux.HandleFunc("GET /internal/config", func(writer http.ResponseWriter, _ *http.Request) {
-		handleConfig(writer, graphPath) //nolint:contextcheck // logseq-go graph API has no context support
+	mux.HandleFunc("GET /internal/config", func(writer http.ResponseWriter, req *http.Request) {
+		graph, ok := findDashboardGraph(graphs, req.URL.Query().Get("graph"))
+		if !ok {
+			http.Error(writer, "unknown graph: "+req.URL.Query().Get("graph"), http.StatusNotFound)
+
+			return
+		}
+
+		handleConfig(writer, graph, graphs) //nolint:contextcheck // logseq-go graph API has no context support
 	})
 
 	mux.HandleFunc("POST /internal/move-to-unranked", func(writer http.ResponseWriter, req *http.Request) {
 		//nolint:contextcheck // logseq-go graph API has no context support
-		handleMoveToUnranked(writer, req, graphPath, pbURL, token)
+		handleMoveToUnranked(writer, req, graphs, pbURL, token)
 	})
 
 	return mux
 }
 
-// handleConfig returns UI configuration derived from the server environment.
-func handleConfig(writer http.ResponseWriter, graphPath string) {
+// handleConfig returns UI configuration derived from the server environment, for the selected graph.
+func handleConfig(writer http.ResponseWriter, selected config.Graph, graphs []config.Graph) {
+	graphPath := selected.Path
 	graphName := ""
 	if graphPath != "" {
 		graphName = filepath.Base(graphPath)
 	}
 
-	// Build a map from short backlog name → full page title (e.g. "self" → "backlog/self").
+	// Build a map from short backlog name → full page title (e.g. "self" → "backlog/self"),
+	// and others to the backlog icon (e.g. "self" → "🧘") and to its WIP limits, for the backlogs with any.
 	backlogPages := map[string]string{}
+	backlogIcons := map[string]string{}
+	backlogLimits := map[string]backlog.Limits{}
 
 	if graphPath != "" {
 		graph := logseqapi.OpenGraphFromPath(graphPath)
-		reader := backlog.NewPageConfigReader(graph, "backlog")
+		reader := newGraphBacklogConfigReader(graph, selected)
 
 		cfg, readErr := reader.ReadConfig()
 		if readErr == nil {
@@ -206,12 +281,21 @@ func handleConfig(writer http.ResponseWriter, graphPath string) {
 			if cfg.FocusPage != "" {
 				shortName := filepath.Base(cfg.FocusPage)
 				backlogPages[shortName] = cfg.FocusPage
+				backlogIcons[shortName] = cfg.Icon(cfg.FocusPage)
 			}
 
 			for _, backlogCfg := range cfg.Backlogs {
 				// Key is the last path component (the short name stored in PocketBase).
 				shortName := filepath.Base(backlogCfg.BacklogPage)
 				backlogPages[shortName] = backlogCfg.BacklogPage
+
+				if backlogCfg.Icon != "" {
+					backlogIcons[shortName] = backlogCfg.Icon
+				}
+
+				if !backlogCfg.Limits.IsZero() {
+					backlogLimits[shortName] = backlogCfg.Limits
+				}
 			}
 		}
 	}
@@ -222,16 +306,36 @@ func handleConfig(writer http.ResponseWriter, graphPath string) {
 	// correct deep links to journal pages without hardcoding the format.
 	journalTitleFormat := logseqext.ReadJournalTitleFormat(graphPath)
 
+	// The graph profile names let the UI switch graphs; the collection and the profile name
+	// tell it where to read the tasks of the selected graph.
+	profileNames := make([]string, 0, len(graphs))
+
+	for _, graph := range graphs {
+		if graph.Name != "" {
+			profileNames = append(profileNames, graph.Name)
+		}
+	}
+
 	type configResponse struct {
-		GraphName          string            `json:"graphName"`
-		BacklogPages       map[string]string `json:"backlogPages"`
-		JournalTitleFormat string            `json:"journalTitleFormat"`
+		GraphName          string                    `json:"graphName"`
+		BacklogPages       map[string]string         `json:"backlogPages"`
+		BacklogIcons       map[string]string         `json:"backlogIcons"`
+		BacklogLimits      map[string]backlog.Limits `json:"backlogLimits"`
+		JournalTitleFormat string                    `json:"journalTitleFormat"`
+		Graph              string                    `json:"graph"`
+		Graphs             []string                  `json:"graphs"`
+		Collection         string                    `json:"collection"`
 	}
 
 	payload, err := json.Marshal(configResponse{
 		GraphName:          graphName,
 		BacklogPages:       backlogPages,
+		BacklogIcons:       backlogIcons,
+		BacklogLimits:      backlogLimits,
 		JournalTitleFormat: journalTitleFormat,
+		Graph:              selected.Name,
+		Graphs:             profileNames,
+		Collection:         selected.PocketBaseCollection,
 	})
 	if err != nil {
 		http.Error(writer, "marshal config: "+err.Error(), http.StatusInternalServerError)
@@ -244,11 +348,11 @@ func handleConfig(writer http.ResponseWriter, graphPath string) {
 }
 
 // resolveBacklogPage maps a short backlog name (e.g. "self") to its full page title
-// (e.g. "Backlogs/self") by reading the backlog config page from the graph.
+// (e.g. "Backlogs/self") by reading the backlog config of the graph.
 // Falls back to the short name if the config cannot be read or the name is not found.
-func resolveBacklogPage(graphPath, shortName string) string {
-	graph := logseqapi.OpenGraphFromPath(graphPath)
-	reader := backlog.NewPageConfigReader(graph, "backlog")
+func resolveBacklogPage(target config.Graph, shortName string) string {
+	graph := logseqapi.OpenGraphFromPath(target.Path)
+	reader := newGraphBacklogConfigReader(graph, target)
 
 	cfg, err := reader.ReadConfig()
 	if err != nil {
@@ -335,8 +439,9 @@ func gracefulShutdown(srv *http.Server) error {
 }
 
 // handleMoveToUnranked handles POST /internal/move-to-unranked.
-func handleMoveToUnranked(writer http.ResponseWriter, req *http.Request, graphPath, pbURL, token string) {
+func handleMoveToUnranked(writer http.ResponseWriter, req *http.Request, graphs []config.Graph, pbURL, token string) {
 	var body struct {
+		Graph       string   `json:"graph"`
 		BacklogPage string   `json:"backlogPage"`
 		UUIDs       []string `json:"uuids"`
 	}
@@ -355,13 +460,21 @@ func handleMoveToUnranked(writer http.ResponseWriter, req *http.Request, graphPa
 		return
 	}
 
+	graph, ok := findDashboardGraph(graphs, body.Graph)
+	if !ok {
+		http.Error(writer, "unknown graph: "+body.Graph, http.StatusNotFound)
+
+		return
+	}
+
+	graphPath := graph.Path
 	if graphPath == "" {
 		http.Error(writer, "LOGSEQ_GRAPH_PATH not set", http.StatusInternalServerError)
 
 		return
 	}
 
-	pageTitle := resolveBacklogPage(graphPath, body.BacklogPage)
+	pageTitle := resolveBacklogPage(graph, body.BacklogPage)
 
 	// body.UUIDs contains composite PocketBase record IDs (uuid_backlogname).
 	// MoveToUnranked needs bare block UUIDs to match ((uuid)) refs in the .md file.
@@ -385,7 +498,7 @@ func handleMoveToUnranked(writer http.ResponseWriter, req *http.Request, graphPa
 	pb := pocketbase.NewClientWithToken(pbURL, token)
 
 	for _, recordID := range body.UUIDs {
-		_ = pb.UpdateRecord("lqd_tasks", recordID, map[string]any{"section": backlog.SectionUnranked})
+		_ = pb.UpdateRecord(graph.PocketBaseCollection, recordID, map[string]any{"section": backlog.SectionUnranked})
 	}
 
 	writer.WriteHeader(http.StatusNoContent)
@@ -2,11 +2,15 @@ package cmd_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/andreoliwa/logseq-doctor/cmd"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -114,6 +118,21 @@ func TestResolvePort_EnvVarInvalid(t *testing.T) {
 	assert.Equal(t, 8091, port)
 }
 
+func TestResolvePort_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[dashboard]\nport = 8123\n"), 0o600))
+	t.Setenv("LQD_CONFIG", path)
+	t.Setenv("LQD_SERVE_PORT", "")
+
+	c := newTestDashboardCmd()
+	require.NoError(t, c.ParseFlags([]string{}))
+	assert.Equal(t, 8123, cmd.ResolvePort(c))
+
+	// An explicit flag still wins over the config file.
+	require.NoError(t, c.ParseFlags([]string{"--port", "9000"}))
+	assert.Equal(t, 9000, cmd.ResolvePort(c))
+}
+
 func TestBuildHTTPMux_Routes(t *testing.T) {
 	mux := cmd.BuildHTTPMux("http://127.0.0.1:8090", "token", "")
 
@@ -181,3 +200,40 @@ func TestBuildHTTPMux_ConfigNoGraphPath(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
 }
+
+func TestBuildGraphsHTTPMux_ConfigPerGraph(t *testing.T) {
+	graphs := []config.Graph{
+		{Name: "work", Path: "", HostURL: "", APIToken: "", PocketBaseCollection: "lqd_tasks"},
+		{Name: "personal", Path: "", HostURL: "", APIToken: "", PocketBaseCollection: "personal_tasks"},
+	}
+	mux := cmd.BuildGraphsHTTPMux("http://127.0.0.1:8090", "", graphs)
+
+	getConfig := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), "GET", "/internal/config"+query, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	var payload struct {
+		Graph      string   `json:"graph"`
+		Graphs     []string `json:"graphs"`
+		Collection string   `json:"collection"`
+	}
+
+	rec := getConfig("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	assert.Equal(t, "work", payload.Graph, "the first graph is the default")
+	assert.Equal(t, []string{"work", "personal"}, payload.Graphs)
+	assert.Equal(t, "lqd_tasks", payload.Collection)
+
+	rec = getConfig("?graph=personal")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	assert.Equal(t, "personal", payload.Graph)
+	assert.Equal(t, "personal_tasks", payload.Collection)
+
+	assert.Equal(t, http.StatusNotFound, getConfig("?graph=nope").Code)
+}
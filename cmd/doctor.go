@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/andreoliwa/logseq-doctor/internal/doctor"
+	"github.com/andreoliwa/logseq-go"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// errChecksFailed is returned by the doctor command when a check fails, so it exits with an error status.
+var errChecksFailed = errors.New("some checks failed")
+
+// doctorReport is the JSON output of the doctor command.
+type doctorReport struct {
+	OK     bool            `json:"ok"`
+	Checks []doctor.Result `json:"checks"`
+}
+
+// NewDoctorCmd creates the doctor command, which checks the lqd setup.
+func NewDoctorCmd(out io.Writer) *cobra.Command {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	var jsonFlag bool
+
+	cmd := &cobra.Command{ //nolint:exhaustruct
+		Use:   "doctor",
+		Short: "Check the graph, the Logseq API, PocketBase and the backlog config",
+		Long: `Check the lqd setup and report one line per check, with its status:
+
+- the graph path exists and has logseq/config.edn
+- the journal title and file name formats can be parsed
+- the Logseq API is reachable with a valid token (skipped in offline mode)
+- PocketBase answers its health endpoint, the credentials work, and the tasks collection has the expected fields
+- the backlog config page lists at least one backlog
+
+The command exits with an error status when a check fails; warnings don't.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			results := runDoctorChecks(time.Now())
+
+			if jsonFlag {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+
+				err := encoder.Encode(doctorReport{OK: !doctor.Failed(results), Checks: results})
+				if err != nil {
+					return fmt.Errorf("failed to encode the report: %w", err)
+				}
+			} else {
+				err := writeDoctorReport(out, results)
+				if err != nil {
+					return err
+				}
+			}
+
+			if doctor.Failed(results) {
+				return errChecksFailed
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonFlag, "json", false, "Output the report as JSON")
+
+	return cmd
+}
+
+// runDoctorChecks runs all the checks on the selected graph. Checks that need the graph are skipped without it.
+func runDoctorChecks(now time.Time) []doctor.Result {
+	graph := currentGraph()
+	graphResult := doctor.Graph(graph.Path)
+	graphOK := graphResult.Status == doctor.StatusOK
+
+	results := []doctor.Result{graphResult}
+
+	if graphOK {
+		results = append(results, doctor.JournalFormats(graph.Path, now)...)
+	} else {
+		results = append(results, doctor.Skip("no graph", doctor.CheckJournalTitles, doctor.CheckJournalFiles)...)
+	}
+
+	if offlineMode() {
+		results = append(results, doctor.Skip("offline mode: tasks are read from the graph files", doctor.CheckLogseqAPI)...)
+	} else {
+		api := logseqapi.NewLogseqAPI(graph.Path, graph.HostURL, graph.APIToken)
+		results = append(results, doctor.LogseqAPI(api, graph.HostURL))
+	}
+
+	results = append(results, doctor.PocketBase(configValue(config.KeyPocketBaseURL),
+		configValue(config.KeyPocketBaseUsername), configValue(config.KeyPocketBasePassword),
+		graph.PocketBaseCollection)...)
+
+	if !graphOK {
+		return append(results, doctor.Skip("no graph", doctor.CheckBacklogConfig)...)
+	}
+
+	lsGraph, err := logseq.Open(context.Background(), graph.Path)
+	if err != nil {
+		return append(results, doctor.Result{
+			Check: doctor.CheckBacklogConfig, Status: doctor.StatusFail, Message: err.Error(),
+		})
+	}
+
+	configFile := configValue(config.KeyBacklogConfigFile)
+	reader := newBacklogConfigReader(lsGraph, configFile)
+
+	return append(results, doctor.BacklogConfig(graph.Path, configValue(config.KeyBacklogConfigPage), configFile, reader))
+}
+
+// writeDoctorReport prints one line per check: its status, name and message.
+func writeDoctorReport(out io.Writer, results []doctor.Result) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	for _, result := range results {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", doctorStatus(result.Status), result.Check, result.Message)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write the report: %w", err)
+	}
+
+	return nil
+}
+
+func doctorStatus(status doctor.Status) string {
+	label := "[" + string(status) + "]"
+
+	switch status {
+	case doctor.StatusOK:
+		return color.GreenString(label)
+	case doctor.StatusWarn:
+		return color.YellowString(label)
+	case doctor.StatusFail:
+		return color.RedString(label)
+	case doctor.StatusSkip:
+		return label
+	}
+
+	return label
+}
+
+func init() { //nolint:gochecknoinits
+	rootCmd.AddCommand(NewDoctorCmd(nil))
+}
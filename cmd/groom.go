@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-doctor/internal/groom"
 	"github.com/andreoliwa/logseq-doctor/internal/pocketbase"
 	logseq "github.com/andreoliwa/logseq-go"
@@ -18,11 +20,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const groomDefaultLimit = 10
-const groomDefaultOlderThan = "1 year"
 const groomFetchMultiplier = 5 // fetch 5× the limit to absorb tasks filtered out by HasFutureDate
 
-// errGroomNoCollection is returned when the lqd_tasks collection does not exist in PocketBase.
+// errGroomNoCollection is returned when the tasks collection does not exist in PocketBase.
 var errGroomNoCollection = errors.New("no tasks found. Run 'lqd sync --init' first")
 
 // GroomDependencies holds all injectable dependencies for the groom command.
@@ -40,23 +40,29 @@ func NewGroomCmd(deps *GroomDependencies) *cobra.Command {
 		}
 	}
 
-	var (
-		olderThan string
-		limit     int
-	)
-
 	cmd := &cobra.Command{ //nolint:exhaustruct
 		Use:   "groom",
 		Short: "Interactively review and groom stale tasks",
 		Long:  "Queries PocketBase for old ungroomed tasks and presents them one at a time for action.",
-		Run: func(_ *cobra.Command, _ []string) {
+		Run: func(command *cobra.Command, _ []string) {
+			olderThan := flagOrConfig(command, "older-than", config.KeyGroomOlderThan)
+
+			limit, err := strconv.Atoi(flagOrConfig(command, "limit", config.KeyGroomLimit))
+			if err != nil {
+				fmt.Printf("Invalid groom.limit value: %v\n", err)
+				exit(1)
+			}
+
 			runGroomWith(deps.TimeNow(), olderThan, limit)
 		},
 	}
 
-	cmd.Flags().StringVar(&olderThan, "older-than", groomDefaultOlderThan,
+	// groom.older_than and groom.limit in the config file take over when the flags are not given.
+	defaultLimit, _ := strconv.Atoi(config.Default(config.KeyGroomLimit))
+
+	cmd.Flags().String("older-than", config.Default(config.KeyGroomOlderThan),
 		"Task age threshold (e.g. \"5 years\", \"90 days\")")
-	cmd.Flags().IntVar(&limit, "limit", groomDefaultLimit, "Maximum tasks to review")
+	cmd.Flags().Int("limit", defaultLimit, "Maximum tasks to review")
 
 	return cmd
 }
@@ -70,13 +76,13 @@ func runGroomWith(now time.Time, olderThan string, limit int) {
 	thresholdDate, err := groom.CalculateThresholdDate(now, olderThan)
 	if err != nil {
 		fmt.Printf("Invalid --older-than value: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	pbClient, tasks, err := fetchGroomTasks(now, thresholdDate, limit)
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	if tasks == nil {
@@ -86,20 +92,19 @@ func runGroomWith(now time.Time, olderThan string, limit int) {
 	graph, api, backlogConfig, err := openGroomResources()
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	fmt.Println(groomStyles.warning.Render("Note: avoid editing tasks in Logseq while grooming."))
-
+	collection := currentGraph().PocketBaseCollection
 	pbUpdater := func(recordID string, groomedAt time.Time) error {
-		return pbClient.UpdateRecord("lqd_tasks", recordID, map[string]any{
+		return pbClient.UpdateRecord(collection, recordID, map[string]any{
 			"groomed": groomedAt.UTC().Format("2006-01-02 15:04:05.000Z"),
 		})
 	}
 
 	counts := processGroomTasks(tasks, now, graph, api, backlogConfig, pbUpdater)
 
-	allTasks, _ := pbClient.FetchRecords("lqd_tasks", groom.BuildGroomFilter(now, thresholdDate), "")
+	allTasks, _ := pbClient.FetchRecords(collection, groomFilter(now, thresholdDate), "")
 	remaining := len(allTasks)
 
 	fmt.Print(groom.FormatGroomSummary(counts, remaining, olderThan))
@@ -108,29 +113,29 @@ func runGroomWith(now time.Time, olderThan string, limit int) {
 // fetchGroomTasks initialises PocketBase, checks the collection, and fetches matching tasks.
 // Returns (nil, nil, nil) with a printed message when there are no tasks.
 func fetchGroomTasks(now, thresholdDate time.Time, limit int) (*pocketbase.Client, []map[string]any, error) {
-	pbURL := os.Getenv("POCKETBASE_URL")
-	if pbURL == "" {
-		pbURL = "http://127.0.0.1:8090"
-	}
+	pbURL := configValue(config.KeyPocketBaseURL)
 
-	pbClient, err := pocketbase.NewClient(pbURL, os.Getenv("POCKETBASE_USERNAME"), os.Getenv("POCKETBASE_PASSWORD"))
+	pbClient, err := pocketbase.NewClient(pbURL,
+		configValue(config.KeyPocketBaseUsername), configValue(config.KeyPocketBasePassword))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to connect to PocketBase: %w", err)
 	}
 
-	exists, err := pbClient.CollectionExists("lqd_tasks")
+	collection := currentGraph().PocketBaseCollection
+
+	exists, err := pbClient.CollectionExists(collection)
 	if err != nil || !exists {
 		return nil, nil, errGroomNoCollection
 	}
 
-	filter := groom.BuildGroomFilter(now, thresholdDate)
+	filter := groomFilter(now, thresholdDate)
 
 	// Fetch more than the limit to account for tasks filtered out by HasRecentDate.
 	// PocketBase date fields store null (not empty string) when unset, so scheduled/deadline
 	// comparisons in the query string are unreliable — we filter in Go instead. See CLAUDE.md.
 	fetchLimit := limit * groomFetchMultiplier
 
-	rawTasks, err := pbClient.FetchRecords("lqd_tasks", filter, "journal", fetchLimit)
+	rawTasks, err := pbClient.FetchRecords(collection, filter, "journal", fetchLimit)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to query tasks: %w", err)
 	}
@@ -156,13 +161,18 @@ func fetchGroomTasks(now, thresholdDate time.Time, limit int) (*pocketbase.Clien
 	return pbClient, tasks, nil
 }
 
+// groomFilter is the PocketBase filter of the groom queue, restricted to the selected graph profile.
+func groomFilter(now, thresholdDate time.Time) string {
+	return pocketbase.GraphFilter(groom.BuildGroomFilter(now, thresholdDate), currentGraph().Name)
+}
+
 // openGroomResources opens the Logseq graph, API, and reads the backlog config.
 func openGroomResources() (*logseq.Graph, api.LogseqAPI, *backlog.Config, error) {
-	path := os.Getenv("LOGSEQ_GRAPH_PATH")
+	path := configValue(config.KeyGraphPath)
 	graph := api.OpenGraphFromPath(path)
-	api := api.NewLogseqAPI(path, os.Getenv("LOGSEQ_HOST_URL"), os.Getenv("LOGSEQ_API_TOKEN"))
+	api := newLogseqAPI(path, true)
 
-	configReader := backlog.NewPageConfigReader(graph, "backlog")
+	configReader := newBacklogConfigReader(graph, configValue(config.KeyBacklogConfigFile))
 
 	backlogConfig, err := configReader.ReadConfig()
 	if err != nil {
@@ -174,7 +184,7 @@ func openGroomResources() (*logseq.Graph, api.LogseqAPI, *backlog.Config, error)
 
 // logseqGraphName extracts the graph name from the graph path for deep links.
 func logseqGraphName() string {
-	path := os.Getenv("LOGSEQ_GRAPH_PATH")
+	path := configValue(config.KeyGraphPath)
 	if path == "" {
 		return "my-graph"
 	}
@@ -307,8 +317,8 @@ func groomHandleTask(
 		opts := &groom.WriteOpts{
 			FocusPageTitle:       backlogConfig.FocusPage,
 			BacklogPageTitle:     backlogConfig.FindBacklogPageTitle(backlogName),
-			TriagedSectionText:   backlog.HeaderTriaged.Label,
-			ScheduledSectionText: backlog.HeaderScheduled.Label,
+			TriagedSectionText:   groomTriagedSectionText(),
+			ScheduledSectionText: backlog.Headers().Get(backlog.SectionNameScheduled).Label,
 			CurrentTime:          time.Now,
 		}
 
@@ -327,6 +337,17 @@ func groomHandleTask(
 	}
 }
 
+// groomTriagedSectionText returns the label of the Triaged section, or an empty string when it is disabled:
+// prioritised tasks then stay where they are on the backlog page.
+func groomTriagedSectionText() string {
+	registry := backlog.Headers()
+	if !registry.Enabled(backlog.SectionNameTriaged) {
+		return ""
+	}
+
+	return registry.Get(backlog.SectionNameTriaged).Label
+}
+
 func groomPrintApplyError(applyErr error, counts *groom.Counts) {
 	counts.Skipped++
 
@@ -18,11 +18,10 @@ import (
 	"fyne.io/systray"
 	"github.com/andreoliwa/logseq-go/content"
 
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 )
 
-const defaultDashboardPort = "8091"
-
 const (
 	tickerIntervalSecs = 2
 	iconIdle           = "🛑"
@@ -64,18 +63,25 @@ func doingIcon(count int) string {
 }
 
 func main() {
-	graphPath := os.Getenv("LOGSEQ_GRAPH_PATH")
+	cfg, err := config.Load(config.DefaultPath())
+	if err == nil {
+		err = cfg.SelectDefaultGraph()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	graphPath := cfg.Get(config.KeyGraphPath)
 	if graphPath == "" {
-		fmt.Fprintln(os.Stderr, "LOGSEQ_GRAPH_PATH is not set")
+		fmt.Fprintln(os.Stderr, "LOGSEQ_GRAPH_PATH is not set (or logseq.graph_path in the config file)")
 		os.Exit(1)
 	}
 
 	graphName := filepath.Base(graphPath)
 
-	port := os.Getenv("LQD_SERVE_PORT")
-	if port == "" {
-		port = defaultDashboardPort
-	}
+	port := cfg.Get(config.KeyDashboardPort)
 
 	dashboardURL := "http://localhost:" + port
 
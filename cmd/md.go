@@ -1,11 +1,11 @@
 package cmd
 
 import (
-	"os"
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal"
 	api "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/spf13/cobra"
 )
@@ -52,7 +52,7 @@ Examples:
   echo "Updated content" | lqd md --key "unique identifier"
   echo "Update work item" | lqd md --page "Projects" --key "feature-123"`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			graphPath := os.Getenv("LOGSEQ_GRAPH_PATH")
+			graphPath := configValue(config.KeyGraphPath)
 			stdin := deps.ReadStdin()
 			graph := deps.OpenGraph(graphPath)
 
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+)
+
+// offlineFlag is set by the global --offline flag.
+var offlineFlag bool //nolint:gochecknoglobals
+
+// offlineMode reports whether commands should read the graph files instead of calling the Logseq HTTP API,
+// either because of --offline or because of the logseq.offline setting (LQD_OFFLINE=1).
+func offlineMode() bool {
+	if offlineFlag {
+		return true
+	}
+
+	return appConfig().GetBool(config.KeyOffline)
+}
+
+// newLogseqAPI returns the Logseq API for the graph at path: the HTTP API of a running Logseq by default,
+// or an index of the graph files in offline mode.
+// Commands that reference tasks by UUID on disk (backlog, sync, groom) pass writeIDs, so the offline index
+// writes the id:: property of the tasks it returns, as Logseq would.
+func newLogseqAPI(path string, writeIDs bool) logseqapi.LogseqAPI {
+	graph := currentGraph()
+	graph.Path = path
+
+	return newGraphLogseqAPI(graph, writeIDs)
+}
+
+// newGraphLogseqAPI is newLogseqAPI for a graph profile other than the selected one.
+func newGraphLogseqAPI(graph config.Graph, writeIDs bool) logseqapi.LogseqAPI {
+	if offlineMode() {
+		return logseqapi.NewOfflineLogseqAPI(graph.Path, logseqapi.OfflineOptions{WriteMissingIDs: writeIDs})
+	}
+
+	return logseqapi.NewLogseqAPI(graph.Path, graph.HostURL, graph.APIToken)
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/andreoliwa/logseq-doctor/internal"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +45,7 @@ func fillOutlineDeps(deps *OutlineDependencies) {
 
 	if deps.WriteFile == nil {
 		deps.WriteFile = func(path string, data string) error {
-			return os.WriteFile(path, []byte(data), 0o600) //nolint:mnd
+			return persist.WriteFile(path, []byte(data), 0o600) //nolint:mnd,wrapcheck
 		}
 	}
 
@@ -57,7 +58,7 @@ func fillOutlineDeps(deps *OutlineDependencies) {
 	}
 
 	if deps.Remove == nil {
-		deps.Remove = os.Remove
+		deps.Remove = persist.Remove
 	}
 
 	if deps.Stdin == nil {
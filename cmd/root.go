@@ -1,11 +1,24 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/spf13/cobra"
 )
 
+// dryRunFlag is set by the global --dry-run flag.
+var dryRunFlag bool //nolint:gochecknoglobals
+
+// allowRewritesFlag is set by the global --allow-rewrites flag.
+var allowRewritesFlag bool //nolint:gochecknoglobals
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{ //nolint:exhaustruct,gochecknoglobals
 	Use:   "lqd",
@@ -14,6 +27,13 @@ var rootCmd = &cobra.Command{ //nolint:exhaustruct,gochecknoglobals
 
 Convert flat Markdown to Logseq outline, clean up Markdown,
 prevent invalid content, and more stuff to come.`,
+	// A hook on the root command rather than cobra.OnInitialize, so commands built on their own in tests skip it.
+	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+		initDryRun()
+		initJournal()
+		initHeaders()
+		persist.SetAllowRewrites(allowRewritesFlag)
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -24,7 +44,94 @@ prevent invalid content, and more stuff to come.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		exit(1)
+	}
+
+	cleanupTempFiles()
+}
+
+// exit ends the process with the given code, after removing the temporary copies of the graph files.
+func exit(code int) {
+	cleanupTempFiles()
+	os.Exit(code)
+}
+
+func cleanupTempFiles() {
+	err := persist.Cleanup()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// initJournal records the writes of this run in the undo journal, so "lqd undo" can restore the files.
+func initJournal() {
+	if dryRunFlag {
+		return
+	}
+
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return // the command itself reports a broken config file
+	}
+
+	keep, err := cfg.GetInt(config.KeyUndoKeep)
+	if err != nil {
+		keep, _ = strconv.Atoi(config.Default(config.KeyUndoKeep))
+	}
+
+	persist.StartJournal(cfg.Get(config.KeyUndoDir), keep, commandLine(os.Args))
+}
+
+// initHeaders sets the section headers of backlog pages from the [backlog.headers.NAME] tables of the config file.
+// Invalid headers stop the run: writing backlog pages with the wrong headers would mangle them.
+func initHeaders() {
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return // the command itself reports a broken config file
+	}
+
+	registry, err := headerRegistry(cfg)
+	if err != nil {
+		fmt.Println(err)
+		exit(1)
+	}
+
+	backlog.SetHeaders(registry)
+}
+
+// headerRegistry reads the header settings of the config file.
+func headerRegistry(cfg *config.Config) (*backlog.HeaderRegistry, error) {
+	var section struct {
+		Headers map[string]backlog.HeaderSettings `toml:"headers"`
+	}
+
+	err := cfg.DecodeSection("backlog", &section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the backlog headers: %w", err)
+	}
+
+	return backlog.NewHeaderRegistry(section.Headers) //nolint:wrapcheck
+}
+
+// commandLine joins the arguments of the process, quoting the ones with spaces, as shown by "lqd history".
+func commandLine(args []string) string {
+	parts := make([]string, 0, len(args))
+
+	for i, arg := range args {
+		if i == 0 {
+			arg = filepath.Base(arg)
+		}
+
+		parts = append(parts, quoteValue(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// initDryRun turns on the dry-run mode chosen with --dry-run: diffs are printed instead of writing the files.
+func initDryRun() {
+	if dryRunFlag {
+		persist.SetDryRun(os.Stdout)
 	}
 }
 
@@ -33,7 +140,16 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.logseq-doctor.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"config file (default is $LQD_CONFIG or ~/.config/lqd/config.toml)")
+	rootCmd.PersistentFlags().StringVar(&graphFlag, "graph", "",
+		"Name of the graph profile to use, from [graphs.NAME] in the config file (same as LQD_GRAPH)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false,
+		"Read tasks from the graph files instead of the Logseq HTTP API (same as LQD_OFFLINE=1)")
+	rootCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false,
+		"Print a unified diff of each graph file instead of writing it")
+	rootCmd.PersistentFlags().BoolVar(&allowRewritesFlag, "allow-rewrites", false,
+		"Save pages even if blocks the command did not change would be rewritten")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
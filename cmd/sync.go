@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/andreoliwa/logseq-doctor/internal"
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/andreoliwa/logseq-doctor/internal/pocketbase"
 	lqdsync "github.com/andreoliwa/logseq-doctor/internal/sync"
@@ -15,6 +18,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// errNoGraphProfiles is returned by sync --all-graphs when the config file has no graph profiles.
+var errNoGraphProfiles = errors.New("no graph profiles: add [graphs.NAME] tables to the config file")
+
+// errCollectionNotFound is returned when the PocketBase tasks collection does not exist.
+var errCollectionNotFound = errors.New("collection not found")
+
 // SyncDependencies holds all injectable dependencies for the sync command.
 // This enables unit testing without connecting to PocketBase or Logseq.
 type SyncDependencies struct {
@@ -30,18 +39,22 @@ func NewSyncCmd(deps *SyncDependencies) *cobra.Command {
 		}
 	}
 
-	var initFlag bool
+	var initFlag, allGraphsFlag bool
 
 	cmd := &cobra.Command{ //nolint:exhaustruct
 		Use:   "sync",
 		Short: "Sync Logseq tasks to PocketBase",
-		Long:  "Reads backlog config and tasks from Logseq, calculates ranks, and upserts to PocketBase.",
+		Long: `Reads backlog config and tasks from Logseq, calculates ranks, and upserts to PocketBase.
+
+With graph profiles, each record gets a "graph" field with the profile name, so several graphs can share
+one collection: a sync only updates and deletes the records of its own graph.`,
 		Run: func(_ *cobra.Command, _ []string) {
-			runSyncWith(deps.TimeNow, initFlag)
+			runSyncWith(deps.TimeNow, initFlag, allGraphsFlag)
 		},
 	}
 
-	cmd.Flags().BoolVar(&initFlag, "init", false, "Drop and recreate lqd_tasks collection before syncing")
+	cmd.Flags().BoolVar(&initFlag, "init", false, "Drop and recreate the tasks collection before syncing")
+	cmd.Flags().BoolVar(&allGraphsFlag, "all-graphs", false, "Sync every graph profile of the config file")
 
 	return cmd
 }
@@ -51,66 +64,127 @@ func init() { //nolint:gochecknoinits
 }
 
 // runSyncWith is the testable core of runSync.
-func runSyncWith(currentTime func() time.Time, initFlag bool) {
-	path := os.Getenv("LOGSEQ_GRAPH_PATH")
-	logseqAPI := logseqapi.NewLogseqAPI(path, os.Getenv("LOGSEQ_HOST_URL"), os.Getenv("LOGSEQ_API_TOKEN"))
-	graph := logseqapi.OpenGraphFromPath(path)
-
-	pbURL := os.Getenv("POCKETBASE_URL")
-	if pbURL == "" {
-		pbURL = "http://127.0.0.1:8090"
+func runSyncWith(currentTime func() time.Time, initFlag, allGraphs bool) {
+	targets, err := syncTargets(allGraphs)
+	if err != nil {
+		fmt.Println(err)
+		exit(1)
 	}
 
-	pbClient, err := pocketbase.NewClient(pbURL, os.Getenv("POCKETBASE_USERNAME"), os.Getenv("POCKETBASE_PASSWORD"))
+	pbURL := configValue(config.KeyPocketBaseURL)
+
+	pbClient, err := pocketbase.NewClient(pbURL,
+		configValue(config.KeyPocketBaseUsername), configValue(config.KeyPocketBasePassword))
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	if initFlag {
-		err = initCollection(pbClient)
+	// Graphs can share a collection: it is only recreated once, before the first of them is synced.
+	initialized := make(map[string]bool)
+
+	for _, target := range targets {
+		collection := target.PocketBaseCollection
+
+		if initFlag && !initialized[collection] {
+			err = initCollection(pbClient, collection)
+			initialized[collection] = true
+		} else {
+			err = checkCollection(pbClient, collection)
+		}
+
 		if err != nil {
 			fmt.Println(err)
-			os.Exit(1)
+			exit(1)
+		}
+
+		if target.Name != "" {
+			fmt.Printf("Syncing graph %s into %s\n", target.Name, collection)
 		}
-	} else {
-		exists, existsErr := pbClient.CollectionExists("lqd_tasks")
-		if existsErr != nil {
-			fmt.Println(existsErr)
-			os.Exit(1)
+
+		graph := logseqapi.OpenGraphFromPath(target.Path)
+
+		err = runSyncPipeline(graph, newGraphLogseqAPI(target, true), pbClient, target, currentTime)
+		if err != nil {
+			fmt.Println(err)
+			exit(1)
 		}
+	}
+}
+
+// syncTargets returns the graphs to sync: the selected one, or every profile with --all-graphs.
+func syncTargets(allGraphs bool) ([]config.Graph, error) {
+	if !allGraphs {
+		return []config.Graph{currentGraph()}, nil
+	}
+
+	cfg := appConfig()
+
+	profiles, err := cfg.Graphs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph profiles: %w", err)
+	}
+
+	if len(profiles) == 0 {
+		return nil, errNoGraphProfiles
+	}
+
+	targets := make([]config.Graph, 0, len(profiles))
 
-		if !exists {
-			fmt.Println("Collection 'lqd_tasks' not found. Run 'lqd sync --init' to create it.")
-			os.Exit(1)
+	for _, profile := range profiles {
+		graph, graphErr := cfg.Graph(profile.Name)
+		if graphErr != nil {
+			return nil, fmt.Errorf("failed to read graph profile: %w", graphErr)
 		}
+
+		targets = append(targets, graph)
 	}
 
-	err = runSyncPipeline(graph, logseqAPI, pbClient, currentTime)
+	return targets, nil
+}
+
+// checkCollection fails with a hint to run --init when the tasks collection does not exist.
+// Fields added to the schema since the collection was created are added to it.
+func checkCollection(client *pocketbase.Client, collection string) error {
+	exists, err := client.CollectionExists(collection)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return fmt.Errorf("failed to check collection: %w", err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: '%s'. Run 'lqd sync --init' to create it", errCollectionNotFound, collection)
 	}
+
+	added, err := client.AddMissingFields(pocketbase.TasksSchema(collection))
+	if err != nil {
+		return fmt.Errorf("failed to migrate collection: %w. Run 'lqd sync --init' to recreate it", err)
+	}
+
+	if len(added) > 0 {
+		fmt.Printf("Added fields %s to the %s collection\n", strings.Join(added, ", "), collection)
+	}
+
+	return nil
 }
 
-func initCollection(client *pocketbase.Client) error {
-	exists, err := client.CollectionExists("lqd_tasks")
+func initCollection(client *pocketbase.Client, collection string) error {
+	exists, err := client.CollectionExists(collection)
 	if err != nil {
 		return fmt.Errorf("failed to check collection: %w", err)
 	}
 
 	if exists {
-		fmt.Println("Dropping existing lqd_tasks collection...")
+		fmt.Printf("Dropping existing %s collection...\n", collection)
 
-		err = client.DeleteCollection("lqd_tasks")
+		err = client.DeleteCollection(collection)
 		if err != nil {
 			return fmt.Errorf("failed to delete collection: %w", err)
 		}
 	}
 
-	fmt.Println("Creating lqd_tasks collection...")
+	fmt.Printf("Creating %s collection...\n", collection)
 
-	err = client.CreateCollection(pocketbase.LqdTasksSchema())
+	err = client.CreateCollection(pocketbase.TasksSchema(collection))
 	if err != nil {
 		return fmt.Errorf("failed to create collection: %w", err)
 	}
@@ -119,16 +193,17 @@ func initCollection(client *pocketbase.Client) error {
 }
 
 func runSyncPipeline(
-	graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI, pbClient *pocketbase.Client, currentTime func() time.Time,
+	graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI, pbClient *pocketbase.Client, target config.Graph,
+	currentTime func() time.Time,
 ) error {
-	reader := backlog.NewPageConfigReader(graph, "backlog")
+	reader := newGraphBacklogConfigReader(graph, target)
 
-	config, err := reader.ReadConfig()
+	backlogConfig, err := reader.ReadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to read backlog config: %w", err)
 	}
 
-	ranks, backlogOrder := collectBacklogRefs(graph, config)
+	ranks, backlogOrder := collectBacklogRefs(graph, backlogConfig)
 	fmt.Printf("Calculated ranks for %d unique tasks across %d backlogs\n", len(ranks), len(backlogOrder))
 
 	tasks, err := fetchLogseqTasks(logseqAPI)
@@ -136,6 +211,11 @@ func runSyncPipeline(
 		return err
 	}
 
+	err = reportLimitBreaches(graph, logseqAPI, backlogConfig, currentTime)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Building tag lookup table...")
 
 	refLookup := logseqapi.BuildRefLookup(tasks)
@@ -145,23 +225,18 @@ func runSyncPipeline(
 
 	tagsByUUID := logseqapi.EnrichTasksWithAncestorTags(tasks, refLookup)
 	desired := buildDesiredRecords(tasks, ranks, tagsByUUID, currentTime)
+	lqdsync.SetGraph(desired, target.Name)
 
-	return applyChanges(pbClient, desired)
+	return applyChanges(pbClient, target, desired)
 }
 
-// unrankedSectionTexts lists the header texts that mark the start of an unranked
-// section on a backlog page. Any block ref that is a child of one of these headers
-// backlogUnrankedSectionTexts returns the header texts that mark the start of an
-// unranked section on a backlog page. Any block ref that is a child of one of
-// these headers is assigned SectionUnranked during sync.
-func backlogUnrankedSectionTexts() []string {
-	return []string{
-		backlog.HeaderOverdue.Label,
-		backlog.HeaderNewTasks.Label,
-		backlog.HeaderTriaged.Label,
-		backlog.HeaderScheduled.Label,
-		backlog.HeaderUnranked.Label,
-	}
+// isBacklogUnrankedSection tells if a block text is a header that marks the start of an
+// unranked section on a backlog page: any enabled header but Focus. Any block ref that is a
+// child of one of these headers is assigned SectionUnranked during sync.
+func isBacklogUnrankedSection(text string) bool {
+	header, ok := backlog.Headers().Match(text)
+
+	return ok && header.Name != backlog.SectionNameFocus
 }
 
 // collectBacklogRefs scans the Focus page and all configured backlog pages,
@@ -174,10 +249,10 @@ func collectBacklogRefs(
 	ranks := make(map[string][]lqdsync.RankInfo)
 	backlogOrder := make([]string, 0, len(config.Backlogs)+1)
 
-	collectFocusRefs(graph, config.FocusPage, ranks, &backlogOrder)
+	collectFocusRefs(graph, config.FocusPage, config.Icon(config.FocusPage), ranks, &backlogOrder)
 
 	for _, bc := range config.Backlogs {
-		collectPageRefs(graph, bc.BacklogPage, ranks, &backlogOrder)
+		collectPageRefs(graph, bc.BacklogPage, bc.Icon, ranks, &backlogOrder)
 	}
 
 	fmt.Println()
@@ -186,7 +261,7 @@ func collectBacklogRefs(
 }
 
 func collectFocusRefs(
-	graph *logseq.Graph, focusPagePath string,
+	graph *logseq.Graph, focusPagePath, icon string,
 	ranks map[string][]lqdsync.RankInfo, backlogOrder *[]string,
 ) {
 	focusName := filepath.Base(focusPagePath)
@@ -203,6 +278,7 @@ func collectFocusRefs(
 	for pos, uuid := range focusUUIDs {
 		ranks[uuid] = append(ranks[uuid], lqdsync.RankInfo{
 			BacklogName:  focusName,
+			BacklogIcon:  icon,
 			BacklogIndex: focusIdx,
 			Section:      backlog.SectionRanked,
 			Rank:         pos + 1,
@@ -213,13 +289,13 @@ func collectFocusRefs(
 }
 
 func collectPageRefs(
-	graph *logseq.Graph, backlogPagePath string,
+	graph *logseq.Graph, backlogPagePath, icon string,
 	ranks map[string][]lqdsync.RankInfo, backlogOrder *[]string,
 ) {
 	pageName := filepath.Base(backlogPagePath)
 	page := logseqapi.OpenPage(graph, backlogPagePath)
 
-	sectioned := logseqext.ExtractSectionedBlockRefUUIDs(page, backlogUnrankedSectionTexts())
+	sectioned := logseqext.ExtractSectionedBlockRefUUIDs(page, isBacklogUnrankedSection)
 	if len(sectioned) == 0 {
 		return
 	}
@@ -238,6 +314,7 @@ func collectPageRefs(
 			rankedPos++
 			ranks[ref.UUID] = append(ranks[ref.UUID], lqdsync.RankInfo{
 				BacklogName:  pageName,
+				BacklogIcon:  icon,
 				BacklogIndex: backlogIdx,
 				Section:      backlog.SectionRanked,
 				Rank:         rankedPos,
@@ -246,6 +323,7 @@ func collectPageRefs(
 			unrankedPos++
 			ranks[ref.UUID] = append(ranks[ref.UUID], lqdsync.RankInfo{
 				BacklogName:  pageName,
+				BacklogIcon:  icon,
 				BacklogIndex: backlogIdx,
 				Section:      backlog.SectionUnranked,
 				Rank:         unrankedPos,
@@ -256,6 +334,45 @@ func collectPageRefs(
 	fmt.Printf("%s=%d ", pageName, len(sectioned))
 }
 
+// reportLimitBreaches prints the backlogs over their WIP limits. Unlike lqd backlog, it leaves the pages alone:
+// the warning block on a backlog page is written by the next lqd backlog run.
+// The DOING tasks are not on the backlog pages: they are counted from the tasks queried for each backlog.
+func reportLimitBreaches(graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI, config *backlog.Config,
+	currentTime func() time.Time,
+) error {
+	for _, bc := range config.Backlogs {
+		if bc.Limits.IsZero() {
+			continue
+		}
+
+		fmt.Printf("%s:", internal.PageColor(bc.BacklogPage))
+
+		doing := 0
+
+		if bc.Limits.Doing > 0 {
+			tasks, err := backlog.QueryTasksFromPages(graph, logseqAPI, bc, currentTime)
+			if err != nil {
+				fmt.Println()
+
+				return fmt.Errorf("failed to query the tasks of %s: %w", bc.BacklogPage, err)
+			}
+
+			doing = backlog.DoingCount(tasks.TaskLookup)
+		}
+
+		page := logseqapi.OpenPage(graph, bc.BacklogPage)
+		breaches := bc.Limits.Breaches(backlog.PageLoad(page, doing))
+
+		if len(breaches) > 0 {
+			backlog.ReportBreaches(breaches)
+		} else {
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
 func fetchLogseqTasks(logseqAPI logseqapi.LogseqAPI) ([]logseqapi.TaskJSON, error) {
 	query := "(and (task TODO DOING WAITING NOW LATER))"
 
@@ -313,18 +430,21 @@ func hasRankInBacklog(rankInfos []lqdsync.RankInfo, backlogName string) bool {
 	return false
 }
 
-func applyChanges(pbClient *pocketbase.Client, desired []map[string]any) error {
-	existing, err := pbClient.FetchRecords("lqd_tasks", "", "")
+func applyChanges(pbClient *pocketbase.Client, target config.Graph, desired []map[string]any) error {
+	collection := target.PocketBaseCollection
+
+	existing, err := pbClient.FetchRecords(collection, "", "")
 	if err != nil {
 		return fmt.Errorf("failed to fetch existing records: %w", err)
 	}
 
+	existing = lqdsync.ScopeToGraph(existing, desired, target.Name)
 	fmt.Printf("Found %d existing records in PocketBase\n", len(existing))
 
 	toCreate, toUpdate, toDelete := lqdsync.DiffRecords(existing, desired)
 
 	for _, record := range toCreate {
-		createErr := pbClient.CreateRecord("lqd_tasks", record)
+		createErr := pbClient.CreateRecord(collection, record)
 		if createErr != nil {
 			fmt.Printf("Warning: failed to create %s: %v\n", record["id"], createErr)
 		}
@@ -333,14 +453,14 @@ func applyChanges(pbClient *pocketbase.Client, desired []map[string]any) error {
 	for _, record := range toUpdate {
 		id, _ := record["id"].(string)
 
-		updateErr := pbClient.UpdateRecord("lqd_tasks", id, record)
+		updateErr := pbClient.UpdateRecord(collection, id, record)
 		if updateErr != nil {
 			fmt.Printf("Warning: failed to update %s: %v\n", id, updateErr)
 		}
 	}
 
 	for _, id := range toDelete {
-		deleteErr := pbClient.DeleteRecord("lqd_tasks", id)
+		deleteErr := pbClient.DeleteRecord(collection, id)
 		if deleteErr != nil {
 			fmt.Printf("Warning: failed to delete %s: %v\n", id, deleteErr)
 		}
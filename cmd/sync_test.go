@@ -28,6 +28,10 @@ func TestNewSyncCmd_DefaultFlags(t *testing.T) {
 	initFlag := syncCmd.Flags().Lookup("init")
 	require.NotNil(t, initFlag)
 	assert.Equal(t, "false", initFlag.DefValue)
+
+	allGraphsFlag := syncCmd.Flags().Lookup("all-graphs")
+	require.NotNil(t, allGraphsFlag)
+	assert.Equal(t, "false", allGraphsFlag.DefValue)
 }
 
 func TestNewSyncCmd_WithCustomDeps(t *testing.T) {
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -10,7 +12,10 @@ import (
 	"github.com/fatih/color"
 
 	"github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +34,16 @@ type TaskAddDependencies struct {
 type TaskLsDependencies struct {
 	NewAPI    func() api.LogseqAPI
 	GraphName func() string
+	// BacklogIcons maps task UUIDs to the icons of the backlogs they are on.
+	BacklogIcons func() map[string]string
+	Out          io.Writer
+}
+
+// TaskDoneDependencies holds all the dependencies for the task done command.
+type TaskDoneDependencies struct {
+	NewAPI    func() api.LogseqAPI
+	OpenGraph func(string) *logseq.Graph
+	TimeNow   func() time.Time
 	Out       io.Writer
 }
 
@@ -73,7 +88,7 @@ Examples:
   lqd task add "Meeting notes" --parent "Project A"`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
-			graphPath := os.Getenv("LOGSEQ_GRAPH_PATH")
+			graphPath := configValue(config.KeyGraphPath)
 			graph := deps.OpenGraph(graphPath)
 
 			targetDate, err := ParseDateFromJournalFlag(journalFlag, deps.TimeNow)
@@ -82,16 +97,21 @@ Examples:
 			}
 
 			opts := &logseqext.AddTaskOptions{
-				Graph:     graph,
-				Date:      targetDate,
-				Page:      pageFlag,
-				BlockText: parentFlag,
-				Key:       keyFlag,
-				Name:      args[0],
-				TimeNow:   deps.TimeNow,
+				Graph:       graph,
+				Transaction: nil,
+				Date:        targetDate,
+				Page:        pageFlag,
+				BlockText:   parentFlag,
+				Key:         keyFlag,
+				Name:        args[0],
+				TimeNow:     deps.TimeNow,
 			}
 
-			return deps.AddTaskFn(opts)
+			return persist.RetryOnConflict(func() error { //nolint:wrapcheck
+				opts.Transaction = persist.NewTransaction(graph)
+
+				return deps.AddTaskFn(opts)
+			})
 		},
 	}
 
@@ -110,6 +130,8 @@ type taskLsFlags struct {
 	canceled  bool
 	done      bool
 	completed bool
+	blocked   bool
+	unblocked bool
 	json      bool
 	verbose   bool
 }
@@ -135,6 +157,13 @@ func runTaskLs(deps *TaskLsDependencies, flags *taskLsFlags, args []string) erro
 		return fmt.Errorf("failed to query Logseq API: %w", err)
 	}
 
+	if flags.blocked || flags.unblocked {
+		jsonStr, err = filterBlockedTasks(client, jsonStr, flags.blocked)
+		if err != nil {
+			return err
+		}
+	}
+
 	if flags.json {
 		fmt.Fprintln(out, jsonStr)
 
@@ -149,12 +178,17 @@ func runTaskLs(deps *TaskLsDependencies, flags *taskLsFlags, args []string) erro
 	api.SortTasksByDate(tasks)
 
 	graphName := deps.GraphName()
+	icons := deps.BacklogIcons()
 
 	green := color.New(color.FgGreen)
 	blueBold := color.New(color.FgBlue, color.Bold)
 
 	for _, task := range tasks {
 		firstLine := strings.SplitN(task.Content, "\n", firstLineCount)[0]
+		if icon := icons[task.UUID]; icon != "" {
+			firstLine = icon + " " + firstLine
+		}
+
 		url := fmt.Sprintf("logseq://graph/%s?block-id=%s", graphName, task.UUID)
 		fmt.Fprintf(out, "%s%s%s\n",
 			green.Sprint(task.Page.OriginalName+"§"),
@@ -166,25 +200,65 @@ func runTaskLs(deps *TaskLsDependencies, flags *taskLsFlags, args []string) erro
 	return nil
 }
 
+// filterBlockedTasks keeps the tasks of a query result that wait for open blocked-by:: tasks, or the ones that don't,
+// warning about the tasks that block each other.
+func filterBlockedTasks(client api.LogseqAPI, jsonStr string, blocked bool) (string, error) {
+	tasks, err := api.ExtractTasksFromJSON(jsonStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract tasks: %w", err)
+	}
+
+	// The raw entries keep the fields TaskJSON doesn't have, for --json.
+	var raw []json.RawMessage
+
+	err = json.Unmarshal([]byte(jsonStr), &raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract tasks: %w", err)
+	}
+
+	deps := api.ResolveDependencies(client, tasks)
+	lookup := make(map[api.TaskUUID]api.TaskJSON, len(tasks))
+
+	for _, task := range tasks {
+		lookup[task.UUID] = task
+	}
+
+	for _, cycle := range deps.Cycles {
+		fmt.Fprintf(os.Stderr, "warning: dependency cycle: %s\n", api.FormatCycle(cycle, lookup))
+	}
+
+	kept := make([]json.RawMessage, 0, len(raw))
+
+	for i, task := range tasks {
+		if deps.Blocked.Contains(task.UUID) == blocked {
+			kept = append(kept, raw[i])
+		}
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+
+	return string(data), nil
+}
+
 // NewTaskLsCmd creates a new task ls subcommand with the specified dependencies.
 // If deps is nil, it uses default implementations. Individual nil fields also fall back
 // to their defaults, so a test can inject only LogseqAPI + Out and leave GraphName defaulted.
 func NewTaskLsCmd(deps *TaskLsDependencies) *cobra.Command {
 	if deps == nil {
 		deps = &TaskLsDependencies{
-			NewAPI:    nil,
-			GraphName: nil,
-			Out:       nil,
+			NewAPI:       nil,
+			GraphName:    nil,
+			BacklogIcons: nil,
+			Out:          nil,
 		}
 	}
 
 	if deps.NewAPI == nil {
 		deps.NewAPI = func() api.LogseqAPI {
-			return api.NewLogseqAPI(
-				os.Getenv("LOGSEQ_GRAPH_PATH"),
-				os.Getenv("LOGSEQ_HOST_URL"),
-				os.Getenv("LOGSEQ_API_TOKEN"),
-			)
+			return newLogseqAPI(configValue(config.KeyGraphPath), false)
 		}
 	}
 
@@ -192,6 +266,10 @@ func NewTaskLsCmd(deps *TaskLsDependencies) *cobra.Command {
 		deps.GraphName = logseqGraphName
 	}
 
+	if deps.BacklogIcons == nil {
+		deps.BacklogIcons = taskBacklogIcons
+	}
+
 	if deps.Out == nil {
 		deps.Out = os.Stdout
 	}
@@ -201,15 +279,19 @@ func NewTaskLsCmd(deps *TaskLsDependencies) *cobra.Command {
 	cmd := &cobra.Command{ //nolint:exhaustruct
 		Use:   "ls [tag...]",
 		Short: "List tasks from Logseq",
-		Long: `List tasks from your Logseq graph via the HTTP API.
+		Long: `List tasks from your Logseq graph via the HTTP API (or the graph files with --offline).
 
 Positional arguments filter by tag or page reference. Multiple tags are combined with OR.
+Tasks on backlog pages are prefixed with the icons of their backlogs.
+--blocked lists the tasks waiting for a task in their blocked-by:: property that is not DONE or CANCELED yet,
+--unblocked the other ones.
 
 Examples:
   lqd task ls
   lqd task ls work
   lqd task ls --done --canceled
   lqd task ls --completed
+  lqd task ls --unblocked work
   lqd task ls --json
   lqd task ls -v work`,
 		Args: cobra.ArbitraryArgs,
@@ -223,17 +305,124 @@ Examples:
 	cmd.Flags().BoolVar(&flags.canceled, "canceled", false, "Include CANCELED tasks")
 	cmd.Flags().BoolVar(&flags.done, "done", false, "Include DONE tasks")
 	cmd.Flags().BoolVarP(&flags.completed, "completed", "c", false, completedUsage)
+	cmd.Flags().BoolVar(&flags.blocked, "blocked", false, "Only tasks waiting for open blocked-by:: tasks")
+	cmd.Flags().BoolVar(&flags.unblocked, "unblocked", false, "Only tasks not waiting for open blocked-by:: tasks")
+	cmd.MarkFlagsMutuallyExclusive("blocked", "unblocked")
 	cmd.Flags().BoolVar(&flags.json, "json", false, "Output raw JSON")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Print the Datalog query before results")
 
 	return cmd
 }
 
+// NewTaskDoneCmd creates a new task done subcommand with the specified dependencies.
+// If deps is nil, it uses default implementations; individual nil fields also fall back to their defaults.
+func NewTaskDoneCmd(deps *TaskDoneDependencies) *cobra.Command {
+	if deps == nil {
+		deps = &TaskDoneDependencies{NewAPI: nil, OpenGraph: nil, TimeNow: nil, Out: nil}
+	}
+
+	if deps.NewAPI == nil {
+		deps.NewAPI = func() api.LogseqAPI {
+			return newLogseqAPI(configValue(config.KeyGraphPath), false)
+		}
+	}
+
+	if deps.OpenGraph == nil {
+		deps.OpenGraph = api.OpenGraphFromPath
+	}
+
+	if deps.TimeNow == nil {
+		deps.TimeNow = time.Now
+	}
+
+	if deps.Out == nil {
+		deps.Out = os.Stdout
+	}
+
+	cmd := &cobra.Command{ //nolint:exhaustruct
+		Use:   "done <uuid>",
+		Short: "Mark a task as done",
+		Long: `Mark a task as DONE, given the UUID of its block (with or without the surrounding parentheses).
+
+A task with a repeating SCHEDULED or DEADLINE date (e.g. <2025-01-06 Mon .+1w>) is not closed:
+its dates move to the next occurrence, the way Logseq does when you tick it.
+
+Examples:
+  lqd task done 6740a2c8-6f3c-4a5b-9f5e-1e2d3c4b5a69
+  lqd task done "((6740a2c8-6f3c-4a5b-9f5e-1e2d3c4b5a69))"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runTaskDone(deps, strings.Trim(args[0], "() "))
+		},
+	}
+
+	return cmd
+}
+
+func runTaskDone(deps *TaskDoneDependencies, uuid string) error {
+	client := deps.NewAPI()
+	graph := deps.OpenGraph(configValue(config.KeyGraphPath))
+
+	var repeated bool
+
+	err := persist.RetryOnConflict(func() error {
+		block, transaction, err := api.FindBlockOnDisk(graph, client, uuid)
+		if err != nil {
+			return fmt.Errorf("finding block on disk: %w", err)
+		}
+
+		repeated, err = logseqext.CompleteTask(block, deps.TimeNow())
+		if err != nil {
+			return fmt.Errorf("failed to complete task: %w", err)
+		}
+
+		saveErr := transaction.Save()
+		if saveErr != nil {
+			return fmt.Errorf("failed to save task page: %w", saveErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if repeated {
+		fmt.Fprintf(deps.Out, "Task %s repeats: moved to its next date\n", uuid)
+	} else {
+		fmt.Fprintf(deps.Out, "Task %s is done\n", uuid)
+	}
+
+	return nil
+}
+
+// taskBacklogIcons reads the backlog config of the graph and maps task UUIDs to the icons of their backlogs.
+// Icons are only a hint: without a graph or a readable config, there are none.
+func taskBacklogIcons() map[string]string {
+	path := configValue(config.KeyGraphPath)
+	if path == "" {
+		return nil
+	}
+
+	graph, err := logseq.Open(context.Background(), path)
+	if err != nil {
+		return nil
+	}
+
+	backlogConfig, err := newBacklogConfigReader(graph, configValue(config.KeyBacklogConfigFile)).ReadConfig()
+	if err != nil {
+		return nil
+	}
+
+	return backlog.TaskIcons(graph, backlogConfig)
+}
+
 // taskCmd represents the task command using the default dependencies.
 var taskCmd = NewTaskCmd() //nolint:gochecknoglobals
 
 func init() {
 	taskCmd.AddCommand(NewTaskAddCmd(nil))
 	taskCmd.AddCommand(NewTaskLsCmd(nil))
+	taskCmd.AddCommand(NewTaskDoneCmd(nil))
 	rootCmd.AddCommand(taskCmd)
 }
@@ -44,9 +44,10 @@ const twoTaskJSON = `[` +
 
 func newTestDeps(mock *mockTaskLsAPI, buf *bytes.Buffer) *cmd.TaskLsDependencies {
 	return &cmd.TaskLsDependencies{
-		NewAPI:    func() api.LogseqAPI { return mock },
-		GraphName: func() string { return "my-graph" },
-		Out:       buf,
+		NewAPI:       func() api.LogseqAPI { return mock },
+		GraphName:    func() string { return "my-graph" },
+		BacklogIcons: func() map[string]string { return nil },
+		Out:          buf,
 	}
 }
 
@@ -224,3 +225,71 @@ func TestNewTaskLsCmd_NilDeps(t *testing.T) {
 	assert.Equal(t, "ls [tag...]", c.Use)
 	assert.Equal(t, "List tasks from Logseq", c.Short)
 }
+
+func TestNewTaskLsCmd_BacklogIcons(t *testing.T) {
+	color.NoColor = true
+
+	t.Cleanup(func() { color.NoColor = false })
+
+	var buf bytes.Buffer
+
+	deps := newTestDeps(&mockTaskLsAPI{queryResult: twoTaskJSON}, &buf)
+	deps.BacklogIcons = func() map[string]string { return map[string]string{"u1": "🏠💼"} }
+
+	c := cmd.NewTaskLsCmd(deps)
+	c.SetArgs([]string{})
+
+	require.NoError(t, c.Execute())
+
+	got := buf.String()
+	assert.Contains(t, got, "🏠💼 TODO buy milk")
+	assert.Contains(t, got, "§DOING write report")
+}
+
+// blockedTaskJSON has a task waiting for an open one, and a task waiting for a DONE one.
+const blockedTaskJSON = `[` +
+	`{"uuid":"b1","marker":"TODO","content":"TODO buy paint","page":{"journalDay":20241201}},` +
+	`{"uuid":"b2","marker":"TODO","content":"TODO paint the wall","page":{"journalDay":20241202},` +
+	`"propertiesTextValues":{"blocked-by":"((b1))"}},` +
+	`{"uuid":"b3","marker":"DONE","content":"DONE clean the wall","page":{"journalDay":20241203}},` +
+	`{"uuid":"b4","marker":"TODO","content":"TODO hang the picture","page":{"journalDay":20241204},` +
+	`"propertiesTextValues":{"blocked-by":"((b3))"}}` +
+	`]`
+
+func TestNewTaskLsCmd_BlockedFilter(t *testing.T) {
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"--blocked", `[{"uuid":"b2",`},
+		{"--unblocked", `[{"uuid":"b1",`},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.flag, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			c := cmd.NewTaskLsCmd(newTestDeps(&mockTaskLsAPI{queryResult: blockedTaskJSON}, &buf))
+			c.SetArgs([]string{testCase.flag, "--json"})
+
+			require.NoError(t, c.Execute())
+
+			got := buf.String()
+			assert.True(t, strings.HasPrefix(got, testCase.want), got)
+
+			if testCase.flag == "--blocked" {
+				assert.NotContains(t, got, `"uuid":"b4"`, "a task waiting for a DONE one is not blocked")
+			} else {
+				assert.Contains(t, got, `"uuid":"b4"`)
+				assert.NotContains(t, got, `"uuid":"b2"`)
+			}
+		})
+	}
+}
+
+func TestNewTaskLsCmd_BlockedAndUnblocked(t *testing.T) {
+	c := cmd.NewTaskLsCmd(newTestDeps(&mockTaskLsAPI{queryResult: `[]`}, &bytes.Buffer{}))
+	c.SetArgs([]string{"--blocked", "--unblocked"})
+
+	require.Error(t, c.Execute())
+}
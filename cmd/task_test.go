@@ -1,10 +1,14 @@
 package cmd_test
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/cmd"
+	"github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/stretchr/testify/assert"
@@ -311,3 +315,86 @@ func TestTaskAddCommand_RequiresArgument(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "requires at least 1 arg(s)")
 }
+
+func TestTaskDoneCommand_WithNilDependencies(t *testing.T) {
+	taskDoneCmd := cmd.NewTaskDoneCmd(nil)
+
+	require.NotNil(t, taskDoneCmd)
+	assert.Equal(t, "done <uuid>", taskDoneCmd.Use)
+	assert.Contains(t, taskDoneCmd.Long, "next occurrence")
+}
+
+func TestTaskDoneCommand_UnknownTask(t *testing.T) {
+	var buf bytes.Buffer
+
+	command := cmd.NewTaskDoneCmd(&cmd.TaskDoneDependencies{
+		NewAPI:    func() api.LogseqAPI { return &mockTaskLsAPI{} }, //nolint:exhaustruct
+		OpenGraph: func(_ string) *logseq.Graph { return &logseq.Graph{} },
+		TimeNow:   time.Now,
+		Out:       &buf,
+	})
+
+	command.SetArgs([]string{"(( 6740a2c8-0000-0000-0000-000000000000 ))"})
+	err := command.Execute()
+
+	require.ErrorIs(t, err, api.ErrBlockNotFoundViaAPI)
+	assert.Contains(t, err.Error(), "6740a2c8-0000-0000-0000-000000000000", "the parentheses around the UUID are trimmed")
+	assert.Empty(t, buf.String())
+}
+
+func TestTaskDoneCommand_CompletesTaskOnDisk(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		expected string
+		output   string
+	}{
+		{
+			name:     "plain task is done",
+			page:     "- TODO Write report\n  id:: 6740a2c8-0000-0000-0000-000000000001\n",
+			expected: "- DONE Write report\n  id:: 6740a2c8-0000-0000-0000-000000000001\n",
+			output:   "is done",
+		},
+		{
+			name: "repeating task moves to its next date",
+			page: "- DOING Water plants\n  SCHEDULED: <2025-04-01 Tue .+1w>\n" +
+				"  id:: 6740a2c8-0000-0000-0000-000000000001\n",
+			expected: "- TODO Water plants\n  SCHEDULED: <2025-04-17 Thu .+1w>\n" +
+				"  id:: 6740a2c8-0000-0000-0000-000000000001\n",
+			output: "moved to its next date",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graphDir := t.TempDir()
+			page := filepath.Join(graphDir, "pages", "chores.md")
+
+			require.NoError(t, os.MkdirAll(filepath.Dir(page), 0o755))
+			require.NoError(t, os.MkdirAll(filepath.Join(graphDir, "logseq"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(graphDir, "logseq", "config.edn"), []byte("{}"), 0o600))
+			require.NoError(t, os.WriteFile(page, []byte(test.page), 0o600))
+			t.Setenv("LQD_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+			t.Setenv("LOGSEQ_GRAPH_PATH", graphDir)
+
+			var buf bytes.Buffer
+
+			command := cmd.NewTaskDoneCmd(&cmd.TaskDoneDependencies{
+				NewAPI: func() api.LogseqAPI {
+					return api.NewOfflineLogseqAPI(graphDir, api.OfflineOptions{WriteMissingIDs: false})
+				},
+				OpenGraph: api.OpenGraphFromPath,
+				TimeNow:   func() time.Time { return time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC) },
+				Out:       &buf,
+			})
+
+			command.SetArgs([]string{"6740a2c8-0000-0000-0000-000000000001"})
+			require.NoError(t, command.Execute())
+			assert.Contains(t, buf.String(), test.output)
+
+			data, err := os.ReadFile(page)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(data))
+		})
+	}
+}
@@ -3,8 +3,8 @@ package cmd
 import (
 	"github.com/andreoliwa/logseq-doctor/internal"
 	"github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
 	"github.com/spf13/cobra"
-	"os"
 )
 
 // tidyUpCmd represents the tidyUp command.
@@ -14,22 +14,23 @@ var tidyUpCmd = &cobra.Command{ //nolint:exhaustruct,gochecknoglobals
 	// TODO: dynamically generate the long description based on the functions in the code.
 	Long: `Tidy up your Markdown files, checking for invalid content and fixing some of them automatically.
 
-- Check for forbidden references to pages/tags
+- Check for forbidden references to pages/tags (tidy-up.forbidden_refs in the config file)
 - Check for running tasks (DOING)
 - Check for double spaces`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(_ *cobra.Command, args []string) {
-		graph := api.OpenGraphFromPath(os.Getenv("LOGSEQ_GRAPH_PATH"))
+		graph := api.OpenGraphFromPath(configValue(config.KeyGraphPath))
 
+		forbiddenRefs := appConfig().GetList(config.KeyTidyUpForbiddenRefs)
 		exitCode := 0
 
 		for _, path := range args {
-			if internal.TidyUpOneFile(graph, path) != 0 {
+			if internal.TidyUpOneFile(graph, path, forbiddenRefs) != 0 {
 				exitCode = 1
 			}
 		}
 
-		os.Exit(exitCode)
+		exit(exitCode)
 	},
 }
 
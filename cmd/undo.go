@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/spf13/cobra"
+)
+
+// NewUndoCmd creates the undo command, which restores the files changed by a previous run.
+func NewUndoCmd(out io.Writer) *cobra.Command {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	var force bool
+
+	cmd := &cobra.Command{ //nolint:exhaustruct
+		Use:   "undo [run-id]",
+		Short: "Restore the graph files changed by a previous run",
+		Long: `Restore the graph files changed by a previous run, as recorded in the undo journal.
+
+Without a run ID, the newest run that was not undone yet is undone ("lqd history" lists the runs).
+Undo refuses to touch a file that changed after the run wrote it, e.g. an edit made in Logseq since;
+use --force to restore it anyway. An undo is a run of its own: undoing it by its ID redoes the changes.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			id := ""
+			if len(args) > 0 {
+				id = args[0]
+			}
+
+			run, err := persist.Undo(configValue(config.KeyUndoDir), id, force)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			verb := "Restored"
+			if persist.DryRun() {
+				verb = "Would restore"
+			}
+
+			fmt.Fprintf(out, "%s %d file(s) changed by run %s (%s)\n", verb, len(run.Files), run.ID, run.Command)
+
+			for _, file := range run.Files {
+				fmt.Fprintf(out, "  %s\n", file.Path)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Restore files even if they changed after the run")
+
+	return cmd
+}
+
+// NewHistoryCmd creates the history command, which lists the runs recorded in the undo journal.
+func NewHistoryCmd(out io.Writer) *cobra.Command {
+	if out == nil {
+		out = os.Stdout
+	}
+
+	return &cobra.Command{ //nolint:exhaustruct
+		Use:   "history [run-id]",
+		Short: "List the runs that changed graph files, newest first",
+		Long: `List the runs that changed graph files, newest first.
+With a run ID, list the files changed by that run.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := configValue(config.KeyUndoDir)
+
+			if len(args) > 0 {
+				run, err := persist.ReadRun(dir, args[0])
+				if err != nil {
+					return err //nolint:wrapcheck
+				}
+
+				writeRunFiles(out, run)
+
+				return nil
+			}
+
+			runs, err := persist.Runs(dir)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd
+
+			for _, run := range runs {
+				fmt.Fprintf(writer, "%s\t%s\t%d file(s)\t%s\t%s\n",
+					run.ID, run.Time.Local().Format("2006-01-02 15:04"), len(run.Files), run.Command, runStatus(run))
+			}
+
+			err = writer.Flush()
+			if err != nil {
+				return fmt.Errorf("failed to write history: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// runStatus tells if a run was undone, or undid another one.
+func runStatus(run persist.Run) string {
+	switch {
+	case run.UndoneBy != "":
+		return "undone by " + run.UndoneBy
+	case run.Undoes != "":
+		return "undoes " + run.Undoes
+	default:
+		return ""
+	}
+}
+
+// writeRunFiles lists the files changed by a run.
+func writeRunFiles(out io.Writer, run *persist.Run) {
+	fmt.Fprintf(out, "%s %s\n", run.ID, run.Command)
+
+	for _, file := range run.Files {
+		change := "modified"
+
+		switch {
+		case file.Created && file.Deleted:
+			change = "created and deleted"
+		case file.Created:
+			change = "created"
+		case file.Deleted:
+			change = "deleted"
+		}
+
+		fmt.Fprintf(out, "  %s (%s)\n", file.Path, change)
+	}
+}
+
+func init() { //nolint:gochecknoinits
+	rootCmd.AddCommand(NewUndoCmd(nil), NewHistoryCmd(nil))
+}
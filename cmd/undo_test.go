@@ -0,0 +1,57 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/cmd"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func TestUndoAndHistoryCmd(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "undo")
+	t.Setenv("LQD_CONFIG", filepath.Join(t.TempDir(), "config.toml"))
+	t.Setenv("LQD_UNDO_DIR", dir)
+
+	page := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(page, []byte("- original\n"), 0o600))
+
+	persist.StartJournal(dir, 10, "lqd tidy-up page.md")
+	t.Cleanup(func() { persist.StartJournal("", 0, "") })
+
+	require.NoError(t, persist.WriteFile(page, []byte("- tidied\n"), 0o600))
+
+	runID := persist.RunID()
+
+	var out bytes.Buffer
+
+	history := cmd.NewHistoryCmd(&out)
+	history.SetArgs([]string{})
+	require.NoError(t, history.Execute())
+	assert.Regexp(t, runID+`\s+\S+ \S+\s+1 file\(s\)\s+lqd tidy-up page.md`, out.String())
+
+	persist.StartJournal(dir, 10, "lqd undo")
+	out.Reset()
+
+	undo := cmd.NewUndoCmd(&out)
+	undo.SetArgs([]string{runID})
+	require.NoError(t, undo.Execute())
+	assert.Contains(t, out.String(), "Restored 1 file(s) changed by run "+runID)
+
+	data, err := os.ReadFile(page)
+	require.NoError(t, err)
+	assert.Equal(t, "- original\n", string(data))
+
+	out.Reset()
+
+	history = cmd.NewHistoryCmd(&out)
+	history.SetArgs([]string{})
+	require.NoError(t, history.Execute())
+	assert.Contains(t, out.String(), "undone by "+persist.RunID())
+	assert.Contains(t, out.String(), "undoes "+runID)
+}
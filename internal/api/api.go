@@ -13,6 +13,7 @@ import (
 	"github.com/andreoliwa/logseq-go"
 
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 )
 
 // ErrFailedOpenGraph is returned when the graph cannot be opened.
@@ -78,6 +79,11 @@ func (l *logseqAPIImpl) UpsertBlockProperty(uuid, key, value string) error {
 		return ErrMissingConfig
 	}
 
+	// Logseq would write the file itself, out of reach of the dry-run diff.
+	if persist.DryRun() {
+		return fmt.Errorf("upsert %s:: on block %s: %w", key, uuid, persist.ErrDryRun)
+	}
+
 	uuidJSON, err := json.Marshal(uuid)
 	if err != nil {
 		return fmt.Errorf("failed to marshal uuid: %w", err)
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andreoliwa/logseq-go/content"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// PropertyBlockedBy is the block property listing the tasks a task waits for: blocked-by:: ((uuid)), ((uuid)).
+const PropertyBlockedBy = "blocked-by"
+
+// blockRefPattern matches the ((uuid)) block refs in a property value.
+var blockRefPattern = regexp.MustCompile(`\(\(\s*([^()\s]+)\s*\)\)`)
+
+// TaskBlockers returns the UUIDs of the tasks in the blocked-by:: property of a task, in the order written.
+func TaskBlockers(task TaskJSON) []TaskUUID {
+	var blockers []TaskUUID
+
+	for _, match := range blockRefPattern.FindAllStringSubmatch(task.PropertiesTextValues[PropertyBlockedBy], -1) {
+		if !slices.Contains(blockers, match[1]) {
+			blockers = append(blockers, match[1])
+		}
+	}
+
+	return blockers
+}
+
+// Dependencies holds the blocked-by:: dependencies of a list of tasks.
+type Dependencies struct {
+	// Blocked are the tasks waiting for at least one open blocker.
+	Blocked *set.Set[TaskUUID]
+	// Cycles are the tasks blocking each other in a loop, each cycle starting at its smallest UUID.
+	Cycles [][]TaskUUID
+}
+
+// ResolveDependencies finds which tasks are blocked. A blocker is open until it is DONE or CANCELED;
+// the status of a blocker missing from the list is asked to Logseq, and a blocker Logseq cannot find blocks nothing.
+func ResolveDependencies(api LogseqAPI, tasks []TaskJSON) Dependencies {
+	markers := make(map[TaskUUID]string, len(tasks))
+	for _, task := range tasks {
+		markers[task.UUID] = task.Marker
+	}
+
+	marker := func(uuid TaskUUID) string {
+		found, ok := markers[uuid]
+		if !ok {
+			info, err := FindBlockByUUID(api, uuid)
+			if err == nil {
+				found = info.Marker
+			}
+
+			markers[uuid] = found
+		}
+
+		return found
+	}
+
+	deps := Dependencies{Blocked: set.NewSet[TaskUUID](), Cycles: nil}
+	edges := make(map[TaskUUID][]TaskUUID)
+
+	for _, task := range tasks {
+		for _, blocker := range TaskBlockers(task) {
+			if !blockerOpen(marker(blocker)) {
+				continue
+			}
+
+			deps.Blocked.Add(task.UUID)
+			edges[task.UUID] = append(edges[task.UUID], blocker)
+		}
+	}
+
+	deps.Cycles = findCycles(edges)
+
+	return deps
+}
+
+// blockerOpen tells if a blocker with this task marker still blocks: a block that is not a task doesn't.
+func blockerOpen(marker string) bool {
+	switch marker {
+	case "", content.TaskStringDone, content.TaskStringCanceled, "CANCELLED":
+		return false
+	}
+
+	return true
+}
+
+// findCycles returns the loops of a dependency graph, once each, in a stable order.
+func findCycles(edges map[TaskUUID][]TaskUUID) [][]TaskUUID {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[TaskUUID]int, len(edges))
+	seen := set.NewSet[string]()
+
+	var (
+		cycles [][]TaskUUID
+		path   []TaskUUID
+		visit  func(node TaskUUID)
+	)
+
+	visit = func(node TaskUUID) {
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, next := range edges[node] {
+			switch state[next] {
+			case visiting:
+				cycle := rotateToSmallest(path[slices.Index(path, next):])
+
+				key := strings.Join(cycle, " ")
+				if !seen.Contains(key) {
+					seen.Add(key)
+					cycles = append(cycles, cycle)
+				}
+			case unvisited:
+				visit(next)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+	}
+
+	nodes := make([]TaskUUID, 0, len(edges))
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+
+	slices.Sort(nodes)
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+
+	return cycles
+}
+
+// rotateToSmallest returns a copy of a cycle that starts at its smallest UUID.
+func rotateToSmallest(cycle []TaskUUID) []TaskUUID {
+	start := slices.Index(cycle, slices.Min(cycle))
+
+	return append(slices.Clone(cycle[start:]), cycle[:start]...)
+}
+
+// FormatCycle describes a dependency cycle with the first line of each task, or its UUID when unknown:
+// "Paint the wall → Buy paint → Paint the wall".
+func FormatCycle(cycle []TaskUUID, tasks map[TaskUUID]TaskJSON) string {
+	names := make([]string, 0, len(cycle)+1)
+
+	for _, uuid := range append(slices.Clone(cycle), cycle[0]) {
+		name := uuid
+		if task, ok := tasks[uuid]; ok {
+			name = logseqext.ExtractFirstLine(task.Content)
+		}
+
+		names = append(names, name)
+	}
+
+	return strings.Join(names, " → ")
+}
@@ -0,0 +1,58 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+)
+
+func blockedBy(uuid, marker, blockers string) logseqapi.TaskJSON {
+	return logseqapi.TaskJSON{ //nolint:exhaustruct
+		UUID: uuid, Marker: marker, PropertiesTextValues: map[string]string{logseqapi.PropertyBlockedBy: blockers},
+	}
+}
+
+func TestTaskBlockers(t *testing.T) {
+	task := blockedBy("a", "TODO", "((b)), (( c )) and ((b)) again")
+
+	assert.Equal(t, []string{"b", "c"}, logseqapi.TaskBlockers(task))
+	assert.Empty(t, logseqapi.TaskBlockers(logseqapi.TaskJSON{})) //nolint:exhaustruct
+}
+
+func TestResolveDependencies(t *testing.T) {
+	// Blockers missing from the list are DONE, according to Logseq.
+	api := &stubDatascriptAPI{datascriptResponse: `[[{"uuid":"x","marker":"DONE","page":{"id":1}}]]`}
+
+	deps := logseqapi.ResolveDependencies(api, []logseqapi.TaskJSON{
+		blockedBy("open-blocker", "TODO", ""),
+		blockedBy("waits", "TODO", "((open-blocker))"),
+		blockedBy("waits-for-done", "TODO", "((finished))"),
+		blockedBy("canceled-blocker", "CANCELED", ""),
+		blockedBy("waits-for-canceled", "TODO", "((canceled-blocker))"),
+		blockedBy("cycle-b", "TODO", "((cycle-a))"),
+		blockedBy("cycle-a", "LATER", "((cycle-b))"),
+	})
+
+	assert.Equal(t, []string{"cycle-a", "cycle-b", "waits"}, deps.Blocked.ValuesSorted())
+	assert.Equal(t, [][]string{{"cycle-a", "cycle-b"}}, deps.Cycles)
+	assert.True(t, api.postDatascriptQueryCalled, "the status of a blocker missing from the list is asked to Logseq")
+}
+
+func TestResolveDependencies_UnknownBlocker(t *testing.T) {
+	api := &stubDatascriptAPI{datascriptResponse: "[]"}
+
+	deps := logseqapi.ResolveDependencies(api, []logseqapi.TaskJSON{blockedBy("waits", "TODO", "((deleted))")})
+
+	assert.Equal(t, 0, deps.Blocked.Size(), "a blocker Logseq cannot find blocks nothing")
+	assert.Empty(t, deps.Cycles)
+}
+
+func TestFormatCycle(t *testing.T) {
+	tasks := map[string]logseqapi.TaskJSON{
+		"a": {UUID: "a", Content: "Paint the wall\nid:: a"}, //nolint:exhaustruct
+	}
+
+	assert.Equal(t, "Paint the wall → b → Paint the wall", logseqapi.FormatCycle([]string{"a", "b"}, tasks))
+}
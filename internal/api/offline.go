@@ -0,0 +1,595 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+// ErrUnsupportedOfflineQuery is returned when the offline index cannot answer a query.
+var ErrUnsupportedOfflineQuery = errors.New("query not supported in offline mode")
+
+// ErrStaleOfflineIndex is returned when a file changed on disk after the offline index was built.
+var ErrStaleOfflineIndex = errors.New("file changed since the offline index was built")
+
+// OfflineOptions configures NewOfflineLogseqAPI.
+type OfflineOptions struct {
+	// WriteMissingIDs writes an id:: property to the tasks returned by PostQuery that don't have one.
+	// Logseq does the same when a block is referenced for the first time; without it, the block refs
+	// created by backlog and sync would point to UUIDs that only exist in memory.
+	WriteMissingIDs bool
+}
+
+// offlineLogseqAPI implements LogseqAPI on top of the graph's Markdown files instead of a running
+// Logseq instance, so commands can run headless (cron, CI, a server without the desktop app).
+type offlineLogseqAPI struct {
+	graphPath string
+	opts      OfflineOptions
+
+	once     sync.Once
+	index    *offlineIndex
+	indexErr error
+}
+
+// NewOfflineLogseqAPI creates a LogseqAPI that answers queries from an in-memory index of the graph
+// at graphPath. The index is built on the first call and returns blocks in the same JSON shape as the
+// Logseq HTTP API, so callers can't tell both implementations apart.
+func NewOfflineLogseqAPI(graphPath string, opts OfflineOptions) LogseqAPI {
+	return &offlineLogseqAPI{ //nolint:exhaustruct
+		graphPath: graphPath,
+		opts:      opts,
+	}
+}
+
+func (o *offlineLogseqAPI) loadIndex() (*offlineIndex, error) {
+	o.once.Do(func() {
+		o.index, o.indexErr = buildOfflineIndex(o.graphPath)
+	})
+
+	return o.index, o.indexErr
+}
+
+// PostQuery evaluates a simple query against the offline index and returns the matching blocks as JSON.
+func (o *offlineLogseqAPI) PostQuery(query string) (string, error) {
+	index, err := o.loadIndex()
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := o.parseOfflineQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	found := index.find(matches)
+
+	if o.opts.WriteMissingIDs {
+		err = index.writeMissingIDs(found)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	tasks := make([]TaskJSON, 0, len(found))
+	for _, block := range found {
+		tasks = append(tasks, block.task)
+	}
+
+	data, err := json.Marshal(tasks)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tasks: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// datascriptUUIDPattern extracts the UUID from the block lookup built by FindBlockByUUID.
+var datascriptUUIDPattern = regexp.MustCompile(`:block/uuid #uuid "([^"]+)"`)
+
+// PostDatascriptQuery answers the block-by-UUID pull used by FindBlockByUUID.
+// Any other Datascript query is unsupported offline.
+func (o *offlineLogseqAPI) PostDatascriptQuery(query string) (string, error) {
+	match := datascriptUUIDPattern.FindStringSubmatch(query)
+	if match == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedOfflineQuery, query)
+	}
+
+	index, err := o.loadIndex()
+	if err != nil {
+		return "", err
+	}
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	block, ok := index.byUUID[match[1]]
+	if !ok {
+		return "[]", nil
+	}
+
+	// Same hyphenated keys as Logseq's Datascript API, see extractBlockInfo.
+	page := map[string]any{
+		"id":            block.task.Page.ID,
+		"name":          block.task.Page.Name,
+		"original-name": block.task.Page.OriginalName,
+	}
+	if block.task.Page.JournalDay > 0 {
+		page["journal-day"] = block.task.Page.JournalDay
+	}
+
+	result := [][]map[string]any{{{
+		"uuid": block.task.UUID, "content": block.task.Content, "marker": block.task.Marker, "page": page,
+	}}}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal block: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// UpsertBlockProperty writes the property straight into the block's Markdown file,
+// below the block's first line, the way Logseq would.
+func (o *offlineLogseqAPI) UpsertBlockProperty(uuid, key, value string) error {
+	index, err := o.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	block, ok := index.byUUID[uuid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBlockNotFoundViaAPI, uuid)
+	}
+
+	err = index.writeProperties(block.path, []propertyEdit{{block: block, key: key, value: value}})
+	if err != nil {
+		return err
+	}
+
+	if key == "id" && value == uuid {
+		block.idOnDisk = true
+	}
+
+	return nil
+}
+
+// parseOfflineQuery turns a simple query into a predicate over indexed blocks.
+func (o *offlineLogseqAPI) parseOfflineQuery(query string) (func(TaskJSON) bool, error) {
+	parsed, err := logseqext.ParseSimpleQuery(query, logseqext.QueryOptions{
+		Today:              time.Now(),
+		JournalTitleFormat: logseqext.ReadJournalTitleFormat(o.graphPath),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedOfflineQuery, err)
+	}
+
+	return func(task TaskJSON) bool {
+		return parsed.Match(queryBlockFromTask(task))
+	}, nil
+}
+
+// queryBlockFromTask exposes the attributes of an indexed block to the simple-query evaluator.
+func queryBlockFromTask(task TaskJSON) logseqext.QueryBlock {
+	pathRefs := make([]string, 0, len(task.PathRefs))
+	for _, ref := range task.PathRefs {
+		pathRefs = append(pathRefs, ref.Name)
+	}
+
+	priority := ""
+	if match := priorityPattern.FindStringSubmatch(task.Content); match != nil {
+		priority = match[1]
+	}
+
+	return logseqext.QueryBlock{
+		Content:    task.Content,
+		Marker:     task.Marker,
+		Priority:   priority,
+		PageName:   task.Page.Name,
+		JournalDay: task.Page.JournalDay,
+		PathRefs:   pathRefs,
+		Properties: task.PropertiesTextValues,
+	}
+}
+
+// Patterns used to read block metadata from Markdown source.
+var (
+	markerPattern         = regexp.MustCompile(`^(?:#+ )?(TODO|DOING|DONE|LATER|NOW|WAITING|WAIT|CANCELED|CANCELLED|IN-PROGRESS)(?:\s|$)`) //nolint:lll
+	priorityPattern       = regexp.MustCompile(`\[#([A-Z])\]`)
+	scheduledPattern      = regexp.MustCompile(`(?m)^SCHEDULED: <(\d{4})-(\d{2})-(\d{2})`)
+	deadlinePattern       = regexp.MustCompile(`(?m)^DEADLINE: <(\d{4})-(\d{2})-(\d{2})`)
+	sourcePropertyPattern = regexp.MustCompile(`^([A-Za-z0-9_\-]+):: ?(.*)$`)
+)
+
+// offlineBlock is a block of the offline index, with enough information to find it again on disk.
+type offlineBlock struct {
+	task     TaskJSON
+	path     string
+	ordinal  int // position in logseqext.SplitSourceBlocks of the file
+	idOnDisk bool
+}
+
+// offlineIndex holds every block of the graph, the way the Logseq DB would.
+type offlineIndex struct {
+	// mu guards the blocks and their files once the index is built: backlog queries its input pages
+	// concurrently, and the id:: writes change both the blocks and the files they share.
+	mu     sync.RWMutex
+	blocks []*offlineBlock
+	byUUID map[TaskUUID]*offlineBlock
+	refIDs map[string]int
+}
+
+// indexAncestor remembers the refs of an open parent block while walking a file.
+type indexAncestor struct {
+	depth int
+	refs  []RefJSON
+}
+
+// buildOfflineIndex reads every page and journal of the graph.
+func buildOfflineIndex(graphPath string) (*offlineIndex, error) {
+	index := &offlineIndex{ //nolint:exhaustruct // the zero mutex is ready to use
+		blocks: nil,
+		byUUID: make(map[TaskUUID]*offlineBlock),
+		refIDs: make(map[string]int),
+	}
+
+	journalFormat := logseqext.ReadJournalTitleFormat(graphPath)
+
+	for _, dir := range []string{"journals", "pages"} {
+		paths, err := filepath.Glob(filepath.Join(graphPath, dir, "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+
+		for _, path := range paths {
+			err = index.addFile(path, dir == "journals", journalFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return index, nil
+}
+
+// addFile indexes all blocks of one Markdown file.
+func (idx *offlineIndex) addFile(path string, journal bool, journalFormat string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the graph directory listing
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	sourceBlocks := logseqext.SplitSourceBlocks(string(data))
+	page := idx.pageFor(path, journal, journalFormat, sourceBlocks)
+	pageRef := RefJSON{ID: page.ID, Name: page.Name}
+
+	var ancestors []indexAncestor
+
+	for ordinal, source := range sourceBlocks {
+		if source.Depth == logseqext.PreambleDepth {
+			continue
+		}
+
+		for len(ancestors) > 0 && ancestors[len(ancestors)-1].depth >= source.Depth {
+			ancestors = ancestors[:len(ancestors)-1]
+		}
+
+		refs := idx.refsFor(source.Content)
+
+		pathRefs := []RefJSON{pageRef}
+		for _, ancestor := range ancestors {
+			pathRefs = appendUniqueRefs(pathRefs, ancestor.refs)
+		}
+
+		pathRefs = appendUniqueRefs(pathRefs, refs)
+		ancestors = append(ancestors, indexAncestor{depth: source.Depth, refs: refs})
+
+		idx.addBlock(path, ordinal, source.Content, page, refs, pathRefs)
+	}
+
+	return nil
+}
+
+func (idx *offlineIndex) addBlock(path string, ordinal int, text string, page PageJSON, refs, pathRefs []RefJSON) {
+	properties := sourceProperties(text, true)
+
+	uuid, idOnDisk := properties["id"], true
+	if uuid == "" {
+		uuid, idOnDisk = newBlockUUID(), false
+	}
+
+	marker := ""
+	if match := markerPattern.FindStringSubmatch(text); match != nil {
+		marker = match[1]
+	}
+
+	block := &offlineBlock{
+		task: TaskJSON{
+			UUID:                 uuid,
+			Marker:               marker,
+			Content:              text,
+			Page:                 page,
+			Deadline:             sourceDate(deadlinePattern, text),
+			Scheduled:            sourceDate(scheduledPattern, text),
+			Refs:                 refs,
+			PathRefs:             pathRefs,
+			PropertiesTextValues: properties,
+		},
+		path:     path,
+		ordinal:  ordinal,
+		idOnDisk: idOnDisk,
+	}
+
+	idx.blocks = append(idx.blocks, block)
+	idx.byUUID[uuid] = block
+}
+
+// pageFor describes the page stored in path. Regular pages use their title:: property when present.
+func (idx *offlineIndex) pageFor(
+	path string, journal bool, journalFormat string, sourceBlocks []logseqext.SourceBlock,
+) PageJSON {
+	page := PageJSON{ID: 0, JournalDay: 0, Name: "", OriginalName: logseqext.PageTitleFromFileName(path)}
+
+	if date, ok := logseqext.JournalDateFromFileName(path); journal && ok {
+		page.JournalDay = logseqext.DateYYYYMMDD(date)
+		page.OriginalName = logseqext.FormatJournalTitle(date, journalFormat)
+	} else if len(sourceBlocks) > 0 && sourceBlocks[0].Depth == logseqext.PreambleDepth {
+		if title := sourceProperties(sourceBlocks[0].Content, false)["title"]; title != "" {
+			page.OriginalName = title
+		}
+	}
+
+	page.Name = strings.ToLower(page.OriginalName)
+	page.ID = idx.refID(page.Name)
+
+	return page
+}
+
+// refID returns the numeric ID of a page, assigning one on first use.
+// Pages that only exist as references get an ID as well, just like in Logseq.
+func (idx *offlineIndex) refID(name string) int {
+	id, ok := idx.refIDs[name]
+	if !ok {
+		id = len(idx.refIDs) + 1
+		idx.refIDs[name] = id
+	}
+
+	return id
+}
+
+// refsFor returns the pages referenced by a block: page links, tags and tags:: property values.
+func (idx *offlineIndex) refsFor(text string) []RefJSON {
+	names := logseqext.ExtractDirectTags(priorityPattern.ReplaceAllString(text, ""))
+
+	if tags := sourceProperties(text, true)["tags"]; tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			tag = strings.Trim(strings.TrimSpace(tag), "#[]")
+			if tag != "" {
+				names = append(names, tag)
+			}
+		}
+	}
+
+	refs := make([]RefJSON, 0, len(names))
+	for _, name := range logseqext.UniqueStrings(names) {
+		name = strings.ToLower(name)
+		refs = appendUniqueRefs(refs, []RefJSON{{ID: idx.refID(name), Name: name}})
+	}
+
+	return refs
+}
+
+func appendUniqueRefs(refs, more []RefJSON) []RefJSON {
+	for _, ref := range more {
+		duplicate := false
+
+		for _, existing := range refs {
+			if existing.ID == ref.ID {
+				duplicate = true
+
+				break
+			}
+		}
+
+		if !duplicate {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// sourceProperties reads the key:: value lines of a block. The first line of a block is its
+// title and never a property, while page-level preambles start with properties right away.
+func sourceProperties(text string, skipFirstLine bool) map[string]string {
+	properties := make(map[string]string)
+
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 && skipFirstLine {
+			continue
+		}
+
+		if match := sourcePropertyPattern.FindStringSubmatch(line); match != nil {
+			properties[strings.ToLower(match[1])] = strings.TrimSpace(match[2])
+		}
+	}
+
+	return properties
+}
+
+// sourceDate reads a SCHEDULED or DEADLINE date as a YYYYMMDD integer, or 0 if absent.
+func sourceDate(pattern *regexp.Regexp, text string) int {
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+
+	date, _ := strconv.Atoi(match[1] + match[2] + match[3])
+
+	return date
+}
+
+// newBlockUUID generates a random (version 4) UUID for a block that has no id:: property yet.
+func newBlockUUID() string {
+	var buf [16]byte
+
+	_, _ = rand.Read(buf[:])
+
+	buf[6] = (buf[6] & 0x0f) | 0x40 //nolint:mnd // version 4
+	buf[8] = (buf[8] & 0x3f) | 0x80 //nolint:mnd // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
+}
+
+// propertyEdit sets one property on one indexed block.
+type propertyEdit struct {
+	block *offlineBlock
+	key   string
+	value string
+}
+
+// find returns the blocks whose task matches.
+func (idx *offlineIndex) find(matches func(TaskJSON) bool) []*offlineBlock {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var found []*offlineBlock
+
+	for _, block := range idx.blocks {
+		if matches(block.task) {
+			found = append(found, block)
+		}
+	}
+
+	return found
+}
+
+// writeMissingIDs writes the generated UUID of each block that has no id:: property on disk yet.
+// Another query may have written some of them in the meantime: they are checked again under the lock.
+func (idx *offlineIndex) writeMissingIDs(blocks []*offlineBlock) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	editsByPath := make(map[string][]propertyEdit)
+
+	for _, block := range blocks {
+		if !block.idOnDisk {
+			editsByPath[block.path] = append(editsByPath[block.path],
+				propertyEdit{block: block, key: "id", value: block.task.UUID})
+		}
+	}
+
+	for path, edits := range editsByPath {
+		err := idx.writeProperties(path, edits)
+		if err != nil {
+			return err
+		}
+
+		for _, edit := range edits {
+			edit.block.idOnDisk = true
+		}
+	}
+
+	return nil
+}
+
+// writeProperties applies property edits to the blocks of a single file, at most one edit per block.
+// The caller holds idx.mu for writing, so the file is not read and written by two queries at once.
+func (idx *offlineIndex) writeProperties(path string, edits []propertyEdit) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	data, err := persist.ReadFile(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	text := string(data)
+	sourceBlocks := logseqext.SplitSourceBlocks(text)
+
+	// Edit from the end of the file backwards, so the byte offsets of earlier blocks stay valid.
+	sort.Slice(edits, func(i, j int) bool { return edits[i].block.ordinal > edits[j].block.ordinal })
+
+	for _, edit := range edits {
+		ordinal := edit.block.ordinal
+		if ordinal >= len(sourceBlocks) || sourceBlocks[ordinal].Content != edit.block.task.Content {
+			return fmt.Errorf("%w: %s", ErrStaleOfflineIndex, path)
+		}
+
+		text = setSourceProperty(text, sourceBlocks[ordinal], edit.key, edit.value)
+	}
+
+	err = persist.WriteFile(path, []byte(text), info.Mode())
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	// Property lines never add bullets, so ordinals are unchanged; only the content moves on.
+	updated := logseqext.SplitSourceBlocks(text)
+	for _, edit := range edits {
+		edit.block.task.Content = updated[edit.block.ordinal].Content
+		edit.block.task.PropertiesTextValues[edit.key] = edit.value
+	}
+
+	return nil
+}
+
+// setSourceProperty replaces the key:: line of a block, or inserts it after the block's first line
+// and existing properties, which is where Logseq keeps block properties.
+func setSourceProperty(text string, block logseqext.SourceBlock, key, value string) string {
+	indent := logseqext.SourceIndent(block.Depth)
+	own := strings.SplitAfter(text[block.Start:block.End], "\n")
+	line := indent + key + ":: " + value
+
+	insertAt := 1
+
+	for insertAt < len(own) {
+		match := sourcePropertyPattern.FindStringSubmatch(strings.TrimPrefix(strings.TrimRight(own[insertAt], "\n"), indent))
+		if match == nil {
+			break
+		}
+
+		if strings.EqualFold(match[1], key) {
+			if strings.HasSuffix(own[insertAt], "\n") {
+				line += "\n"
+			}
+
+			own[insertAt] = line
+
+			return text[:block.Start] + strings.Join(own, "") + text[block.End:]
+		}
+
+		insertAt++
+	}
+
+	if strings.HasSuffix(own[insertAt-1], "\n") {
+		line += "\n"
+	} else {
+		// Last line of a file without a trailing newline.
+		own[insertAt-1] += "\n"
+	}
+
+	own = append(own[:insertAt], append([]string{line}, own[insertAt:]...)...)
+
+	return text[:block.Start] + strings.Join(own, "") + text[block.End:]
+}
@@ -0,0 +1,203 @@
+package api_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+// writeOfflineGraph creates a minimal graph on disk with the given files (relative path → contents).
+func writeOfflineGraph(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["logseq/config.edn"] = `{:journal/page-title-format "EEEE, dd.MM.yyyy"}`
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+
+	return dir
+}
+
+func offlineTasks(t *testing.T, api logseqapi.LogseqAPI, query string) []logseqapi.TaskJSON {
+	t.Helper()
+
+	jsonStr, err := api.PostQuery(query)
+	require.NoError(t, err)
+
+	tasks, err := logseqapi.ExtractTasksFromJSON(jsonStr)
+	require.NoError(t, err)
+
+	return tasks
+}
+
+func TestOfflineLogseqAPI_PostQuery(t *testing.T) {
+	dir := writeOfflineGraph(t, map[string]string{
+		"journals/2025_04_13.md": "- TODO Buy milk #home\n" +
+			"  SCHEDULED: <2025-04-15 Tue>\n" +
+			"- [[work]]\n" +
+			"\t- DOING [#A] Write report\n" +
+			"\t  id:: 11111111-0000-0000-0000-000000000000\n" +
+			"\t- DONE Old stuff\n",
+		"pages/home.md":      "- WAITING Call the plumber\n  DEADLINE: <2025-04-01 Tue>\n",
+		"pages/bk___home.md": "title:: bk/home\n- (( 11111111-0000-0000-0000-000000000000 ))\n",
+	})
+	api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: false})
+
+	t.Run("all active tasks", func(t *testing.T) {
+		tasks := offlineTasks(t, api, logseqapi.BuildTaskListQuery(nil, false, false))
+		require.Len(t, tasks, 3)
+
+		assert.Equal(t, "TODO", tasks[0].Marker)
+		assert.Equal(t, "TODO Buy milk #home\nSCHEDULED: <2025-04-15 Tue>", tasks[0].Content)
+		assert.Equal(t, 20250415, tasks[0].Scheduled)
+		assert.Equal(t, 20250413, tasks[0].Page.JournalDay)
+		assert.Equal(t, "Sunday, 13.04.2025", tasks[0].Page.OriginalName)
+
+		assert.Equal(t, "11111111-0000-0000-0000-000000000000", tasks[1].UUID)
+		assert.Equal(t, "DOING", tasks[1].Marker)
+
+		assert.Equal(t, "home", tasks[2].Page.Name)
+		assert.Equal(t, 20250401, tasks[2].Deadline)
+	})
+
+	t.Run("tag matches page, own refs and ancestor refs", func(t *testing.T) {
+		tasks := offlineTasks(t, api, logseqapi.BuildTaskListQuery([]string{"home"}, false, false))
+		require.Len(t, tasks, 2)
+		assert.Equal(t, "TODO", tasks[0].Marker)
+		assert.Equal(t, "WAITING", tasks[1].Marker)
+
+		tasks = offlineTasks(t, api, logseqapi.BuildTaskListQuery([]string{"Work"}, false, true))
+		require.Len(t, tasks, 2)
+		assert.Equal(t, "DONE", tasks[1].Marker)
+	})
+
+	t.Run("or of tags", func(t *testing.T) {
+		tasks := offlineTasks(t, api, "(and (or [[work]] [[home]]) (task DOING WAITING))")
+		assert.Len(t, tasks, 2)
+	})
+
+	t.Run("custom query from a backlog page", func(t *testing.T) {
+		query := "{:title \"Urgent\"\n  :query (and (priority a) (not [[home]]) (task TODO DOING))\n  :collapsed? false}"
+		tasks := offlineTasks(t, api, query)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "DOING", tasks[0].Marker)
+	})
+
+	t.Run("between journal dates", func(t *testing.T) {
+		tasks := offlineTasks(t, api, "(and (between [[Saturday, 12.04.2025]] [[Monday, 14.04.2025]]) (task TODO))")
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "TODO", tasks[0].Marker)
+	})
+
+	t.Run("unsupported query", func(t *testing.T) {
+		_, err := api.PostQuery("(page-tags book)")
+		require.ErrorIs(t, err, logseqapi.ErrUnsupportedOfflineQuery)
+		require.ErrorIs(t, err, logseqext.ErrUnsupportedQuery)
+	})
+}
+
+func TestOfflineLogseqAPI_FindBlockByUUID(t *testing.T) {
+	dir := writeOfflineGraph(t, map[string]string{
+		"journals/2025_04_13.md": "- TODO Task\n  id:: 22222222-0000-0000-0000-000000000000\n",
+		"pages/bk___home.md":     "- TODO Other task\n  id:: 33333333-0000-0000-0000-000000000000\n",
+	})
+	api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: false})
+
+	info, err := logseqapi.FindBlockByUUID(api, "22222222-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+	assert.True(t, info.IsJournal)
+	assert.Equal(t, 13, info.JournalDate.Day())
+	assert.Equal(t, "TODO", info.Marker)
+
+	info, err = logseqapi.FindBlockByUUID(api, "33333333-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+	assert.False(t, info.IsJournal)
+	assert.Equal(t, "bk/home", info.PageName)
+
+	_, err = logseqapi.FindBlockByUUID(api, "missing")
+	require.ErrorIs(t, err, logseqapi.ErrBlockNotFoundViaAPI)
+}
+
+func TestOfflineLogseqAPI_WriteMissingIDs(t *testing.T) {
+	dir := writeOfflineGraph(t, map[string]string{
+		"pages/home.md": "- TODO First\n  priority:: high\n\t- TODO Nested\n- TODO Last",
+	})
+	api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: true})
+
+	tasks := offlineTasks(t, api, "(and (task TODO))")
+	require.Len(t, tasks, 3)
+
+	data, err := os.ReadFile(filepath.Join(dir, "pages", "home.md"))
+	require.NoError(t, err)
+
+	expected := "- TODO First\n  priority:: high\n  id:: " + tasks[0].UUID + "\n" +
+		"\t- TODO Nested\n\t  id:: " + tasks[1].UUID + "\n" +
+		"- TODO Last\n  id:: " + tasks[2].UUID
+	assert.Equal(t, expected, string(data))
+
+	// A second query finds the ids on disk and doesn't write them again.
+	again := offlineTasks(t, api, "(and (task TODO))")
+	assert.Equal(t, tasks[0].UUID, again[0].UUID)
+
+	data2, err := os.ReadFile(filepath.Join(dir, "pages", "home.md"))
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(data2))
+}
+
+func TestOfflineLogseqAPI_WriteMissingIDsConcurrently(t *testing.T) {
+	dir := writeOfflineGraph(t, map[string]string{
+		"pages/home.md": "- TODO Clean #home\n- TODO Paint #home\n- TODO Call #phone\n- TODO Text #phone\n",
+	})
+	api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: true})
+
+	// Like backlog, which queries its input pages at the same time: the queries share a file.
+	queries := []string{"(and [[home]] (task TODO))", "(and [[phone]] (task TODO))", "(and (task TODO))"}
+
+	var wg sync.WaitGroup
+
+	for i := range 12 {
+		wg.Go(func() {
+			_, err := api.PostQuery(queries[i%len(queries)])
+			assert.NoError(t, err)
+		})
+	}
+
+	wg.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, "pages", "home.md"))
+	require.NoError(t, err)
+
+	for _, task := range offlineTasks(t, api, "(and (task TODO))") {
+		assert.Equal(t, 1, strings.Count(string(data), "id:: "+task.UUID), "the id of %q is on disk once", task.Content)
+	}
+
+	assert.Equal(t, 4, strings.Count(string(data), "id:: "))
+}
+
+func TestOfflineLogseqAPI_UpsertBlockProperty(t *testing.T) {
+	dir := writeOfflineGraph(t, map[string]string{
+		"pages/home.md": "- TODO Task\n  id:: 44444444-0000-0000-0000-000000000000\n  status:: old\n- Other\n",
+	})
+	api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: false})
+
+	require.NoError(t, api.UpsertBlockProperty("44444444-0000-0000-0000-000000000000", "status", "new"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "pages", "home.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "- TODO Task\n  id:: 44444444-0000-0000-0000-000000000000\n  status:: new\n- Other\n", string(data))
+
+	err = api.UpsertBlockProperty("missing", "status", "new")
+	require.ErrorIs(t, err, logseqapi.ErrBlockNotFoundViaAPI)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/andreoliwa/logseq-go/content"
 
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 )
 
 // ErrBlockNotFoundViaAPI is returned when a block UUID query returns no results from the Logseq API.
@@ -21,7 +22,7 @@ var ErrBlockNotFoundViaAPI = errors.New("block not found via API")
 var ErrBlockNotOnDiskAfterWriteback = errors.New("block still not found on disk after write-back")
 
 // OpenPageForBlock opens the appropriate page (journal or regular) for a block described by blockInfo.
-func OpenPageForBlock(transaction *logseq.Transaction, blockInfo *BlockQueryInfo) (logseq.Page, error) {
+func OpenPageForBlock(transaction *persist.Transaction, blockInfo *BlockQueryInfo) (logseq.Page, error) {
 	if blockInfo.IsJournal {
 		page, err := transaction.OpenJournal(blockInfo.JournalDate)
 		if err != nil {
@@ -47,7 +48,7 @@ func FindBlockOnDisk(
 	graph *logseq.Graph,
 	api LogseqAPI,
 	uuid string,
-) (*content.Block, *logseq.Transaction, error) {
+) (*content.Block, *persist.Transaction, error) {
 	blockInfo, err := FindBlockByUUID(api, uuid)
 	if err != nil {
 		return nil, nil, fmt.Errorf("block %s: API lookup failed: %w", uuid, err)
@@ -86,8 +87,8 @@ func openBlockFromInfo(
 	graph *logseq.Graph,
 	blockInfo *BlockQueryInfo,
 	uuid string,
-) (*content.Block, *logseq.Transaction, error) {
-	transaction := graph.NewTransaction()
+) (*content.Block, *persist.Transaction, error) {
+	transaction := persist.NewTransaction(graph)
 
 	page, err := OpenPageForBlock(transaction, blockInfo)
 	if err != nil {
@@ -104,6 +105,7 @@ type BlockQueryInfo struct {
 	PageName    string
 	JournalDate time.Time
 	IsJournal   bool
+	Marker      string // the task marker of the block (e.g. "DONE"), empty for a block that is not a task
 }
 
 // FindBlockByUUID queries the Logseq HTTP API to find a block by UUID.
@@ -141,7 +143,10 @@ func parseBlockQueryResponse(jsonStr, uuid string) (*BlockQueryInfo, error) {
 	block := results[0][0]
 	page, _ := block["page"].(map[string]any)
 
-	return extractBlockInfo(page), nil
+	info := extractBlockInfo(page)
+	info.Marker, _ = block["marker"].(string)
+
+	return info, nil
 }
 
 // extractBlockInfo extracts page name and journal info from a page map.
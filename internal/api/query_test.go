@@ -69,6 +69,17 @@ func TestFindBlockByUUID_UsesDatascriptQuery(t *testing.T) {
 	assert.True(t, api.postDatascriptQueryCalled, "should use PostDatascriptQuery")
 	assert.False(t, api.postQueryCalled, "should NOT use PostQuery (logseq.db.q fails for datascript)")
 	assert.Equal(t, "My Page", info.PageName)
+	assert.Empty(t, info.Marker)
+}
+
+func TestFindBlockByUUID_Marker(t *testing.T) {
+	blockJSON := `[[{"uuid":"test-uuid","marker":"DONE","page":{"id":1,"original-name":"My Page"}}]]`
+	api := &stubDatascriptAPI{datascriptResponse: blockJSON}
+
+	info, err := logseqapi.FindBlockByUUID(api, "test-uuid")
+
+	require.NoError(t, err)
+	assert.Equal(t, "DONE", info.Marker)
 }
 
 func TestFindBlockByUUID_JournalPage(t *testing.T) {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"slices"
 	"sort"
 	"strings"
 
@@ -13,6 +14,7 @@ func BuildRefLookup(tasks []TaskJSON) map[int]string {
 
 	for _, task := range tasks {
 		populatePageRef(refLookup, task)
+		populateNamedRefs(refLookup, task)
 	}
 
 	refCandidates := collectRefCandidates(tasks, refLookup)
@@ -37,6 +39,15 @@ func populatePageRef(refLookup map[int]string, task TaskJSON) {
 	}
 }
 
+// populateNamedRefs adds refs that already carry their page name (the offline index provides them).
+func populateNamedRefs(refLookup map[int]string, task TaskJSON) {
+	for _, ref := range slices.Concat(task.Refs, task.PathRefs) {
+		if _, resolved := refLookup[ref.ID]; !resolved && ref.Name != "" {
+			refLookup[ref.ID] = ref.Name
+		}
+	}
+}
+
 // collectRefCandidates gathers tag candidates for unresolved ref IDs.
 func collectRefCandidates(tasks []TaskJSON, refLookup map[int]string) map[int]map[string]int {
 	candidates := make(map[int]map[string]int)
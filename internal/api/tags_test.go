@@ -1,10 +1,12 @@
 package api_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -141,3 +143,82 @@ func TestTaskFutureScheduled(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskDueSoon(t *testing.T) {
+	// Fixed reference: Jan 1, 2025
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := func() time.Time { return fixedTime }
+
+	tests := []struct {
+		name      string
+		scheduled int
+		deadline  int
+		leadDays  int
+		expected  bool
+	}{
+		{"deadline tomorrow", 0, 20250102, 7, true},
+		{"deadline on the last day of the window", 0, 20250108, 7, true},
+		{"deadline after the window", 0, 20250109, 7, false},
+		{"deadline today (overdue)", 0, 20250101, 7, false},
+		{"no deadline", 20250102, 0, 7, false},
+		{"deadline soon but overdue scheduled date", 20241231, 20250103, 7, false},
+		{"no lead time", 0, 20250102, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task := logseqapi.TaskJSON{Scheduled: test.scheduled, Deadline: test.deadline}
+			assert.Equal(t, test.expected, logseqapi.TaskDueSoon(task, now, test.leadDays))
+		})
+	}
+}
+
+func TestTaskDates_Repeating(t *testing.T) {
+	// Fixed reference: Jan 1, 2025, a Wednesday
+	fixedTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := func() time.Time { return fixedTime }
+
+	tests := []struct {
+		name              string
+		content           string
+		expectedScheduled int
+		expectedDeadline  int
+		overdue           bool
+		futureScheduled   bool
+	}{
+		{"missed occurrences", "TODO Water plants\nSCHEDULED: <2024-12-02 Mon .+1w>", 20250106, 0, true, false},
+		{"occurrence today", "TODO Water plants\nSCHEDULED: <2024-12-04 Wed +1w>", 20250101, 0, true, false},
+		{"repeating deadline", "TODO Pay rent\nDEADLINE: <2024-11-05 Tue ++1m>", 0, 20250105, true, false},
+		{"next occurrence", "TODO Water plants\nSCHEDULED: <2025-01-03 Fri .+1w>", 20250103, 0, false, true},
+		{"no repeater", "TODO Call mom\nSCHEDULED: <2024-12-02 Mon>", 20241202, 0, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date := logseqext.FindTaskDates(test.content)[0].Date
+			task := logseqapi.TaskJSON{Content: test.content} //nolint:exhaustruct
+
+			if strings.Contains(test.content, "SCHEDULED") {
+				task.Scheduled = logseqext.DateYYYYMMDD(date)
+			} else {
+				task.Deadline = logseqext.DateYYYYMMDD(date)
+			}
+
+			scheduled, deadline := logseqapi.TaskDates(task, now)
+
+			assert.Equal(t, test.expectedScheduled, scheduled)
+			assert.Equal(t, test.expectedDeadline, deadline)
+			assert.Equal(t, test.overdue, logseqapi.TaskOverdue(task, now))
+			assert.Equal(t, test.futureScheduled, logseqapi.TaskFutureScheduled(task, now))
+		})
+	}
+}
+
+func TestTaskRepeater(t *testing.T) {
+	task := logseqapi.TaskJSON{ //nolint:exhaustruct
+		Content: "TODO Pay rent\nDEADLINE: <2025-01-05 Sun ++1m>\nSCHEDULED: <2025-01-01 Wed .+1w>",
+	}
+
+	assert.Equal(t, ".+1w", logseqapi.TaskRepeater(task).String(), "the SCHEDULED repeater comes first")
+	assert.True(t, logseqapi.TaskRepeater(logseqapi.TaskJSON{Content: "TODO x"}).IsZero()) //nolint:exhaustruct
+}
@@ -47,6 +47,8 @@ type CategorizedTasks struct {
 	Overdue         *set.Set[TaskUUID]
 	Doing           *set.Set[TaskUUID]
 	FutureScheduled *set.Set[TaskUUID]
+	DueSoon         *set.Set[TaskUUID] // tasks with a deadline in the next days, see TaskDueSoon
+	Blocked         *set.Set[TaskUUID] // tasks waiting for open blockers, see ResolveDependencies
 	TaskLookup      map[TaskUUID]TaskJSON
 }
 
@@ -57,6 +59,8 @@ func NewCategorizedTasks() CategorizedTasks {
 		Overdue:         set.NewSet[TaskUUID](),
 		Doing:           set.NewSet[TaskUUID](),
 		FutureScheduled: set.NewSet[TaskUUID](),
+		DueSoon:         set.NewSet[TaskUUID](),
+		Blocked:         set.NewSet[TaskUUID](),
 		TaskLookup:      make(map[TaskUUID]TaskJSON),
 	}
 }
@@ -74,12 +78,52 @@ func ExtractTasksFromJSON(jsonStr string) ([]TaskJSON, error) {
 }
 
 // TaskOverdue checks if the task is overdue based on deadline or scheduled date.
+// A repeating date is checked as written too: like in Logseq, an occurrence missed makes the task overdue
+// until it is done and the date moves on.
 func TaskOverdue(t TaskJSON, currentTime func() time.Time) bool {
 	currentDate := logseqext.DateYYYYMMDD(currentTime())
 
 	return (t.Deadline > 0 && t.Deadline <= currentDate) || (t.Scheduled > 0 && t.Scheduled <= currentDate)
 }
 
+// TaskRepeater returns the repeater of the SCHEDULED date of a task, or of its DEADLINE, e.g. ".+1w";
+// zero if the task doesn't repeat.
+func TaskRepeater(t TaskJSON) logseqext.Repeater {
+	var repeater logseqext.Repeater
+
+	for _, date := range logseqext.FindTaskDates(t.Content) {
+		if repeater.IsZero() || date.Keyword == logseqext.TaskDateScheduled {
+			repeater = date.Repeater
+		}
+	}
+
+	return repeater
+}
+
+// TaskDates returns the scheduled and deadline days of a task as YYYYMMDD (0 when absent).
+// A repeating date gives its next occurrence, today or later, the date to show and sort the task by;
+// TaskOverdue still checks the date as written.
+func TaskDates(t TaskJSON, currentTime func() time.Time) (int, int) {
+	scheduled, deadline := t.Scheduled, t.Deadline
+
+	for _, date := range logseqext.FindTaskDates(t.Content) {
+		if date.Repeater.IsZero() {
+			continue
+		}
+
+		next := logseqext.DateYYYYMMDD(date.Repeater.Occurrence(date.Date, currentTime()))
+
+		switch date.Keyword {
+		case logseqext.TaskDateScheduled:
+			scheduled = next
+		case logseqext.TaskDateDeadline:
+			deadline = next
+		}
+	}
+
+	return scheduled, deadline
+}
+
 // TaskDoing checks if the task has the DOING marker.
 func TaskDoing(t TaskJSON) bool {
 	return t.Marker == content.TaskStringDoing
@@ -88,6 +132,18 @@ func TaskDoing(t TaskJSON) bool {
 // TaskFutureScheduled checks if the task is scheduled for the future (tomorrow onwards) and it's not overdue.
 func TaskFutureScheduled(t TaskJSON, currentTime func() time.Time) bool {
 	currentDate := logseqext.DateYYYYMMDD(currentTime())
+	scheduled, _ := TaskDates(t, currentTime)
+
+	return scheduled > currentDate && !TaskOverdue(t, currentTime)
+}
+
+// TaskDueSoon checks if the task has a deadline within the next leadDays days (tomorrow onwards),
+// the way Logseq warns about deadlines ahead of time, and it's not overdue.
+func TaskDueSoon(t TaskJSON, currentTime func() time.Time, leadDays int) bool {
+	now := currentTime()
+	currentDate := logseqext.DateYYYYMMDD(now)
+	lastDate := logseqext.DateYYYYMMDD(now.AddDate(0, 0, leadDays))
+	_, deadline := TaskDates(t, currentTime)
 
-	return t.Scheduled > currentDate && !TaskOverdue(t, currentTime)
+	return deadline > currentDate && deadline <= lastDate && !TaskOverdue(t, currentTime)
 }
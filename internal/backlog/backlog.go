@@ -1,23 +1,39 @@
 package backlog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal"
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/pkg/pool"
 	"github.com/andreoliwa/logseq-doctor/pkg/set"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/andreoliwa/logseq-go/content"
 	"github.com/fatih/color"
 )
 
+// ErrPagesNotQueried is returned with the input pages of a backlog left out after a query failed.
+var ErrPagesNotQueried = errors.New("input pages not queried")
+
 type Result struct {
 	FocusRefsFromPage *set.Set[string]
-	ShowQuickCapture  bool
+	// DueSoonRefs are the block refs in the Due soon section of the page, shown on the Focus page too.
+	DueSoonRefs      *set.Set[string]
+	ShowQuickCapture bool
+	// MovedRefs are the block refs moved to other backlogs by directives, by backlog page.
+	MovedRefs map[string]*set.Set[string]
+	// AutoFocusRefs are the block refs put in the Focus section by a FocusPolicy, and RankedRefs the top-level
+	// block refs below the Focus divider that are not in a section, both in page order.
+	AutoFocusRefs []string
+	RankedRefs    []string
 }
 
 type Backlog interface {
@@ -26,16 +42,52 @@ type Backlog interface {
 	ProcessOne(pageTitle string, funcQueryRefs func() (*logseqapi.CategorizedTasks, error)) (*Result, error)
 }
 
+// DefaultQueryWorkers is how many input pages of a backlog are queried at the same time.
+const DefaultQueryWorkers = 4
+
+// queryWorkers is the number of concurrent page queries of the current run, see SetQueryWorkers.
+//
+//nolint:gochecknoglobals // set once per run from the --workers flag or the lqd config file
+var queryWorkers atomic.Int64
+
+// SetQueryWorkers sets how many input pages of a backlog are queried at the same time;
+// zero or less restores DefaultQueryWorkers.
+func SetQueryWorkers(workers int) {
+	queryWorkers.Store(int64(max(workers, 0)))
+}
+
+// QueryWorkers returns how many input pages of a backlog are queried at the same time.
+func QueryWorkers() int {
+	if workers := int(queryWorkers.Load()); workers > 0 {
+		return workers
+	}
+
+	return DefaultQueryWorkers
+}
+
 type backlogImpl struct {
 	graph        *logseq.Graph
 	logseqAPI    logseqapi.LogseqAPI
 	configReader ConfigReader
 	currentTime  func() time.Time
+	// sections, icons and limits hold the section order, the icon and the WIP limits of each backlog page,
+	// from the last config read.
+	sections map[string][]Header
+	icons    map[string]string
+	limits   map[string]Limits
+	// backlogs are the backlogs of the last config read, where move directives send tasks.
+	backlogs []SingleBacklogConfig
+	// moved holds the block refs moved by directives and not yet added to their backlog page, by page.
+	moved map[string]*set.Set[string]
 }
 
 func NewBacklog(graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI, reader ConfigReader,
 	currentTime func() time.Time) Backlog {
-	return &backlogImpl{graph: graph, logseqAPI: logseqAPI, configReader: reader, currentTime: currentTime}
+	return &backlogImpl{
+		graph: graph, logseqAPI: logseqAPI, configReader: reader, currentTime: currentTime,
+		sections: map[string][]Header{}, icons: map[string]string{}, limits: map[string]Limits{}, backlogs: nil,
+		moved: map[string]*set.Set[string]{},
+	}
 }
 
 func (b *backlogImpl) Graph() *logseq.Graph {
@@ -48,7 +100,12 @@ func (b *backlogImpl) ProcessAll(partialNames []string) error {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
 
-	allFocusTasks := logseqapi.NewCategorizedTasks()
+	if len(config.Backlogs) == 0 {
+		fmt.Println("no pages found in the backlog (run 'lqd doctor' to check the backlog config)")
+	}
+
+	var focusBacklogs []focusBacklog
+
 	processAllPages := len(partialNames) == 0
 	showQuickCapture := false
 
@@ -58,6 +115,15 @@ func (b *backlogImpl) ProcessAll(partialNames []string) error {
 		fmt.Printf("Processing pages with partial names: %s\n", strings.Join(partialNames, ", "))
 	}
 
+	b.icons[config.FocusPage] = config.Icon(config.FocusPage)
+	b.backlogs = config.Backlogs
+
+	for _, backlogConfig := range config.Backlogs {
+		b.sections[backlogConfig.BacklogPage] = backlogConfig.Sections
+		b.icons[backlogConfig.BacklogPage] = backlogConfig.Icon
+		b.limits[backlogConfig.BacklogPage] = backlogConfig.Limits
+	}
+
 	for _, backlogConfig := range config.Backlogs {
 		processThisPage := processAllPages
 
@@ -73,27 +139,54 @@ func (b *backlogImpl) ProcessAll(partialNames []string) error {
 			continue
 		}
 
+		var tasks *logseqapi.CategorizedTasks
+
 		result, err := b.ProcessOne(backlogConfig.BacklogPage,
 			func() (*logseqapi.CategorizedTasks, error) {
-				return queryTasksFromPages(b.graph, b.logseqAPI, backlogConfig.InputPages, b.currentTime)
+				queried, err := QueryTasksFromPages(b.graph, b.logseqAPI, backlogConfig, b.currentTime)
+				tasks = queried
+
+				return queried, err
 			})
 		if err != nil {
 			return err
 		}
 
-		allFocusTasks.All.Update(result.FocusRefsFromPage)
+		if !backlogConfig.ExcludeFromFocus {
+			focusBacklogs = append(focusBacklogs, focusBacklog{page: backlogConfig.BacklogPage, result: result, tasks: tasks})
+		}
 
 		if result.ShowQuickCapture {
 			showQuickCapture = true
 		}
 	}
 
+	err = b.processMovedRefs(config.Backlogs)
+	if err != nil {
+		return err
+	}
+
+	if policy := FillFocusPolicy(); policy != nil {
+		err = b.fillFocus(*policy, focusBacklogs, b.unprocessedFocusCandidates(config.Backlogs, focusBacklogs))
+		if err != nil {
+			return err
+		}
+	}
+
 	if !processAllPages {
 		color.Yellow("Skipping focus page because not all pages were processed")
 
 		return b.maybeShowQuickCapture(showQuickCapture)
 	}
 
+	allFocusTasks := logseqapi.NewCategorizedTasks()
+
+	for _, focus := range focusBacklogs {
+		allFocusTasks.All.Update(focus.result.FocusRefsFromPage)
+		allFocusTasks.All.Update(focus.result.DueSoonRefs)
+		allFocusTasks.DueSoon.Update(focus.result.DueSoonRefs)
+	}
+
 	return b.processFocusPage(config.FocusPage, &allFocusTasks, showQuickCapture)
 }
 
@@ -110,14 +203,22 @@ func (b *backlogImpl) ProcessOne(pageTitle string,
 
 	existingBlockRefs := blockRefsFromPages(page)
 
-	fmt.Printf("%s: %s", internal.PageColor(pageTitle), FormatCount(existingBlockRefs.Size(), "task", "tasks"))
+	fmt.Printf("%s: %s", b.pageTitle(pageTitle), FormatCount(existingBlockRefs.Size(), "task", "tasks"))
 
 	blockRefsFromQuery, err := funcQueryRefs()
 	if err != nil {
 		return nil, err
 	}
 
+	// Tasks moved here by a directive may not be found by the query yet, until Logseq indexes their new tag.
+	movedHere := set.NewSet[string]()
+	if moved, ok := b.moved[pageTitle]; ok {
+		movedHere = moved
+		delete(b.moved, pageTitle)
+	}
+
 	newBlockRefs := blockRefsFromQuery.All.Diff(existingBlockRefs)
+	newBlockRefs.Update(movedHere.Diff(existingBlockRefs))
 
 	// Calculate obsolete refs, but exclude DOING tasks from removal
 	// DOING tasks should be preserved even if they're not in the All set
@@ -125,18 +226,62 @@ func (b *backlogImpl) ProcessOne(pageTitle string,
 	allValidRefs.Update(blockRefsFromQuery.All)
 	allValidRefs.Update(blockRefsFromQuery.Doing)
 	allValidRefs.Update(blockRefsFromQuery.FutureScheduled)
+	allValidRefs.Update(movedHere)
 	obsoleteBlockRefs := existingBlockRefs.Diff(allValidRefs)
 
 	result, err := insertAndRemoveRefs(b.graph, b.logseqAPI, pageTitle, newBlockRefs, obsoleteBlockRefs,
-		blockRefsFromQuery.Overdue, blockRefsFromQuery.FutureScheduled, blockRefsFromQuery.TaskLookup,
-		b.currentTime)
+		blockRefsFromQuery.Overdue, blockRefsFromQuery.FutureScheduled,
+		blockRefsFromQuery.DueSoon, blockRefsFromQuery.Blocked,
+		blockRefsFromQuery.TaskLookup,
+		b.sections[pageTitle], b.backlogs, b.limits[pageTitle], b.currentTime)
 	if err != nil {
 		return nil, err
 	}
 
+	for page, refs := range result.MovedRefs {
+		if b.moved[page] == nil {
+			b.moved[page] = set.NewSet[string]()
+		}
+
+		b.moved[page].Update(refs)
+	}
+
 	return result, nil
 }
 
+// pageTitle returns the coloured title of a page, after its icon.
+func (b *backlogImpl) pageTitle(pageTitle string) string {
+	title := internal.PageColor(pageTitle)
+	if icon := b.icons[pageTitle]; icon != "" {
+		title = icon + " " + title
+	}
+
+	return title
+}
+
+// processMovedRefs processes again the backlog pages that tasks were moved to after they were processed,
+// or that were skipped, so a move directive takes effect in one run.
+// Directives on those pages may move tasks further, until no moved task is left.
+func (b *backlogImpl) processMovedRefs(backlogs []SingleBacklogConfig) error {
+	for len(b.moved) > 0 {
+		for _, backlogConfig := range backlogs {
+			if _, ok := b.moved[backlogConfig.BacklogPage]; !ok {
+				continue
+			}
+
+			_, err := b.ProcessOne(backlogConfig.BacklogPage,
+				func() (*logseqapi.CategorizedTasks, error) {
+					return QueryTasksFromPages(b.graph, b.logseqAPI, backlogConfig, b.currentTime)
+				})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (b *backlogImpl) processFocusPage(
 	focusPage string, allFocusTasks *logseqapi.CategorizedTasks, backlogChanged bool,
 ) error {
@@ -174,83 +319,123 @@ func blockRefsFromPages(page logseq.Page) *set.Set[string] {
 	return existingRefs
 }
 
-// queryTasksFromPages queries Logseq API for tasks from specified pages.
-// It uses concurrent processing for multiple pages and sequential processing for a single page.
-func queryTasksFromPages(graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI,
-	pageTitles []string, currentTime func() time.Time) (*logseqapi.CategorizedTasks, error) {
+// QueryTasksFromPages queries Logseq API for tasks from the input pages of a backlog, or with its custom query.
+func QueryTasksFromPages(graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI,
+	backlogConfig SingleBacklogConfig, currentTime func() time.Time) (*logseqapi.CategorizedTasks, error) {
 	tasks := logseqapi.NewCategorizedTasks()
-	finder := logseqext.NewLogseqFinder(graph)
 
-	if len(pageTitles) <= 1 {
-		return queryTasksFromPagesSequential(logseqAPI, pageTitles, &tasks, finder, currentTime)
-	}
-
-	return queryTasksFromPagesConcurrent(logseqAPI, pageTitles, &tasks, finder, currentTime)
-}
-
-// queryTasksFromPagesSequential processes pages sequentially (original implementation).
-func queryTasksFromPagesSequential(logseqAPI logseqapi.LogseqAPI,
-	pageTitles []string, tasks *logseqapi.CategorizedTasks,
-	finder logseqext.LogseqFinder, currentTime func() time.Time) (*logseqapi.CategorizedTasks, error) {
-	for _, pageTitle := range pageTitles {
-		jsonTasks, err := queryTasksFromSinglePage(logseqAPI, pageTitle, finder)
+	if backlogConfig.Query != "" {
+		jsonTasks, err := runTaskQuery(logseqAPI, backlogConfig.Query)
 		if err != nil {
 			return nil, err
 		}
 
-		fmt.Printf(" %s: ", internal.PageColor(pageTitle))
+		jsonTasks = filterTasks(jsonTasks, backlogConfig)
+
+		fmt.Print(" query: ")
 		fmt.Print(FormatCount(len(jsonTasks), "task", "tasks"))
 
-		addTasksToCategories(jsonTasks, tasks, currentTime)
+		addTasksToCategories(jsonTasks, &tasks, currentTime)
+		markBlockedTasks(logseqAPI, &tasks)
+
+		return &tasks, nil
 	}
 
-	return tasks, nil
-}
+	finder := logseqext.NewLogseqFinder(graph)
 
-// queryTasksFromPagesConcurrent processes pages concurrently using goroutines.
-func queryTasksFromPagesConcurrent(logseqAPI logseqapi.LogseqAPI,
-	pageTitles []string, tasks *logseqapi.CategorizedTasks,
-	finder logseqext.LogseqFinder, currentTime func() time.Time) (*logseqapi.CategorizedTasks, error) {
-	type pageResult struct {
-		pageTitle string
-		jsonTasks []logseqapi.TaskJSON
-		err       error
+	err := queryInputPages(logseqAPI, backlogConfig, &tasks, finder, currentTime)
+	if err != nil {
+		return nil, err
 	}
 
-	resultChan := make(chan pageResult, len(pageTitles))
+	markBlockedTasks(logseqAPI, &tasks)
 
-	for _, pageTitle := range pageTitles {
-		go func(title string) {
-			jsonTasks, err := queryTasksFromSinglePage(logseqAPI, title, finder)
-			resultChan <- pageResult{pageTitle: title, jsonTasks: jsonTasks, err: err}
-		}(pageTitle)
-	}
+	return &tasks, nil
+}
+
+// queryInputPages queries the input pages of a backlog on a pool of QueryWorkers goroutines, so a backlog with
+// dozens of pages doesn't flood the Logseq API. The task counts are printed in the order of the input pages,
+// then the time taken. The first failure cancels the queries not started yet; all the failures are returned,
+// with the pages not queried (ErrPagesNotQueried).
+func queryInputPages(logseqAPI logseqapi.LogseqAPI, backlogConfig SingleBacklogConfig,
+	tasks *logseqapi.CategorizedTasks, finder logseqext.LogseqFinder, currentTime func() time.Time) error {
+	start := time.Now()
+
+	results := pool.Run(context.Background(), QueryWorkers(), backlogConfig.InputPages,
+		func(ctx context.Context, pageTitle string) ([]logseqapi.TaskJSON, error) {
+			// Another worker may have failed since this page was handed out.
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", pool.ErrSkipped, context.Cause(ctx))
+			}
+
+			return queryTasksFromSinglePage(logseqAPI, pageTitle, backlogConfig, finder)
+		})
+
+	var (
+		errs       []error
+		notQueried []string
+	)
 
-	for range pageTitles {
-		result := <-resultChan
+	for index, result := range results {
+		pageTitle := backlogConfig.InputPages[index]
 
-		if result.err != nil {
-			return nil, result.err
+		switch {
+		case errors.Is(result.Err, pool.ErrSkipped):
+			notQueried = append(notQueried, pageTitle)
+
+			continue
+		case result.Err != nil:
+			errs = append(errs, fmt.Errorf("page %s: %w", pageTitle, result.Err))
+
+			continue
 		}
 
-		// Print results in the order they complete (may be different from input order)
-		fmt.Printf(" %s: ", internal.PageColor(result.pageTitle))
-		fmt.Print(FormatCount(len(result.jsonTasks), "task", "tasks"))
+		fmt.Printf(" %s: ", internal.PageColor(pageTitle))
+		fmt.Print(FormatCount(len(result.Value), "task", "tasks"))
+
+		addTasksToCategories(result.Value, tasks, currentTime)
+	}
+
+	fmt.Printf(" (%s in %s)", FormatCount(len(results), "page", "pages"), time.Since(start).Round(time.Millisecond))
 
-		addTasksToCategories(result.jsonTasks, tasks, currentTime)
+	if len(notQueried) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrPagesNotQueried, strings.Join(notQueried, ", ")))
 	}
 
-	return tasks, nil
+	return errors.Join(errs...)
 }
 
-// queryTasksFromSinglePage queries tasks from a single page and returns the JSON tasks.
-func queryTasksFromSinglePage(logseqAPI logseqapi.LogseqAPI, pageTitle string,
+// queryTasksFromSinglePage queries tasks from a single page and returns the JSON tasks the backlog keeps.
+// A query written on the page replaces the default one, and the excluded pages are not applied to it;
+// excluded statuses and page types are.
+func queryTasksFromSinglePage(logseqAPI logseqapi.LogseqAPI, pageTitle string, backlogConfig SingleBacklogConfig,
 	finder logseqext.LogseqFinder) ([]logseqapi.TaskJSON, error) {
 	query := finder.FindFirstQuery(pageTitle)
 	if query == "" {
-		query = defaultQuery(pageTitle)
+		query = defaultQuery(pageTitle, backlogConfig.ExcludedTags)
+	}
+
+	jsonTasks, err := runTaskQuery(logseqAPI, query)
+	if err != nil {
+		return nil, err
 	}
 
+	return filterTasks(jsonTasks, backlogConfig), nil
+}
+
+// filterTasks leaves out the tasks with a status or on a page type that the backlog excludes.
+func filterTasks(jsonTasks []logseqapi.TaskJSON, backlogConfig SingleBacklogConfig) []logseqapi.TaskJSON {
+	return slices.DeleteFunc(jsonTasks, func(task logseqapi.TaskJSON) bool {
+		journal := task.Page.JournalDay > 0
+
+		return slices.Contains(backlogConfig.ExcludedStatuses, task.Marker) ||
+			(journal && backlogConfig.ExcludeJournals) ||
+			(!journal && backlogConfig.ExcludePages)
+	})
+}
+
+// runTaskQuery runs a Logseq query and returns the tasks it found.
+func runTaskQuery(logseqAPI logseqapi.LogseqAPI, query string) ([]logseqapi.TaskJSON, error) {
 	jsonStr, err := logseqAPI.PostQuery(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Logseq API: %w", err)
@@ -267,6 +452,8 @@ func queryTasksFromSinglePage(logseqAPI logseqapi.LogseqAPI, pageTitle string,
 // addTasksToCategories adds tasks to the appropriate categories in CategorizedTasks.
 func addTasksToCategories(jsonTasks []logseqapi.TaskJSON, tasks *logseqapi.CategorizedTasks,
 	currentTime func() time.Time) {
+	leadDays := Headers().DueSoonLeadDays()
+
 	for _, task := range jsonTasks {
 		tasks.TaskLookup[task.UUID] = task
 
@@ -278,6 +465,10 @@ func addTasksToCategories(jsonTasks []logseqapi.TaskJSON, tasks *logseqapi.Categ
 			tasks.FutureScheduled.Add(task.UUID)
 		}
 
+		if logseqapi.TaskDueSoon(task, currentTime, leadDays) {
+			tasks.DueSoon.Add(task.UUID)
+		}
+
 		if logseqapi.TaskDoing(task) {
 			tasks.Doing.Add(task.UUID)
 		} else {
@@ -288,6 +479,12 @@ func addTasksToCategories(jsonTasks []logseqapi.TaskJSON, tasks *logseqapi.Categ
 	}
 }
 
-func defaultQuery(pageTitle string) string {
-	return fmt.Sprintf("(and [[%s]] (task TODO LATER DOING NOW WAITING))", pageTitle)
+func defaultQuery(pageTitle string, excluded []string) string {
+	var exclusions strings.Builder
+
+	for _, tag := range excluded {
+		fmt.Fprintf(&exclusions, " (not [[%s]])", tag)
+	}
+
+	return fmt.Sprintf("(and [[%s]] (task %s)%s)", pageTitle, strings.Join(openTaskStatuses, " "), exclusions.String())
 }
@@ -1,11 +1,14 @@
 package backlog_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
 	"github.com/andreoliwa/logseq-doctor/internal/testutils"
 	"github.com/andreoliwa/logseq-go/content"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -195,6 +198,76 @@ func TestFocusOnlyShowsQuickCapture(t *testing.T) {
 	require.Contains(t, output, "logseq://graph/")
 }
 
+func TestFillFocus(t *testing.T) {
+	backlog.SetFocusPolicy(&backlog.FocusPolicy{PerBacklog: 1, Limit: 3, OverduePriorityA: true})
+	t.Cleanup(func() { backlog.SetFocusPolicy(nil) })
+
+	fixture := homePhoneFixture(t).Add(
+		testutils.Task("home-fix-leak", content.TaskStringTodo, "Fix the leak under the sink",
+			testutils.WithTags("home"), testutils.WithPriority("A"), testutils.WithDeadline("-1d")),
+	)
+	back := fixture.FakeBacklog(t, "bk", "fill-focus")
+
+	// The overdue task with priority A goes first, the task the policy chose before keeps its place,
+	// and the phone backlog has no room left besides the task put in focus by hand.
+	// A second run finds the same tasks and changes nothing.
+	for range 2 {
+		require.NoError(t, back.ProcessAll([]string{}))
+		fixture.AssertGoldenPages(t, back.Graph(), "fill-focus", []string{"bk___home", "bk___phone", "bk___Focus"})
+	}
+}
+
+func TestFillFocus_PartialRun(t *testing.T) {
+	backlog.SetFocusPolicy(&backlog.FocusPolicy{PerBacklog: 1, Limit: 3, OverduePriorityA: true})
+	t.Cleanup(func() { backlog.SetFocusPolicy(nil) })
+
+	fixture := homePhoneFixture(t).Add(
+		testutils.Task("home-fix-leak", content.TaskStringTodo, "Fix the leak under the sink",
+			testutils.WithTags("home"), testutils.WithPriority("A"), testutils.WithDeadline("-1d")),
+	)
+	back := fixture.FakeBacklog(t, "bk", "fill-focus-partial")
+
+	// Only the home backlog runs, like on a rerun of --watch. The two focus tasks of the phone backlog
+	// still take room on the Focus page: the overdue task with priority A takes the last place,
+	// and the task the policy chose before is taken out. The phone page is left as it was.
+	output := testutils.CaptureOutput(func() {
+		require.NoError(t, back.ProcessAll([]string{"home"}))
+	})
+
+	assert.Contains(t, output, "1 task was put in focus")
+	assert.Contains(t, output, "Skipping focus page because not all pages were processed")
+	fixture.AssertGoldenPages(t, back.Graph(), "fill-focus-partial", []string{"bk___home", "bk___phone"})
+}
+
+func TestFocusPolicy(t *testing.T) {
+	t.Cleanup(func() { backlog.SetFocusPolicy(nil) })
+
+	assert.Nil(t, backlog.FillFocusPolicy())
+
+	policy := &backlog.FocusPolicy{PerBacklog: 2, Limit: 10, OverduePriorityA: true}
+	backlog.SetFocusPolicy(policy)
+	assert.Same(t, policy, backlog.FillFocusPolicy())
+	assert.Equal(t, "2 tasks per backlog, overdue tasks with priority A, 10 at most", policy.String())
+	assert.Equal(t, "1 task per backlog", backlog.FocusPolicy{PerBacklog: 1}.String())
+}
+
+func TestWIPLimits(t *testing.T) {
+	fixture := homePhoneFixture(t).Add(
+		testutils.Task("home-fix-door", content.TaskStringDoing, "Fix the garage door", testutils.WithTags("home")),
+		testutils.Task("home-call-plumber", content.TaskStringDoing, "Call the plumber", testutils.WithTags("home")),
+	)
+	back := fixture.FakeBacklog(t, "bk", "wip-limits")
+
+	// The home backlog is over three of its limits: the warning block is rewritten at the top of its page.
+	// Its DOING tasks are counted from its queried tasks, also the one that is not on its page.
+	// The phone backlog is back within its limit: its warning block is removed.
+	// A second run finds the same breaches and changes nothing.
+	for range 2 {
+		require.NoError(t, back.ProcessAll([]string{}))
+		fixture.AssertGoldenPages(t, back.Graph(), "wip-limits", []string{"bk___home", "bk___phone"})
+	}
+}
+
 func TestDeletedTasks(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -229,6 +302,96 @@ func TestUnrankedTasks(t *testing.T) {
 	})
 }
 
+func TestBlockedSection(t *testing.T) {
+	todo := content.TaskStringTodo
+
+	blockedBy := func(slug string) testutils.BlockOpt {
+		return testutils.WithExtraProps(map[string]string{"blocked-by": "(( " + slug + " ))"})
+	}
+
+	fixture := testutils.NewFixture(t,
+		testutils.Task("blk-blocker", todo, "Buy paint", testutils.WithTags("home")),
+		testutils.Task("blk-waits", todo, "Paint the wall", testutils.WithTags("home"), blockedBy("blk-blocker")),
+		testutils.Task("blk-finished", content.TaskStringDone, "Buy brushes"),
+		testutils.Task("blk-unblocked", todo, "Clean the brushes", testutils.WithTags("home"),
+			blockedBy("blk-finished")),
+		testutils.Task("blk-new-blocked", todo, "Hang the picture", testutils.WithTags("home"),
+			blockedBy("blk-waits")),
+		testutils.Task("blk-cycle-a", todo, "Choose a colour", testutils.WithTags("home"), blockedBy("blk-cycle-b")),
+		testutils.Task("blk-cycle-b", todo, "Ask the landlord", testutils.WithTags("home"), blockedBy("blk-cycle-a")),
+	)
+
+	back := fixture.FakeBacklogWithUUIDPages(t, "bk", "blocked", map[string]string{"blk-finished": "tasks"})
+
+	err := back.ProcessAll([]string{})
+	require.NoError(t, err)
+
+	// Tasks with open blockers move to Blocked, new ones go straight there,
+	// and a task whose blockers are all DONE goes back to New tasks. The cycle is only reported.
+	fixture.AssertGoldenPages(t, back.Graph(), "blocked", []string{"bk___home"})
+}
+
+func TestDueSoonSection(t *testing.T) {
+	todo := content.TaskStringTodo
+	home := testutils.WithTags("home")
+
+	fixture := testutils.NewFixture(t,
+		testutils.Task("ds-tomorrow", todo, "Renew the passport", home, testutils.WithDeadline("+1d")),
+		testutils.Task("ds-week", todo, "Pay the car insurance", home, testutils.WithDeadline("+7d")),
+		testutils.Task("ds-later", todo, "Book the holidays", home, testutils.WithDeadline("+8d")),
+		testutils.Task("ds-postponed", todo, "File the taxes", home, testutils.WithDeadline("+20d")),
+		testutils.Task("ds-scheduled-later", todo, "Return the library books", home,
+			testutils.WithScheduled("+30d"), testutils.WithDeadline("+3d")),
+	)
+
+	back := fixture.FakeBacklog(t, "bk", "due-soon")
+
+	err := back.ProcessAll([]string{})
+	require.NoError(t, err)
+
+	// Deadlines in the next 7 days gather under Due soon, even when scheduled later, and on the Focus page too;
+	// a postponed deadline goes back to New tasks.
+	fixture.AssertGoldenPages(t, back.Graph(), "due-soon", []string{"bk___home", "bk___Focus"})
+}
+
+func TestDoneSection(t *testing.T) {
+	enabled := true
+
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameDone: {Enabled: &enabled},
+	})
+	require.NoError(t, err)
+
+	backlog.SetHeaders(registry)
+	t.Cleanup(func() { backlog.SetHeaders(nil) })
+
+	todo := content.TaskStringTodo
+	done := content.TaskStringDone
+
+	fixture := testutils.NewFixture(t,
+		testutils.Task("done-open", todo, "Keep this task open", testutils.WithTags("home")),
+		testutils.Task("done-finished", done, "Finish this task"),
+		testutils.Task("done-canceled", content.TaskStringCanceled, "Cancel this task"),
+		testutils.Task("done-directive", todo, "Complete with a directive", testutils.WithTags("home")),
+		testutils.Task("done-reopened", todo, "Reopen this task", testutils.WithTags("home")),
+		testutils.Task("done-recent", done, "Finished two days ago"),
+		testutils.Task("done-old", done, "Finished two weeks ago"),
+	)
+
+	back := fixture.FakeBacklogWithUUIDPages(t, "bk", "done", map[string]string{
+		"done-finished":  "tasks",
+		"done-canceled":  "tasks",
+		"done-directive": "tasks",
+	})
+
+	err = back.ProcessAll([]string{})
+	require.NoError(t, err)
+
+	// DONE tasks go to the Done section, canceled ones are removed, a reopened task goes back to New tasks,
+	// and entries older than a week are archived.
+	fixture.AssertGoldenPages(t, back.Graph(), "done", []string{"bk___home", "bk___home___archive", "tasks"})
+}
+
 func TestOverdueTasks(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -347,3 +510,86 @@ func TestDoingTasks(t *testing.T) {
 		})
 	}
 }
+
+var errQueryFailed = errors.New("query failed")
+
+// brokenPagesAPI is a Logseq API that fails the queries of the pages starting with "broken" and finds no tasks
+// on the other ones.
+type brokenPagesAPI struct{}
+
+func (brokenPagesAPI) PostQuery(query string) (string, error) {
+	if strings.Contains(query, "[[broken") {
+		return "", errQueryFailed
+	}
+
+	return "[]", nil
+}
+
+func (brokenPagesAPI) PostDatascriptQuery(string) (string, error) { return "[]", nil }
+
+func (brokenPagesAPI) UpsertBlockProperty(_, _, _ string) error { return nil }
+
+func TestQueryInputPages_Failures(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    int
+		reported   []string
+		notQueried string
+	}{
+		{name: "all the failures are reported", workers: 0, reported: []string{"broken-a", "broken-b"}},
+		{
+			name: "the first failure skips the other pages", workers: 1, reported: []string{"broken-a"},
+			notQueried: "fine, broken-b",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backlog.SetQueryWorkers(test.workers)
+			t.Cleanup(func() { backlog.SetQueryWorkers(0) })
+
+			graph := testutils.NewFixture(t).FakeBacklog(t, "bk", "").Graph()
+			configFile := writeConfigFile(t, "backlogs.toml", `
+[[backlogs]]
+page = "bk/mixed"
+input = ["broken-a", "fine", "broken-b"]
+`)
+			back := backlog.NewBacklog(graph, brokenPagesAPI{}, backlog.NewFileConfigReader(configFile, "bk"),
+				testutils.RelativeTime)
+
+			var err error
+
+			output := testutils.CaptureOutput(func() {
+				err = back.ProcessAll(nil)
+			})
+
+			require.ErrorIs(t, err, errQueryFailed)
+
+			for _, page := range test.reported {
+				assert.Contains(t, err.Error(), "page "+page+": ")
+			}
+
+			assert.Equal(t, len(test.reported), strings.Count(err.Error(), "page "))
+			assert.Equal(t, test.notQueried != "", !strings.Contains(output, "fine: 0 tasks (3 pages in "), output)
+
+			if test.notQueried == "" {
+				assert.NotErrorIs(t, err, backlog.ErrPagesNotQueried)
+			} else {
+				require.ErrorIs(t, err, backlog.ErrPagesNotQueried)
+				assert.Contains(t, err.Error(), "input pages not queried: "+test.notQueried)
+			}
+		})
+	}
+}
+
+func TestQueryWorkers(t *testing.T) {
+	t.Cleanup(func() { backlog.SetQueryWorkers(0) })
+
+	assert.Equal(t, backlog.DefaultQueryWorkers, backlog.QueryWorkers())
+
+	backlog.SetQueryWorkers(8)
+	assert.Equal(t, 8, backlog.QueryWorkers())
+
+	backlog.SetQueryWorkers(-1)
+	assert.Equal(t, backlog.DefaultQueryWorkers, backlog.QueryWorkers())
+}
@@ -0,0 +1,41 @@
+package backlog
+
+import (
+	"maps"
+	"slices"
+
+	logseq "github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/fatih/color"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+// markBlockedTasks sets the tasks waiting for open blockers (blocked-by:: property),
+// warning about the tasks that block each other: none of them can ever be unblocked.
+func markBlockedTasks(logseqAPI logseqapi.LogseqAPI, tasks *logseqapi.CategorizedTasks) {
+	deps := logseqapi.ResolveDependencies(logseqAPI, slices.Collect(maps.Values(tasks.TaskLookup)))
+	tasks.Blocked = deps.Blocked
+
+	for _, cycle := range deps.Cycles {
+		color.Yellow("\n[backlog] WARNING: dependency cycle: %s", logseqapi.FormatCycle(cycle, tasks.TaskLookup))
+	}
+}
+
+// insertBlockedTasks moves the blocked tasks found elsewhere on the page, and new blocked tasks,
+// under the Blocked divider (creating it if needed, before the Done section or at the bottom of the page).
+func insertBlockedTasks(page logseq.Page, state *pageState) {
+	for _, blockRef := range state.newlyBlockedRefs.ValuesSorted() {
+		if state.dividerBlocked == nil {
+			state.dividerBlocked = content.NewBlock(state.headers.Get(SectionNameBlocked).NewHeading())
+			if !placeSection(page, state, SectionNameBlocked, state.dividerBlocked) {
+				logseqext.AddSibling(page, state.dividerBlocked, state.dividerDone)
+			}
+		}
+
+		if !BlockRefExistsUnder(state.dividerBlocked, blockRef) {
+			state.dividerBlocked.AddChild(content.NewBlock(content.NewBlockRef(blockRef)))
+		}
+	}
+}
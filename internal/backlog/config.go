@@ -1,20 +1,48 @@
 package backlog
 
 import (
-	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/andreoliwa/logseq-go/content"
 )
 
 type SingleBacklogConfig struct {
 	BacklogPage string
-	Icon        string
-	InputPages  []string
+	// Icon is an emoji shown next to the backlog name in the console, the dashboard and lqd task ls.
+	Icon       string
+	InputPages []string
+	// ExcludedTags are pages or tags whose tasks are left out, even when they reference an input page.
+	ExcludedTags []string
+	// ExcludedStatuses are task statuses left out, e.g. WAITING.
+	ExcludedStatuses []string
+	// ExcludeJournals leaves out the tasks written on journal pages.
+	ExcludeJournals bool
+	// ExcludePages leaves out the tasks written on regular pages, keeping the journal ones.
+	ExcludePages bool
+	// Query replaces the queries of the input pages, when set.
+	Query string
+	// ExcludeFromFocus keeps the tasks of this backlog away from the Focus page.
+	ExcludeFromFocus bool
+	// Sections is the order of the section headers on the backlog page. New sections are placed accordingly;
+	// sections not listed keep their default place.
+	Sections []Header
+	// Limits are the WIP limits of the backlog, reported by lqd backlog and lqd sync when they are exceeded.
+	Limits Limits
 }
 
+// PropertyIcon is the block property that sets the icon of a backlog on the config page.
+const PropertyIcon = "icon"
+
+// Exclusion words on a line of the config page, besides excluded pages and tags (-[[page]], -#tag).
+const (
+	ExcludeJournalsWord = "-journals"
+	ExcludePagesWord    = "-pages"
+)
+
 type Config struct {
 	FocusPage string
 	Backlogs  []SingleBacklogConfig
@@ -38,7 +66,11 @@ func NewPageConfigReader(graph *logseq.Graph, configPage string) ConfigReader {
 }
 
 // ReadConfig reads the backlog configuration from a Logseq page.
-func (p *pageConfigReader) ReadConfig() (*Config, error) { //nolint:cyclop,funlen
+// The icon of a backlog is its icon:: property, or the first emoji on its line.
+// Its WIP limits are the max-doing::, max-focus:: and max-open:: properties.
+// Pages and tags prefixed with a dash (-[[someday]], -#personal) are excluded instead of read,
+// and so are the statuses (-WAITING) and page types (-journals, -pages) written the same way.
+func (p *pageConfigReader) ReadConfig() (*Config, error) { //nolint:cyclop,funlen,gocognit
 	configPage := api.OpenPage(p.graph, p.configPage)
 
 	var backlogs []SingleBacklogConfig
@@ -48,12 +80,26 @@ func (p *pageConfigReader) ReadConfig() (*Config, error) { //nolint:cyclop,funle
 
 		firstRegularPage := ""
 		backlogPage := ""
+		icon := logseqext.BlockPropertyText(block, PropertyIcon)
+		previousText := ""
+		exclusions := SingleBacklogConfig{} //nolint:exhaustruct // only the exclusions are filled
 
 		// TODO: simplify and replace by FilterDeep after a test is added
 		block.Children().FindDeep(func(node content.Node) bool {
 			target := ""
 			isLink := false
 
+			if text, ok := node.(*content.Text); ok {
+				if icon == "" {
+					icon = logseqext.FirstEmoji(text.Value)
+				}
+
+				exclusions.addExclusions(text.Value)
+				previousText = text.Value
+
+				return false
+			}
+
 			if pageLink, ok := node.(*content.PageLink); ok {
 				target = pageLink.To
 			} else if tag, ok := node.(*content.Hashtag); ok {
@@ -80,6 +126,12 @@ func (p *pageConfigReader) ReadConfig() (*Config, error) { //nolint:cyclop,funle
 				return false
 			}
 
+			if strings.HasSuffix(previousText, "-") {
+				exclusions.ExcludedTags = append(exclusions.ExcludedTags, target)
+
+				return false
+			}
+
 			if firstRegularPage == "" {
 				firstRegularPage = p.configPage + "/" + target
 			}
@@ -95,19 +147,78 @@ func (p *pageConfigReader) ReadConfig() (*Config, error) { //nolint:cyclop,funle
 				chosenPage = firstRegularPage
 			}
 
-			backlogs = append(backlogs, SingleBacklogConfig{
-				BacklogPage: chosenPage,
-				Icon:        "",
-				InputPages:  inputPages,
+			backlogs = append(backlogs, SingleBacklogConfig{ //nolint:exhaustruct // the page has no other options
+				BacklogPage:      chosenPage,
+				Icon:             icon,
+				InputPages:       inputPages,
+				ExcludedTags:     exclusions.ExcludedTags,
+				ExcludedStatuses: exclusions.ExcludedStatuses,
+				ExcludeJournals:  exclusions.ExcludeJournals,
+				ExcludePages:     exclusions.ExcludePages,
+				Limits:           parseLimits(block),
 			})
 		}
 	}
 
-	if len(backlogs) == 0 {
-		fmt.Println("no pages found in the backlog")
+	return &Config{FocusPage: p.configPage + "/Focus", Backlogs: backlogs}, nil
+}
+
+// addExclusions adds the exclusions written as plain text on a line of the config page:
+// task statuses, page types, and tags the parser left as text (-#tag).
+func (c *SingleBacklogConfig) addExclusions(text string) {
+	for _, word := range strings.Fields(text) {
+		switch {
+		case word == ExcludeJournalsWord:
+			c.ExcludeJournals = true
+		case word == ExcludePagesWord:
+			c.ExcludePages = true
+		case strings.HasPrefix(word, "-#") && len(word) > len("-#"):
+			c.ExcludedTags = append(c.ExcludedTags, strings.TrimPrefix(word, "-#"))
+		case strings.HasPrefix(word, "-") && slices.Contains(openTaskStatuses, strings.TrimPrefix(word, "-")):
+			c.ExcludedStatuses = append(c.ExcludedStatuses, strings.TrimPrefix(word, "-"))
+		}
 	}
+}
 
-	return &Config{FocusPage: p.configPage + "/Focus", Backlogs: backlogs}, nil
+// Icon returns the icon of a backlog page, or an empty string if it has none.
+// The Focus page has the emoji of its header.
+func (c *Config) Icon(backlogPage string) string {
+	if backlogPage == c.FocusPage {
+		return Headers().Get(SectionNameFocus).Emoji
+	}
+
+	for _, bc := range c.Backlogs {
+		if bc.BacklogPage == backlogPage {
+			return bc.Icon
+		}
+	}
+
+	return ""
+}
+
+// TaskIcons maps the UUID of each task on a backlog page to the icons of the backlogs it is on, in config order.
+// Backlogs without an icon, and pages that cannot be opened, are skipped.
+func TaskIcons(graph *logseq.Graph, config *Config) map[string]string {
+	icons := make(map[string]string)
+
+	for _, bc := range config.Backlogs {
+		if bc.Icon == "" {
+			continue
+		}
+
+		page, err := graph.OpenPage(bc.BacklogPage)
+		if err != nil {
+			continue
+		}
+
+		for _, uuid := range logseqext.ExtractBlockRefUUIDs(page) {
+			if !strings.Contains(icons[uuid], bc.Icon) {
+				icons[uuid] += bc.Icon
+			}
+		}
+	}
+
+	return icons
 }
 
 // FindBacklogPageTitle looks up the full backlog page path from config by backlog name.
@@ -37,6 +37,21 @@ func TestFindBacklogPageTitle(t *testing.T) {
 	assert.Empty(t, cfg.FindBacklogPageTitle("unknown"))
 }
 
+func TestConfig_Icon(t *testing.T) {
+	cfg := &backlog.Config{
+		FocusPage: "config/Focus",
+		Backlogs: []backlog.SingleBacklogConfig{
+			{BacklogPage: "config/computer", InputPages: []string{"computer"}},
+			{BacklogPage: "config/house", Icon: "🏠", InputPages: []string{"house"}},
+		},
+	}
+
+	assert.Equal(t, "🎯", cfg.Icon("config/Focus"))
+	assert.Equal(t, "🏠", cfg.Icon("config/house"))
+	assert.Empty(t, cfg.Icon("config/computer"))
+	assert.Empty(t, cfg.Icon("unknown"))
+}
+
 func TestPageConfigReader_ReadConfig(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
 	prefix := "config"
@@ -55,13 +70,22 @@ func TestPageConfigReader_ReadConfig(t *testing.T) {
 			},
 			{
 				BacklogPage: prefix + "/house",
-				Icon:        "",
+				Icon:        "🏠",
 				InputPages:  []string{"house"},
 			},
 			{
 				BacklogPage: prefix + "/work",
-				Icon:        "",
+				Icon:        "💼",
 				InputPages:  []string{"work", "office"},
+				Limits:      backlog.Limits{Doing: 3, Open: 50},
+			},
+			{
+				BacklogPage:      prefix + "/errands",
+				Icon:             "",
+				InputPages:       []string{"errands", "shopping"},
+				ExcludedTags:     []string{"someday", "personal"},
+				ExcludedStatuses: []string{"WAITING"},
+				ExcludeJournals:  true,
 			},
 			{
 				BacklogPage: prefix + "/start",
@@ -0,0 +1,175 @@
+package backlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConfigFile is returned when a backlog config file cannot be used.
+var ErrInvalidConfigFile = errors.New("invalid backlog config file")
+
+// configFile is the layout of a backlog config file, in TOML:
+//
+//	focus_page = "backlog/Focus"
+//
+//	[[backlogs]]
+//	page = "backlog/work"
+//	icon = "💼"
+//	input = ["work", "office"]
+//	exclude = ["someday"]
+//	exclude_status = ["WAITING"]
+//	exclude_journals = false
+//	exclude_pages = false
+//	focus = false
+//	max_doing = 3
+//	max_focus = 10
+//	max_open = 50
+//	sections = ["focus", "overdue", "new", "triaged", "unranked", "scheduled"]
+//
+// YAML files have the same keys.
+type configFile struct {
+	FocusPage string              `toml:"focus_page" yaml:"focus_page"`
+	Backlogs  []configFileBacklog `toml:"backlogs"   yaml:"backlogs"`
+}
+
+type configFileBacklog struct {
+	Page            string   `toml:"page"             yaml:"page"`
+	Icon            string   `toml:"icon"             yaml:"icon"`
+	Input           []string `toml:"input"            yaml:"input"`
+	Exclude         []string `toml:"exclude"          yaml:"exclude"`
+	ExcludeStatus   []string `toml:"exclude_status"   yaml:"exclude_status"`
+	ExcludeJournals bool     `toml:"exclude_journals" yaml:"exclude_journals"`
+	ExcludePages    bool     `toml:"exclude_pages"    yaml:"exclude_pages"`
+	Query           string   `toml:"query"            yaml:"query"`
+	Focus           *bool    `toml:"focus"            yaml:"focus"`
+	Sections        []string `toml:"sections"         yaml:"sections"`
+	MaxDoing        int      `toml:"max_doing"        yaml:"max_doing"`
+	MaxFocus        int      `toml:"max_focus"        yaml:"max_focus"`
+	MaxOpen         int      `toml:"max_open"         yaml:"max_open"`
+}
+
+type fileConfigReader struct {
+	path       string
+	configPage string
+}
+
+// NewFileConfigReader creates a new ConfigReader that reads the backlogs declared in a TOML or YAML file,
+// told apart by the file extension. Without a focus_page in the file, the Focus page is configPage/Focus,
+// like with NewPageConfigReader.
+func NewFileConfigReader(path, configPage string) ConfigReader {
+	return &fileConfigReader{
+		path:       path,
+		configPage: configPage,
+	}
+}
+
+// ReadConfig reads the backlog configuration from the file.
+func (f *fileConfigReader) ReadConfig() (*Config, error) {
+	data, err := os.ReadFile(f.path) //nolint:gosec // a config file chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the backlog config file: %w", err)
+	}
+
+	var file configFile
+
+	switch strings.ToLower(filepath.Ext(f.path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("%w: %s: use a .toml, .yaml or .yml file", ErrInvalidConfigFile, f.path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfigFile, f.path, err)
+	}
+
+	config := &Config{FocusPage: file.FocusPage, Backlogs: nil}
+	if config.FocusPage == "" {
+		config.FocusPage = f.configPage + "/Focus"
+	}
+
+	for i, entry := range file.Backlogs {
+		single, err := entry.toConfig()
+		if err != nil {
+			return nil, fmt.Errorf("%s: backlog #%d: %w", f.path, i+1, err)
+		}
+
+		config.Backlogs = append(config.Backlogs, single)
+	}
+
+	return config, nil
+}
+
+// toConfig validates a backlog entry of the file and converts it.
+func (e configFileBacklog) toConfig() (SingleBacklogConfig, error) {
+	if e.Page == "" {
+		return SingleBacklogConfig{}, fmt.Errorf("%w: missing page", ErrInvalidConfigFile) //nolint:exhaustruct
+	}
+
+	if len(e.Input) == 0 && e.Query == "" {
+		return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+			"%w: %s: set input pages or a query", ErrInvalidConfigFile, e.Page)
+	}
+
+	if e.ExcludeJournals && e.ExcludePages {
+		return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+			"%w: %s: excluding both journals and pages leaves no tasks", ErrInvalidConfigFile, e.Page)
+	}
+
+	if e.MaxDoing < 0 || e.MaxFocus < 0 || e.MaxOpen < 0 {
+		return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+			"%w: %s: the max_* limits cannot be negative", ErrInvalidConfigFile, e.Page)
+	}
+
+	var statuses []string
+
+	for _, status := range e.ExcludeStatus {
+		status = strings.ToUpper(status)
+		if !slices.Contains(openTaskStatuses, status) {
+			return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+				"%w: %s: unknown status %q", ErrInvalidConfigFile, e.Page, status)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	var sections []Header
+
+	for _, name := range e.Sections {
+		header, ok := defaultHeader(strings.ToLower(name))
+		if !ok {
+			return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+				"%w: %s: unknown section %q", ErrInvalidConfigFile, e.Page, name)
+		}
+
+		if slices.ContainsFunc(sections, header.sameSection) {
+			return SingleBacklogConfig{}, fmt.Errorf( //nolint:exhaustruct
+				"%w: %s: section %q listed twice", ErrInvalidConfigFile, e.Page, name)
+		}
+
+		sections = append(sections, header)
+	}
+
+	return SingleBacklogConfig{
+		BacklogPage:      e.Page,
+		Icon:             e.Icon,
+		InputPages:       e.Input,
+		ExcludedTags:     e.Exclude,
+		ExcludedStatuses: statuses,
+		ExcludeJournals:  e.ExcludeJournals,
+		ExcludePages:     e.ExcludePages,
+		Query:            e.Query,
+		ExcludeFromFocus: e.Focus != nil && !*e.Focus,
+		Sections:         sections,
+		Limits:           Limits{Doing: e.MaxDoing, Focus: e.MaxFocus, Open: e.MaxOpen},
+	}, nil
+}
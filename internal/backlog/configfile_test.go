@@ -0,0 +1,146 @@
+package backlog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+
+	return path
+}
+
+func TestFileConfigReader_TOML(t *testing.T) {
+	path := writeConfigFile(t, "backlogs.toml", `
+focus_page = "work/Focus"
+
+[[backlogs]]
+page = "work/computer"
+icon = "💻"
+input = ["computer", "Android"]
+exclude = ["someday"]
+exclude_status = ["waiting"]
+exclude_pages = true
+max_doing = 2
+max_focus = 5
+sections = ["focus", "New", "overdue", "scheduled"]
+
+[[backlogs]]
+page = "work/reading"
+query = "(and [[book]] (task TODO))"
+focus = false
+`)
+
+	result, err := backlog.NewFileConfigReader(path, "backlog").ReadConfig()
+	require.NoError(t, err)
+
+	expected := backlog.Config{
+		FocusPage: "work/Focus",
+		Backlogs: []backlog.SingleBacklogConfig{
+			{
+				BacklogPage:      "work/computer",
+				Icon:             "💻",
+				InputPages:       []string{"computer", "Android"},
+				ExcludedTags:     []string{"someday"},
+				ExcludedStatuses: []string{"WAITING"},
+				ExcludePages:     true,
+				Sections: []backlog.Header{
+					backlog.HeaderFocus, backlog.HeaderNewTasks, backlog.HeaderOverdue, backlog.HeaderScheduled,
+				},
+				Limits: backlog.Limits{Doing: 2, Focus: 5},
+			},
+			{
+				BacklogPage:      "work/reading",
+				Query:            "(and [[book]] (task TODO))",
+				ExcludeFromFocus: true,
+			},
+		},
+	}
+	assert.Equal(t, &expected, result)
+}
+
+func TestFileConfigReader_YAML(t *testing.T) {
+	path := writeConfigFile(t, "backlogs.yml", `
+backlogs:
+  - page: backlog/house
+    input: [house, garden]
+    focus: true
+`)
+
+	result, err := backlog.NewFileConfigReader(path, "backlog").ReadConfig()
+	require.NoError(t, err)
+
+	expected := backlog.Config{
+		FocusPage: "backlog/Focus",
+		Backlogs: []backlog.SingleBacklogConfig{
+			{BacklogPage: "backlog/house", InputPages: []string{"house", "garden"}},
+		},
+	}
+	assert.Equal(t, &expected, result)
+}
+
+func TestFileConfigReader_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		message  string
+	}{
+		{"unknown extension", "backlogs.json", `{}`, "use a .toml, .yaml or .yml file"},
+		{"syntax error", "backlogs.toml", `[[backlogs]`, "backlogs.toml"},
+		{
+			"missing page", "backlogs.toml", "[[backlogs]]\ninput = [\"work\"]",
+			"backlog #1: invalid backlog config file: missing page",
+		},
+		{"no input", "backlogs.toml", "[[backlogs]]\npage = \"backlog/work\"", "backlog/work: set input pages or a query"},
+		{
+			"unknown section", "backlogs.yaml",
+			"backlogs:\n  - page: backlog/work\n    input: [work]\n    sections: [focus, later]",
+			`backlog/work: unknown section "later"`,
+		},
+		{
+			"section twice", "backlogs.yaml",
+			"backlogs:\n  - page: backlog/work\n    input: [work]\n    sections: [new, New]",
+			`backlog/work: section "New" listed twice`,
+		},
+		{
+			"unknown status", "backlogs.toml",
+			"[[backlogs]]\npage = \"backlog/work\"\ninput = [\"work\"]\nexclude_status = [\"DONE\"]",
+			`backlog/work: unknown status "DONE"`,
+		},
+		{
+			"journals and pages", "backlogs.toml",
+			"[[backlogs]]\npage = \"backlog/work\"\ninput = [\"work\"]\nexclude_journals = true\nexclude_pages = true",
+			"backlog/work: excluding both journals and pages leaves no tasks",
+		},
+		{
+			"negative limit", "backlogs.yaml",
+			"backlogs:\n  - page: backlog/work\n    input: [work]\n    max_open: -1",
+			"backlog/work: the max_* limits cannot be negative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeConfigFile(t, test.file, test.contents)
+
+			_, err := backlog.NewFileConfigReader(path, "backlog").ReadConfig()
+			require.ErrorIs(t, err, backlog.ErrInvalidConfigFile)
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+}
+
+func TestFileConfigReader_missingFile(t *testing.T) {
+	_, err := backlog.NewFileConfigReader(filepath.Join(t.TempDir(), "missing.toml"), "backlog").ReadConfig()
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
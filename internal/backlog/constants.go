@@ -2,24 +2,56 @@ package backlog
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/andreoliwa/logseq-go/content"
 )
 
+// Section names, as used in the sections list of a backlog config file and in the [backlog.headers] tables
+// of the lqd config file.
+const (
+	SectionNameFocus     = "focus"
+	SectionNameOverdue   = "overdue"
+	SectionNameDueSoon   = "due_soon"
+	SectionNameNew       = "new"
+	SectionNameTriaged   = "triaged"
+	SectionNameScheduled = "scheduled"
+	SectionNameBlocked   = "blocked"
+	SectionNameUnranked  = "unranked"
+	SectionNameDone      = "done"
+)
+
 // Header represents a backlog section divider.
-// Label is the display word(s) without the "tasks" suffix (e.g. "Focus").
-// String() always returns "Emoji Label tasks".
+// Name identifies the section, whatever its text. Label is the display word(s) without the suffix
+// (e.g. "Focus"), and Suffix is the word after it ("tasks"), left out when empty.
+// String() returns "Emoji Label Suffix".
 type Header struct {
-	Emoji string
-	Label string
+	Name   string
+	Emoji  string
+	Label  string
+	Suffix string
 }
 
-// String returns the canonical display form: "emoji label tasks".
-func (h Header) String() string { return h.Emoji + " " + h.Label + " tasks" }
+// String returns the canonical display form: "emoji label suffix".
+func (h Header) String() string {
+	text := h.Emoji + " " + h.Label
+	if h.Suffix != "" {
+		text += " " + h.Suffix
+	}
+
+	return text
+}
 
-// Matches reports whether blockText contains the label, case-insensitively.
+// Matches reports whether blockText is the header: the label, with or without the suffix,
+// case-insensitively. Any emoji or heading marker before the label is ignored, so headers written
+// with an older emoji still match; other words on the line make it a regular block.
 func (h Header) Matches(blockText string) bool {
-	return strings.Contains(strings.ToLower(blockText), strings.ToLower(h.Label))
+	text := strings.TrimLeftFunc(blockText, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	text = strings.TrimSpace(text)
+
+	return strings.EqualFold(text, h.Label) || (h.Suffix != "" && strings.EqualFold(text, h.Label+" "+h.Suffix))
 }
 
 // NewHeading returns a level-1 heading node with the canonical header text
@@ -36,26 +68,29 @@ func (h Header) NewHeading() *content.Heading {
 // so the user can identify dividers inserted by lqd backlog.
 const quickCapturePageName = "quick capture"
 
-// Backlog section header definitions.
-// Detection uses Header.Matches (case-insensitive label search).
-// Creation uses Header.String() so the canonical emoji+label+tasks is always written.
+// Default backlog section headers, used unless the lqd config file overrides them (see HeaderRegistry).
+// Detection uses Header.Matches (exact label, case-insensitive).
+// Creation uses Header.String() so the canonical emoji+label+suffix is always written.
 //
 //nolint:gochecknoglobals // named constants for well-known headers
 var (
-	HeaderFocus     = Header{"🎯", "Focus"}
-	HeaderOverdue   = Header{"📅", "Overdue"}
-	HeaderNewTasks  = Header{"✨", "New"}
-	HeaderTriaged   = Header{"🏷️", "Triaged"}
-	HeaderScheduled = Header{"⏰", "Scheduled"}
-	HeaderUnranked  = Header{"⤵️", "Unranked"}
+	HeaderFocus     = Header{SectionNameFocus, "🎯", "Focus", "tasks"}
+	HeaderOverdue   = Header{SectionNameOverdue, "📅", "Overdue", "tasks"}
+	HeaderDueSoon   = Header{SectionNameDueSoon, "⚠️", "Due soon", ""}
+	HeaderNewTasks  = Header{SectionNameNew, "✨", "New", "tasks"}
+	HeaderTriaged   = Header{SectionNameTriaged, "🏷️", "Triaged", "tasks"}
+	HeaderScheduled = Header{SectionNameScheduled, "⏰", "Scheduled", "tasks"}
+	HeaderBlocked   = Header{SectionNameBlocked, "⛔", "Blocked", "tasks"}
+	HeaderUnranked  = Header{SectionNameUnranked, "⤵️", "Unranked", "tasks"}
+	HeaderDone      = Header{SectionNameDone, "✅", "Done", "tasks"}
 )
 
-// allHeaders is the full list used to normalize section dividers on write-back.
+// defaultHeaders is the full list of headers, in their default order on a backlog page.
 //
 //nolint:gochecknoglobals // package-level list derived from the Header vars above
-var allHeaders = []Header{
-	HeaderFocus, HeaderOverdue, HeaderNewTasks,
-	HeaderTriaged, HeaderScheduled, HeaderUnranked,
+var defaultHeaders = []Header{
+	HeaderFocus, HeaderOverdue, HeaderDueSoon, HeaderNewTasks,
+	HeaderTriaged, HeaderScheduled, HeaderBlocked, HeaderUnranked, HeaderDone,
 }
 
 // Section values for the PocketBase `section` field.
@@ -66,3 +101,8 @@ const (
 	SectionUnranked = 2 // under ⤵️ Unranked tasks, 📅 Overdue tasks, ⏰ Scheduled tasks, ✨ New tasks, 🏷️ Triaged tasks
 	SectionOrphan   = 3 // not referenced in any backlog page
 )
+
+// openTaskStatuses are the statuses of the tasks collected by the default query of an input page.
+//
+//nolint:gochecknoglobals // constant list of statuses
+var openTaskStatuses = []string{"TODO", "LATER", "DOING", "NOW", "WAITING"}
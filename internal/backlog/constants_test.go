@@ -14,10 +14,13 @@ func TestHeader_String(t *testing.T) {
 	}{
 		{backlog.HeaderFocus, "🎯 Focus tasks"},
 		{backlog.HeaderOverdue, "📅 Overdue tasks"},
+		{backlog.HeaderDueSoon, "⚠️ Due soon"},
 		{backlog.HeaderNewTasks, "✨ New tasks"},
 		{backlog.HeaderTriaged, "🏷️ Triaged tasks"},
 		{backlog.HeaderScheduled, "⏰ Scheduled tasks"},
+		{backlog.HeaderBlocked, "⛔ Blocked tasks"},
 		{backlog.HeaderUnranked, "⤵️ Unranked tasks"},
+		{backlog.HeaderDone, "✅ Done tasks"},
 	}
 
 	for _, tt := range tests {
@@ -27,6 +30,14 @@ func TestHeader_String(t *testing.T) {
 	}
 }
 
+func TestHeader_String_noSuffix(t *testing.T) {
+	header := backlog.Header{Name: backlog.SectionNameNew, Emoji: "🆕", Label: "Neue Aufgaben", Suffix: ""}
+
+	assert.Equal(t, "🆕 Neue Aufgaben", header.String())
+	assert.True(t, header.Matches("Neue Aufgaben"))
+	assert.False(t, header.Matches("Neue Aufgaben tasks"))
+}
+
 func TestHeader_Matches(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -39,7 +50,10 @@ func TestHeader_Matches(t *testing.T) {
 		{"canonical form matches", backlog.HeaderFocus, "🎯 Focus tasks", true},
 		{"old form without emoji matches", backlog.HeaderNewTasks, "New tasks", true},
 		{"old emoji + old label matches", backlog.HeaderNewTasks, "🆕 New tasks", true},
+		{"heading with quick capture link text matches", backlog.HeaderNewTasks, "✨ New tasks ", true},
 		{"wrong header no match", backlog.HeaderFocus, "scheduled", false},
+		{"label among other words no match", backlog.HeaderNewTasks, "New car for the family", false},
+		{"canonical form with more words no match", backlog.HeaderFocus, "🎯 Focus tasks for today", false},
 		{"empty string no match", backlog.HeaderFocus, "", false},
 	}
 
@@ -2,7 +2,10 @@ package backlog
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 
 	logseq "github.com/andreoliwa/logseq-go"
 	"github.com/andreoliwa/logseq-go/content"
@@ -10,6 +13,8 @@ import (
 
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
 )
 
 type directiveKind int
@@ -19,67 +24,170 @@ const (
 	directiveWaiting
 	directiveTodo
 	directivePriority
+	directiveDone
+	directiveDoing
+	directiveNow
+	directiveLater
+	directiveScheduled
+	directiveDeadline
+	directiveMove
+	directiveAddTag
+	directiveRemoveTag
 )
 
+// removesRef tells if the block ref leaves the backlog page once the directive is applied:
+// a canceled or done task is not in the backlog anymore, and a moved one is in another backlog.
+func (k directiveKind) removesRef() bool {
+	return k == directiveCancel || k == directiveDone || k == directiveMove
+}
+
 // blockDirective records a pending task modification detected on a backlog page.
 type blockDirective struct {
 	UUID          string
 	Kind          directiveKind
 	Priority      content.PriorityValue // only for directivePriority; PriorityNone for other kinds
-	DirectiveNode content.Node          // the TaskMarker or Priority node to remove after apply
+	Date          time.Time             // only for directiveScheduled and directiveDeadline
+	Tag           string                // only for directiveAddTag and directiveRemoveTag
+	DirectiveNode content.Node          // the TaskMarker, Priority, Hashtag, PageLink or Text node to remove after apply
+	DirectiveText string                // for a Text node: the date to remove from it, keeping the rest
+	MarkerText    *content.Text         // the text to remove with the node: >> before a move link, - before a tag
 	BacklogBlock  *content.Block        // the block on the backlog page containing the BlockRef
+	Repeated      bool                  // only for directiveDone: the task repeats, it stays open with its next dates
+
+	// Only for directiveMove: the backlog named by the directive, and the backlogs the task leaves
+	// (nil if the page is not a backlog) and goes to, see resolveMoveTargets.
+	MoveTo string
+	Source *SingleBacklogConfig
+	Target *SingleBacklogConfig
 }
 
-// detectDirectives inspects all preceding siblings of the BlockRef in its parent Paragraph.
-// It walks backwards from the BlockRef collecting TaskMarker and Priority nodes until
-// it reaches a non-directive node. Returns all found directives (may be empty).
+// markerDirectives maps the task markers typed in front of a block ref to their directive.
 //
-//nolint:cyclop // complexity comes from the inherent number of directive kinds, not poor structure
+//nolint:gochecknoglobals // lookup table
+var markerDirectives = map[content.TaskStatus]directiveKind{
+	content.TaskStatusCanceled:  directiveCancel,
+	content.TaskStatusCancelled: directiveCancel,
+	content.TaskStatusWaiting:   directiveWaiting,
+	content.TaskStatusWait:      directiveWaiting,
+	content.TaskStatusTodo:      directiveTodo,
+	content.TaskStatusDone:      directiveDone,
+	content.TaskStatusDoing:     directiveDoing,
+	content.TaskStatusNow:       directiveNow,
+	content.TaskStatusLater:     directiveLater,
+}
+
+// detectDirectives returns the directives typed around a block ref (may be empty).
+// Task markers and priorities come before the BlockRef, in its parent Paragraph: it walks backwards
+// from the BlockRef collecting them until it reaches a non-directive node.
+// SCHEDULED and DEADLINE dates come after it, on the same line or on the next lines of the block.
 func detectDirectives(blockRef *content.BlockRef) []blockDirective {
 	// blockRef lives inside a Paragraph inside a Block.
 	var backlogBlock *content.Block
 
-	if para, ok := blockRef.Parent().(*content.Paragraph); ok {
+	para, _ := blockRef.Parent().(*content.Paragraph)
+	if para != nil {
 		if block, ok := para.Parent().(*content.Block); ok {
 			backlogBlock = block
 		}
 	}
 
+	found := prefixDirectives(blockRef, backlogBlock)
+	if hasWordsAfter(blockRef) {
+		found = withoutTagDirectives(found)
+	}
+
+	for next := blockRef.NextSibling(); next != nil; next = next.NextSibling() {
+		found = append(found, dateDirectives(blockRef.ID, next, backlogBlock)...)
+	}
+
+	// Dates typed on the next lines of the block (Shift+Enter in Logseq) are paragraphs of their own.
+	if para != nil && backlogBlock != nil {
+		for next := para.NextSibling(); next != nil; next = next.NextSibling() {
+			line, ok := next.(*content.Paragraph)
+			if !ok {
+				continue
+			}
+
+			for _, child := range line.Children() {
+				found = append(found, dateDirectives(blockRef.ID, child, backlogBlock)...)
+			}
+		}
+	}
+
+	return found
+}
+
+// prefixDirectives collects the task markers, priorities, moves and tags typed in front of a block ref.
+// A hashtag is only a directive where a task marker goes, at the start of the line: after other words,
+// it is an ordinary tag of the text.
+//
+//nolint:cyclop // complexity comes from the inherent number of directive kinds, not poor structure
+func prefixDirectives(blockRef *content.BlockRef, backlogBlock *content.Block) []blockDirective {
 	var found []blockDirective
 
 	for prev := blockRef.PreviousSibling(); prev != nil; prev = prev.PreviousSibling() {
+		if target, marker, ok := moveDirective(prev); ok {
+			found = append(found, blockDirective{ //nolint:exhaustruct // resolved later by resolveMoveTargets
+				UUID:          blockRef.ID,
+				Kind:          directiveMove,
+				Priority:      content.PriorityNone,
+				DirectiveNode: prev,
+				BacklogBlock:  backlogBlock,
+				MoveTo:        target,
+				MarkerText:    marker,
+			})
+
+			if marker != nil {
+				prev = marker
+			}
+
+			continue
+		}
+
+		if tag, marker, ok := tagDirective(prev); ok {
+			kind := directiveAddTag
+			if marker != nil {
+				kind = directiveRemoveTag
+			}
+
+			found = append(found, blockDirective{ //nolint:exhaustruct // no priority nor date
+				UUID:          blockRef.ID,
+				Kind:          kind,
+				Priority:      content.PriorityNone,
+				Tag:           tag,
+				DirectiveNode: prev,
+				MarkerText:    marker,
+				BacklogBlock:  backlogBlock,
+			})
+
+			if marker != nil {
+				prev = marker
+			}
+
+			continue
+		}
+
 		switch node := prev.(type) {
+		case *content.Text:
+			// Spaces between directives.
+			if strings.TrimSpace(node.Value) != "" {
+				return withoutTagDirectives(found)
+			}
 		case *content.TaskMarker:
-			switch node.Status { //nolint:exhaustive // only CANCELED/WAITING/TODO are valid directives; others are ignored
-			case content.TaskStatusCanceled, content.TaskStatusCancelled:
-				found = append(found, blockDirective{
-					UUID:          blockRef.ID,
-					Kind:          directiveCancel,
-					Priority:      content.PriorityNone,
-					DirectiveNode: node,
-					BacklogBlock:  backlogBlock,
-				})
-			case content.TaskStatusWaiting, content.TaskStatusWait:
-				found = append(found, blockDirective{
-					UUID:          blockRef.ID,
-					Kind:          directiveWaiting,
-					Priority:      content.PriorityNone,
-					DirectiveNode: node,
-					BacklogBlock:  backlogBlock,
-				})
-			case content.TaskStatusTodo:
-				found = append(found, blockDirective{
-					UUID:          blockRef.ID,
-					Kind:          directiveTodo,
-					Priority:      content.PriorityNone,
-					DirectiveNode: node,
-					BacklogBlock:  backlogBlock,
-				})
-			default:
-				return found
+			kind, ok := markerDirectives[node.Status]
+			if !ok {
+				return withoutTagDirectives(found)
 			}
+
+			found = append(found, blockDirective{ //nolint:exhaustruct // no priority nor date
+				UUID:          blockRef.ID,
+				Kind:          kind,
+				Priority:      content.PriorityNone,
+				DirectiveNode: node,
+				BacklogBlock:  backlogBlock,
+			})
 		case *content.Priority:
-			found = append(found, blockDirective{
+			found = append(found, blockDirective{ //nolint:exhaustruct // no date
 				UUID:          blockRef.ID,
 				Kind:          directivePriority,
 				Priority:      node.Priority,
@@ -87,13 +195,117 @@ func detectDirectives(blockRef *content.BlockRef) []blockDirective {
 				BacklogBlock:  backlogBlock,
 			})
 		default:
-			return found
+			return withoutTagDirectives(found)
+		}
+	}
+
+	return found
+}
+
+// withoutTagDirectives leaves out the hashtags found around a block ref that turned out to be part of the text.
+func withoutTagDirectives(found []blockDirective) []blockDirective {
+	return slices.DeleteFunc(found, func(directive blockDirective) bool {
+		return directive.Kind == directiveAddTag || directive.Kind == directiveRemoveTag
+	})
+}
+
+// hasWordsAfter tells if the line of a block ref goes on with words, links or tags; dates and emojis
+// such as the 📌 of a pinned task don't count.
+func hasWordsAfter(blockRef *content.BlockRef) bool {
+	for next := blockRef.NextSibling(); next != nil; next = next.NextSibling() {
+		switch node := next.(type) {
+		case *content.Text:
+			value := node.Value
+			for _, taskDate := range logseqext.FindTaskDates(value) {
+				value = strings.Replace(value, taskDate.Text, "", 1)
+			}
+
+			if strings.IndexFunc(value, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				return true
+			}
+		case *content.Hashtag, *content.PageLink, *content.Link, *content.BlockRef:
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagDirective tells if a node in front of a block ref is a hashtag to add to the task,
+// or to remove from it when written -#tag: marker is then the text holding the - before it.
+func tagDirective(node content.Node) (string, *content.Text, bool) {
+	hashtag, ok := node.(*content.Hashtag)
+	if !ok {
+		return "", nil, false
+	}
+
+	if marker, ok := hashtag.PreviousSibling().(*content.Text); ok && strings.TrimSpace(marker.Value) == "-" {
+		return hashtag.To, marker, true
+	}
+
+	return hashtag.To, nil, true
+}
+
+// dateDirectives returns the SCHEDULED and DEADLINE dates of a text node next to a block ref.
+func dateDirectives(uuid string, node content.Node, backlogBlock *content.Block) []blockDirective {
+	text, ok := node.(*content.Text)
+	if !ok {
+		return nil
+	}
+
+	var found []blockDirective
+
+	for _, taskDate := range logseqext.FindTaskDates(text.Value) {
+		kind := directiveScheduled
+		if taskDate.Keyword == logseqext.TaskDateDeadline {
+			kind = directiveDeadline
 		}
+
+		found = append(found, blockDirective{
+			UUID:          uuid,
+			Kind:          kind,
+			Priority:      content.PriorityNone,
+			Date:          taskDate.Date,
+			DirectiveNode: text,
+			DirectiveText: taskDate.Text,
+			BacklogBlock:  backlogBlock,
+		})
 	}
 
 	return found
 }
 
+// strip removes the directive from the backlog page. A date is cut out of its text node,
+// and a line left empty is removed.
+func (d *blockDirective) strip() {
+	text, ok := d.DirectiveNode.(*content.Text)
+	if !ok || d.DirectiveText == "" {
+		if space, ok := d.DirectiveNode.NextSibling().(*content.Text); ok && strings.TrimSpace(space.Value) == "" {
+			space.RemoveSelf()
+		}
+
+		if d.MarkerText != nil {
+			d.MarkerText.RemoveSelf()
+		}
+
+		d.DirectiveNode.RemoveSelf()
+
+		return
+	}
+
+	text.Value = strings.TrimRight(strings.Replace(text.Value, d.DirectiveText, "", 1), " ")
+	if strings.TrimSpace(text.Value) != "" {
+		return
+	}
+
+	line := text.Parent()
+	text.RemoveSelf()
+
+	if line != nil && line.FirstChild() == nil {
+		line.RemoveSelf()
+	}
+}
+
 // applyDirectives processes all collected directives: modifies the real task block on disk,
 // then strips the directive node from the backlog page.
 //
@@ -103,31 +315,33 @@ func detectDirectives(blockRef *content.BlockRef) []blockDirective {
 // If a block is not on disk and the Logseq API is available, it forces a UUID write-back.
 // If the API is unavailable, it warns and skips.
 // Returns true if any directive was successfully applied (meaning the backlog page AST was mutated
-// and the caller must save the backlog transaction).
+// and the caller must save the backlog transaction), the block refs moved to other backlogs,
+// and the block refs of the tasks set to DONE (repeating tasks stay open).
 func applyDirectives(
 	graph *logseq.Graph,
 	logseqAPI logseqapi.LogseqAPI,
 	directives []blockDirective,
 	currentTime func() time.Time,
-) bool {
+) (bool, map[string]*set.Set[string], *set.Set[string]) {
 	groups := groupDirectivesByUUID(directives)
+	appliedGroups := make([]bool, len(groups))
 	applied := false
 
 	for gi := range groups {
 		if applyDirectiveGroupAndCleanup(graph, logseqAPI, &groups[gi], currentTime) {
+			appliedGroups[gi] = true
 			applied = true
 		}
 	}
 
-	return applied
+	return applied, movedRefs(groups, appliedGroups), doneRefs(groups, appliedGroups)
 }
 
 // directiveGroup holds all directives targeting the same task UUID.
 type directiveGroup struct {
-	items     []*blockDirective
-	uuid      string
-	backlog   *content.Block
-	hasCancel bool
+	items   []*blockDirective
+	uuid    string
+	backlog *content.Block
 }
 
 // groupDirectivesByUUID groups a flat slice of directives into per-UUID groups,
@@ -141,17 +355,12 @@ func groupDirectivesByUUID(directives []blockDirective) []directiveGroup {
 
 		if idx, ok := seenUUID[directive.UUID]; ok {
 			groups[idx].items = append(groups[idx].items, directive)
-
-			if directive.Kind == directiveCancel {
-				groups[idx].hasCancel = true
-			}
 		} else {
 			seenUUID[directive.UUID] = len(groups)
 			groups = append(groups, directiveGroup{
-				items:     []*blockDirective{directive},
-				uuid:      directive.UUID,
-				backlog:   directive.BacklogBlock,
-				hasCancel: directive.Kind == directiveCancel,
+				items:   []*blockDirective{directive},
+				uuid:    directive.UUID,
+				backlog: directive.BacklogBlock,
 			})
 		}
 	}
@@ -182,11 +391,11 @@ func applyDirectiveGroupAndCleanup(
 	}
 
 	for _, item := range grp.items {
-		item.DirectiveNode.RemoveSelf()
+		item.strip()
 	}
 
 	if grp.backlog != nil {
-		if grp.hasCancel {
+		if grp.leavesPage() {
 			grp.backlog.RemoveSelf()
 		} else {
 			props := logseqext.BlockProperties(grp.backlog)
@@ -201,6 +410,18 @@ func applyDirectiveGroupAndCleanup(
 	return true
 }
 
+// leavesPage tells if the block ref of an applied group leaves the backlog page: a repeating task
+// completed with DONE stays, with its next dates.
+func (g *directiveGroup) leavesPage() bool {
+	for _, item := range g.items {
+		if item.Kind.removesRef() && !item.Repeated {
+			return true
+		}
+	}
+
+	return false
+}
+
 func applyDirectiveGroup(
 	graph *logseq.Graph,
 	logseqAPI logseqapi.LogseqAPI,
@@ -211,6 +432,18 @@ func applyDirectiveGroup(
 		return nil
 	}
 
+	return persist.RetryOnConflict(func() error { //nolint:wrapcheck
+		return applyDirectivesToTask(graph, logseqAPI, items, currentTime)
+	})
+}
+
+// applyDirectivesToTask opens the page of a task and applies its directives.
+func applyDirectivesToTask(
+	graph *logseq.Graph,
+	logseqAPI logseqapi.LogseqAPI,
+	items []*blockDirective,
+	currentTime func() time.Time,
+) error {
 	block, transaction, err := logseqapi.FindBlockOnDisk(graph, logseqAPI, items[0].UUID)
 	if err != nil {
 		return fmt.Errorf("finding block on disk: %w", err)
@@ -260,6 +493,38 @@ func applyDirectiveToBlock(
 		if prioErr != nil {
 			return fmt.Errorf("failed to set priority: %w", prioErr)
 		}
+
+	case directiveDone:
+		repeated, doneErr := logseqext.CompleteTask(block, currentTime())
+		if doneErr != nil {
+			return fmt.Errorf("failed to complete task: %w", doneErr)
+		}
+
+		directive.Repeated = repeated
+
+	case directiveDoing, directiveNow, directiveLater:
+		statusErr := logseqext.SetTaskStatus(block, directiveStatus(directive.Kind))
+		if statusErr != nil {
+			return fmt.Errorf("failed to set task status: %w", statusErr)
+		}
+
+	case directiveScheduled, directiveDeadline:
+		dateErr := logseqext.SetTaskDate(block, kindName(directive.Kind), directive.Date)
+		if dateErr != nil {
+			return fmt.Errorf("failed to set task date: %w", dateErr)
+		}
+
+	case directiveMove:
+		return moveTask(block, directive)
+
+	case directiveAddTag:
+		tagErr := logseqext.AddTag(block, directive.Tag)
+		if tagErr != nil {
+			return fmt.Errorf("failed to add tag: %w", tagErr)
+		}
+
+	case directiveRemoveTag:
+		logseqext.RemoveTag(block, directive.Tag)
 	}
 
 	return nil
@@ -275,7 +540,35 @@ func kindName(kind directiveKind) string {
 		return content.TaskStringTodo
 	case directivePriority:
 		return "priority"
+	case directiveDone, directiveDoing, directiveNow, directiveLater:
+		return directiveStatus(kind).String()
+	case directiveScheduled:
+		return logseqext.TaskDateScheduled
+	case directiveDeadline:
+		return logseqext.TaskDateDeadline
+	case directiveMove:
+		return "move"
+	case directiveAddTag:
+		return "#tag"
+	case directiveRemoveTag:
+		return "-#tag"
 	}
 
 	return "unknown"
 }
+
+// directiveStatus returns the task status set by a status directive.
+func directiveStatus(kind directiveKind) content.TaskStatus {
+	switch kind { //nolint:exhaustive // only the kinds handled by SetTaskStatus
+	case directiveDone:
+		return content.TaskStatusDone
+	case directiveDoing:
+		return content.TaskStatusDoing
+	case directiveNow:
+		return content.TaskStatusNow
+	case directiveLater:
+		return content.TaskStatusLater
+	}
+
+	return content.TaskStatusNone
+}
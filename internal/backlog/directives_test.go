@@ -25,6 +25,19 @@ func directivesFixture(t *testing.T) *testutils.TaskFixture {
 		testutils.Task("task-plain", todo, "Leave this task unchanged", testutils.WithTags("home")),
 		testutils.Task("task-waiting-to-todo", waiting, "Change waiting task to todo", testutils.WithTags("home")),
 		testutils.Task("task-multi-directive", todo, "Set waiting and priority B", testutils.WithTags("home")),
+		testutils.Task("task-done", todo, "Complete this task", testutils.WithTags("home")),
+		testutils.Task("task-doing", todo, "Start doing this task", testutils.WithTags("home")),
+		testutils.Task("task-now", todo, "Start this task now", testutils.WithTags("home")),
+		testutils.Task("task-later", todo, "Leave this task for later", testutils.WithTags("home")),
+		testutils.Task("task-scheduled", todo, "Schedule this task", testutils.WithTags("home")),
+		testutils.Task("task-deadline", todo, "Set a deadline on this task", testutils.WithTags("home")),
+		testutils.Task("task-reschedule", todo, "Reschedule this task", testutils.WithTags("home")),
+		testutils.Task("task-start-and-schedule", todo, "Start, prioritise and schedule this task",
+			testutils.WithTags("home")),
+		testutils.Task("task-add-tag", todo, "Add a tag to this task", testutils.WithTags("home")),
+		testutils.Task("task-remove-tag", todo, "Remove a tag from this task", testutils.WithTags("home")),
+		testutils.Task("task-tag-in-text", todo, "Keep the tags of this task", testutils.WithTags("home")),
+		testutils.Task("task-tag-before-text", todo, "Keep the tags of this other task", testutils.WithTags("home")),
 	)
 }
 
@@ -34,14 +47,26 @@ func TestDirectives_StripsDirectivesAndTransformsTasks(t *testing.T) {
 	back := fixture.FakeBacklogWithUUIDPages(
 		t, "bk", "directives",
 		map[string]string{
-			"task-cancel":          "home",
-			"task-waiting":         "home",
-			"task-priority-a":      "home",
-			"task-priority-b":      "home",
-			"task-priority-c":      "home",
-			"task-a-to-b":          "home",
-			"task-waiting-to-todo": "home",
-			"task-multi-directive": "home",
+			"task-cancel":             "home",
+			"task-waiting":            "home",
+			"task-priority-a":         "home",
+			"task-priority-b":         "home",
+			"task-priority-c":         "home",
+			"task-a-to-b":             "home",
+			"task-waiting-to-todo":    "home",
+			"task-multi-directive":    "home",
+			"task-done":               "home",
+			"task-doing":              "home",
+			"task-now":                "home",
+			"task-later":              "home",
+			"task-scheduled":          "home",
+			"task-deadline":           "home",
+			"task-reschedule":         "home",
+			"task-start-and-schedule": "home",
+			"task-add-tag":            "home",
+			"task-remove-tag":         "home",
+			"task-tag-in-text":        "home",
+			"task-tag-before-text":    "home",
 		},
 	)
 
@@ -49,6 +74,7 @@ func TestDirectives_StripsDirectivesAndTransformsTasks(t *testing.T) {
 	require.NoError(t, err)
 
 	// Backlog page: all directive prefixes stripped, bare block refs remain.
+	// The hashtags written in a sentence around a ref are not directives: the lines and the tasks are left alone.
 	fixture.AssertGoldenPages(t, back.Graph(), "directives", []string{"bk___home"})
 
 	// Task source page: each task transformed according to its directive.
@@ -64,3 +90,34 @@ func TestDirectives_NilAPI_WarnsAndSkips(t *testing.T) {
 	err := back.ProcessAll([]string{})
 	require.NoError(t, err)
 }
+
+func TestDirectives_MoveToAnotherBacklog(t *testing.T) {
+	todo := content.TaskStringTodo
+
+	fixture := testutils.NewFixture(t,
+		testutils.Task("home-task", todo, "Already in the home backlog", testutils.WithTags("home")),
+		testutils.Task("phone-task", todo, "Already in the phone backlog", testutils.WithTags("phone")),
+		testutils.Task("task-move-tag", todo, "Move me with a tag", testutils.WithTags("work")),
+		testutils.Task("task-move-link", todo, "Move me with a link", testutils.WithTags("work")),
+		testutils.Task("task-stays", todo, "Stay in the work backlog", testutils.WithTags("work")),
+	)
+
+	back := fixture.FakeBacklogWithUUIDPages(
+		t, "bk", "move",
+		map[string]string{
+			"task-move-tag":  "tasks",
+			"task-move-link": "tasks",
+			"task-stays":     "tasks",
+		},
+	)
+
+	err := back.ProcessAll([]string{})
+	require.NoError(t, err)
+
+	// The work backlog is processed last: the moved refs reach the New section of backlogs processed before it.
+	// The move to an unknown backlog is left on the page.
+	fixture.AssertGoldenPages(t, back.Graph(), "move", []string{"bk___home", "bk___phone", "bk___work"})
+
+	// The source tasks lose the work tag and get the tag of their new backlog.
+	fixture.AssertGoldenPages(t, back.Graph(), "move", []string{"tasks"})
+}
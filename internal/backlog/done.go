@@ -0,0 +1,120 @@
+package backlog
+
+import (
+	"fmt"
+	"time"
+
+	logseq "github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// PropertyCompleted is the property of a ref in the Done section, with the day lqd found its task DONE.
+const PropertyCompleted = "completed"
+
+// ArchivePageSuffix is added to the title of a backlog page to name the page its old Done entries go to.
+const ArchivePageSuffix = "/archive"
+
+// isTaskDone asks Logseq if the task of an obsolete ref is DONE, rather than canceled or no longer tagged.
+// A task Logseq cannot find is not DONE.
+func isTaskDone(logseqAPI logseqapi.LogseqAPI, uuid string) bool {
+	info, err := logseqapi.FindBlockByUUID(logseqAPI, uuid)
+
+	return err == nil && info.Marker == content.TaskStringDone
+}
+
+// doneRefs returns the block refs completed by the applied DONE directives, leaving out the repeating tasks.
+func doneRefs(groups []directiveGroup, applied []bool) *set.Set[string] {
+	done := set.NewSet[string]()
+
+	for gi, grp := range groups {
+		if !applied[gi] {
+			continue
+		}
+
+		for _, item := range grp.items {
+			if item.Kind == directiveDone && !item.Repeated {
+				done.Add(item.UUID)
+			}
+		}
+	}
+
+	return done
+}
+
+// handleDoneRef keeps a ref under the Done divider while its task is DONE. A reopened task leaves the section
+// and goes back to New tasks, unless it is elsewhere on the page.
+func handleDoneRef(blockRef *content.BlockRef, state *pageState, obsoleteBlockRefs *set.Set[string]) {
+	if obsoleteBlockRefs.Contains(blockRef.ID) {
+		return
+	}
+
+	blockRef.Parent().Parent().RemoveSelf()
+	state.reopenedRefs.Add(blockRef.ID)
+}
+
+// insertDoneTasks adds the refs of the tasks completed since the last run to the Done section
+// (creating it if needed), each one with the day it was found DONE.
+func insertDoneTasks(page logseq.Page, state *pageState, completed time.Time) {
+	for _, uuid := range state.completedRefs.ValuesSorted() {
+		if state.dividerDone != nil && BlockRefExistsUnder(state.dividerDone, uuid) {
+			continue
+		}
+
+		if state.dividerDone == nil {
+			state.dividerDone = content.NewBlock(state.headers.Get(SectionNameDone).NewHeading())
+			if !placeSection(page, state, SectionNameDone, state.dividerDone) {
+				page.AddBlock(state.dividerDone)
+			}
+		}
+
+		entry := content.NewBlock(content.NewParagraph(content.NewBlockRef(uuid)), content.NewProperties())
+		logseqext.BlockProperties(entry).Set(PropertyCompleted, content.NewText(logseqext.FormatLogseqDate(completed)))
+		state.dividerDone.AddChild(entry)
+
+		state.doneCount++
+	}
+}
+
+// archiveDoneTasks moves the Done entries completed more than keepDays ago to the archive page of the backlog,
+// at its end. Entries without a completed date stay until removed by hand.
+func archiveDoneTasks(
+	transaction *persist.Transaction, pageTitle string, state *pageState, keepDays int, today time.Time,
+) error {
+	if state.dividerDone == nil {
+		return nil
+	}
+
+	cutoff := logseqext.DateYYYYMMDD(today.AddDate(0, 0, -keepDays))
+
+	var old []*content.Block
+
+	for _, entry := range state.dividerDone.Blocks() {
+		completed := logseqext.BlockPropertyDate(entry, PropertyCompleted)
+		if !completed.IsZero() && logseqext.DateYYYYMMDD(completed) < cutoff {
+			old = append(old, entry)
+		}
+	}
+
+	if len(old) == 0 {
+		return nil
+	}
+
+	archive, err := transaction.OpenPage(pageTitle + ArchivePageSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to open the archive page: %w", err)
+	}
+
+	for _, entry := range old {
+		entry.RemoveSelf()
+		archive.AddBlock(entry)
+	}
+
+	state.archivedCount += len(old)
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package backlog
+
+import (
+	logseq "github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+// insertDueSoonTasks moves the tasks with a deadline in the next days, found elsewhere on the page or new,
+// under the Due soon divider (creating it if needed, after the Overdue or Focus section, or before New tasks).
+func insertDueSoonTasks(page logseq.Page, state *pageState) {
+	before := state.firstBlock
+	if state.dividerNewTasks != nil {
+		before = state.dividerNewTasks
+	}
+
+	for _, blockRef := range state.newlyDueSoonRefs.ValuesSorted() {
+		if state.dividerDueSoon == nil {
+			state.dividerDueSoon = content.NewBlock(state.headers.Get(SectionNameDueSoon).NewHeading())
+			if !placeSection(page, state, SectionNameDueSoon, state.dividerDueSoon) {
+				logseqext.AddSibling(page, state.dividerDueSoon, before, state.dividerOverdue, state.dividerFocus)
+			}
+		}
+
+		if !BlockRefExistsUnder(state.dividerDueSoon, blockRef) {
+			state.dividerDueSoon.AddChild(content.NewBlock(content.NewBlockRef(blockRef)))
+		}
+	}
+
+	state.result.DueSoonRefs.Update(state.dueSoonRefs)
+}
@@ -0,0 +1,448 @@
+package backlog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	logseq "github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/fatih/color"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// AutoFocusMarker follows the ref of a task put in the Focus section of a backlog page by a FocusPolicy.
+// The next runs take these refs out again when the policy no longer chooses them; refs without it are left alone.
+const AutoFocusMarker = "🤖"
+
+// FocusPolicy chooses the tasks that --fill-focus puts in the Focus sections of the backlog pages,
+// and so on the Focus page.
+type FocusPolicy struct {
+	// PerBacklog is how many focus tasks a backlog can have, those put there by hand included.
+	// The free places go to the top tasks of its ranked area.
+	PerBacklog int
+	// Limit is how many tasks the Focus page can hold (its WIP limit), zero for no limit.
+	// The tasks put in focus by hand are counted first.
+	Limit int
+	// OverduePriorityA adds the overdue tasks with priority A of every backlog, beyond PerBacklog but not Limit.
+	OverduePriorityA bool
+}
+
+// focusPolicy is the policy of the current run, see SetFocusPolicy.
+//
+//nolint:gochecknoglobals // set once per run from the --fill-focus flags or the lqd config file
+var focusPolicy atomic.Pointer[FocusPolicy]
+
+// SetFocusPolicy turns on --fill-focus for the next runs of ProcessAll; nil turns it off.
+func SetFocusPolicy(policy *FocusPolicy) {
+	focusPolicy.Store(policy)
+}
+
+// FillFocusPolicy returns the policy set with SetFocusPolicy, or nil when --fill-focus is off.
+func FillFocusPolicy() *FocusPolicy {
+	return focusPolicy.Load()
+}
+
+// String describes the policy for the output of the command.
+func (p FocusPolicy) String() string {
+	text := FormatCount(p.PerBacklog, "task", "tasks") + " per backlog"
+	if p.OverduePriorityA {
+		text += ", overdue tasks with priority A"
+	}
+
+	if p.Limit > 0 {
+		text += fmt.Sprintf(", %d at most", p.Limit)
+	}
+
+	return text
+}
+
+// focusBacklog is a processed backlog whose focus tasks go to the Focus page.
+type focusBacklog struct {
+	page   string
+	result *Result
+	tasks  *logseqapi.CategorizedTasks
+}
+
+// focusCandidates are the tasks of a backlog that a FocusPolicy chooses from.
+type focusCandidates struct {
+	page   string
+	manual *set.Set[string] // refs put in the Focus section by hand
+	auto   []string         // refs put in the Focus section by the policy, in page order
+	ranked []string         // refs of the ranked area, in page order
+	urgent []string         // refs of the overdue tasks with priority A
+}
+
+func newFocusCandidates(focus focusBacklog, policy FocusPolicy) focusCandidates {
+	auto := set.NewSet[string]()
+	for _, uuid := range focus.result.AutoFocusRefs {
+		auto.Add(uuid)
+	}
+
+	candidates := focusCandidates{
+		page:   focus.page,
+		manual: focus.result.FocusRefsFromPage.Diff(auto),
+		auto:   focus.result.AutoFocusRefs,
+		ranked: focus.result.RankedRefs,
+		urgent: nil,
+	}
+
+	if !policy.OverduePriorityA || focus.tasks == nil {
+		return candidates
+	}
+
+	for _, uuid := range focus.tasks.Overdue.ValuesSorted() {
+		task, ok := focus.tasks.TaskLookup[uuid]
+		if ok && !focus.tasks.Blocked.Contains(uuid) &&
+			logseqext.ParsePriorityFromContent(task.Content) == content.PriorityHigh {
+			candidates.urgent = append(candidates.urgent, uuid)
+		}
+	}
+
+	return candidates
+}
+
+// choose returns the refs the policy puts in the Focus section of each backlog page, in the order they go there.
+// The overdue tasks with priority A come first, then the ranked tasks are taken one backlog at a time,
+// so the Limit doesn't leave the last backlogs without any. The refs the policy chose before come first
+// in their backlog: they stay in focus until they are done or the limits leave no room for them.
+func (p FocusPolicy) choose(backlogs []focusCandidates) map[string][]string {
+	chosen := make(map[string][]string, len(backlogs))
+	taken := set.NewSet[string]() // refs on the Focus page, whatever their backlog
+
+	for _, candidates := range backlogs {
+		taken.Update(candidates.manual)
+	}
+
+	free := -1 // no limit
+
+	if p.Limit > 0 {
+		free = max(p.Limit-taken.Size(), 0)
+	}
+
+	pick := func(page, uuid string) bool {
+		if free == 0 || taken.Contains(uuid) {
+			return false
+		}
+
+		taken.Add(uuid)
+		chosen[page] = append(chosen[page], uuid)
+
+		if free > 0 {
+			free--
+		}
+
+		return true
+	}
+
+	if p.OverduePriorityA {
+		for _, candidates := range backlogs {
+			for _, uuid := range candidates.urgent {
+				pick(candidates.page, uuid)
+			}
+		}
+	}
+
+	queues := make([][]string, len(backlogs))
+	counts := make([]int, len(backlogs))
+
+	for i, candidates := range backlogs {
+		queues[i] = append(slices.Clone(candidates.auto), candidates.ranked...)
+		counts[i] = candidates.manual.Size()
+	}
+
+	for picked := true; picked; {
+		picked = false
+
+		for i, candidates := range backlogs {
+			for counts[i] < p.PerBacklog && len(queues[i]) > 0 {
+				uuid := queues[i][0]
+				queues[i] = queues[i][1:]
+
+				if pick(candidates.page, uuid) {
+					counts[i]++
+					picked = true
+
+					break
+				}
+			}
+		}
+	}
+
+	return chosen
+}
+
+// fillFocus applies the focus policy to the processed backlogs going to the Focus page, and updates their focus
+// refs. The focus refs of the other ones, not processed by this run, count towards the Limit and are left alone.
+func (b *backlogImpl) fillFocus(policy FocusPolicy, backlogs []focusBacklog, others []focusCandidates) error {
+	if !Headers().Enabled(SectionNameFocus) {
+		color.Yellow("Skipping --fill-focus because the Focus section is disabled")
+
+		return nil
+	}
+
+	candidates := make([]focusCandidates, 0, len(backlogs)+len(others))
+	for _, focus := range backlogs {
+		candidates = append(candidates, newFocusCandidates(focus, policy))
+	}
+
+	candidates = append(candidates, others...)
+
+	chosen := policy.choose(candidates)
+
+	fmt.Printf("Filling the focus sections: %s\n", policy)
+
+	for i, focus := range backlogs {
+		added, removed, err := fillFocusSection(b.graph, focus.page, candidates[i].auto, chosen[focus.page])
+		if err != nil {
+			return err
+		}
+
+		for _, uuid := range removed {
+			focus.result.FocusRefsFromPage.Remove(uuid)
+		}
+
+		for _, uuid := range added {
+			focus.result.FocusRefsFromPage.Add(uuid)
+		}
+
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s:", b.pageTitle(focus.page))
+
+		if len(added) > 0 {
+			color.Cyan(" %s put in focus", FormatCount(len(added), "task was", "tasks were"))
+		}
+
+		if len(removed) > 0 {
+			color.Yellow(" %s taken out of focus", FormatCount(len(removed), "task was", "tasks were"))
+		}
+	}
+
+	return nil
+}
+
+// fillFocusSection puts the chosen refs above the Focus divider of a backlog page, followed by AutoFocusMarker,
+// and moves the refs the policy put there before and no longer chooses right below the divider, without it.
+// The blocks are moved with their children. It returns the refs put in focus and the refs taken out.
+func fillFocusSection(
+	graph *logseq.Graph, pageTitle string, auto, chosen []string,
+) ([]string, []string, error) {
+	var added, removed []string
+
+	for _, uuid := range chosen {
+		if !slices.Contains(auto, uuid) {
+			added = append(added, uuid)
+		}
+	}
+
+	for _, uuid := range auto {
+		if !slices.Contains(chosen, uuid) {
+			removed = append(removed, uuid)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+
+	transaction := persist.NewTransaction(graph)
+
+	page, err := transaction.OpenPage(pageTitle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open page for transaction: %w", err)
+	}
+
+	divider := FindSectionDivider(page, SectionNameFocus)
+	if divider == nil {
+		divider = content.NewBlock(Headers().Get(SectionNameFocus).NewHeading())
+
+		var first *content.Block
+		if blocks := page.Blocks(); len(blocks) > 0 {
+			first = blocks[0]
+		}
+
+		logseqext.AddSibling(page, divider, first)
+	}
+
+	// Backwards, so the refs keep their order below the divider.
+	for _, uuid := range slices.Backward(removed) {
+		block := findRefBlock(page, uuid)
+		if block == nil {
+			continue
+		}
+
+		removeAutoFocusMarker(block)
+		block.RemoveSelf()
+		page.InsertBlockAfter(block, divider)
+	}
+
+	var moved []string
+
+	var emptied []*content.Block
+
+	for _, uuid := range added {
+		block := findRefBlock(page, uuid)
+		if block == nil {
+			continue
+		}
+
+		if parent, ok := block.Parent().(*content.Block); ok && isSectionDivider(parent) {
+			emptied = append(emptied, parent)
+		}
+
+		addAutoFocusMarker(block, uuid)
+		block.RemoveSelf()
+		page.InsertBlockBefore(block, divider)
+
+		moved = append(moved, uuid)
+	}
+
+	// A section left without tasks is removed, like at the end of a run.
+	logseqext.RemoveEmptyBlocks(false, emptied...)
+
+	err = transaction.Save()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save transaction: %w", err)
+	}
+
+	return moved, removed, nil
+}
+
+// unprocessedFocusCandidates returns the backlogs going to the Focus page that were not processed, e.g. with
+// partial names or on a rerun of --watch: the refs in their Focus section take room on the Focus page too,
+// so they count like refs put there by hand.
+func (b *backlogImpl) unprocessedFocusCandidates(backlogs []SingleBacklogConfig,
+	processed []focusBacklog) []focusCandidates {
+	var others []focusCandidates
+
+	for _, backlogConfig := range backlogs {
+		if backlogConfig.ExcludeFromFocus || slices.ContainsFunc(processed, func(focus focusBacklog) bool {
+			return focus.page == backlogConfig.BacklogPage
+		}) {
+			continue
+		}
+
+		page, err := b.graph.OpenPage(backlogConfig.BacklogPage)
+		if err != nil || page == nil {
+			continue
+		}
+
+		others = append(others, focusCandidates{
+			page: backlogConfig.BacklogPage, manual: focusRefsOnPage(page), auto: nil, ranked: nil, urgent: nil,
+		})
+	}
+
+	return others
+}
+
+// focusRefsOnPage returns the top-level refs above the Focus divider of a backlog page; none without a divider.
+func focusRefsOnPage(page logseq.Page) *set.Set[string] {
+	refs := set.NewSet[string]()
+
+	divider := FindSectionDivider(page, SectionNameFocus)
+	if divider == nil {
+		return refs
+	}
+
+	for _, block := range page.Blocks() {
+		if block == divider {
+			break
+		}
+
+		if uuid := logseqext.ExtractBlockRefUUID(block); uuid != "" {
+			refs.Add(uuid)
+		}
+	}
+
+	return refs
+}
+
+// collectFocusRefs sets the refs of the result that a FocusPolicy chooses from: the refs it put above
+// the Focus divider before, and the top-level refs below it that are not in a section (the ranked area).
+func collectFocusRefs(page logseq.Page, state *pageState) {
+	aboveFocus := state.dividerFocus != nil
+
+	for _, block := range page.Blocks() {
+		if block == state.dividerFocus {
+			aboveFocus = false
+
+			continue
+		}
+
+		if _, isDivider := state.headers.Match(logseqext.BlockContentText(block)); isDivider {
+			continue
+		}
+
+		uuid := logseqext.ExtractBlockRefUUID(block)
+
+		switch {
+		case uuid == "":
+			continue
+		case !aboveFocus:
+			state.result.RankedRefs = append(state.result.RankedRefs, uuid)
+		case hasAutoFocusMarker(block):
+			state.result.AutoFocusRefs = append(state.result.AutoFocusRefs, uuid)
+		}
+	}
+}
+
+// findRefBlock returns the block holding the ref of a task, at any depth of the page, or nil.
+func findRefBlock(page logseq.Page, uuid string) *content.Block {
+	return page.Blocks().FindDeep(func(block *content.Block) bool {
+		return logseqext.ExtractBlockRefUUID(block) == uuid
+	})
+}
+
+// isSectionDivider tells if a block is the header of a section other than Focus.
+func isSectionDivider(block *content.Block) bool {
+	header, ok := Headers().Match(logseqext.BlockContentText(block))
+
+	return ok && header.Name != SectionNameFocus
+}
+
+// hasAutoFocusMarker tells if the content of a block has AutoFocusMarker.
+func hasAutoFocusMarker(block *content.Block) bool {
+	return block.Content().FindDeep(func(node content.Node) bool {
+		text, ok := node.(*content.Text)
+
+		return ok && strings.Contains(text.Value, AutoFocusMarker)
+	}) != nil
+}
+
+// addAutoFocusMarker adds AutoFocusMarker at the end of the line of the ref of a task, after a 📌 if any.
+func addAutoFocusMarker(block *content.Block, uuid string) {
+	ref := block.Content().FindDeep(func(node content.Node) bool {
+		blockRef, ok := node.(*content.BlockRef)
+
+		return ok && blockRef.ID == uuid
+	})
+	if ref != nil && !hasAutoFocusMarker(block) {
+		ref.Parent().AddChild(content.NewText(" " + AutoFocusMarker))
+	}
+}
+
+// removeAutoFocusMarker removes AutoFocusMarker from the content of a block.
+func removeAutoFocusMarker(block *content.Block) {
+	markers := block.Content().FilterDeep(func(node content.Node) bool {
+		text, ok := node.(*content.Text)
+
+		return ok && strings.Contains(text.Value, AutoFocusMarker)
+	})
+
+	for _, node := range markers {
+		text, _ := node.(*content.Text)
+		if strings.TrimSpace(text.Value) == AutoFocusMarker {
+			text.RemoveSelf()
+		} else {
+			text.Value = strings.ReplaceAll(text.Value, " "+AutoFocusMarker, "")
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package backlog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidHeader is returned for header settings that cannot be used.
+var ErrInvalidHeader = errors.New("invalid backlog header")
+
+// HeaderSettings overrides a default header, from a [backlog.headers.NAME] table of the lqd config file:
+//
+//	[backlog.headers.new]
+//	emoji = "🆕"
+//	label = "Neue"
+//	suffix = "Aufgaben"
+//
+//	[backlog.headers.overdue]
+//	enabled = false
+//
+//	[backlog.headers.done]
+//	enabled = true
+//	keep_days = 14
+//
+//	[backlog.headers.due_soon]
+//	lead_days = 3
+//
+// Unset fields keep their default. An empty suffix is allowed, e.g. for a label that says it all.
+// keep_days only applies to the Done section, lead_days to the Due soon section.
+type HeaderSettings struct {
+	Emoji    string  `toml:"emoji"`
+	Label    string  `toml:"label"`
+	Suffix   *string `toml:"suffix"`
+	Enabled  *bool   `toml:"enabled"`
+	KeepDays *int    `toml:"keep_days"`
+	LeadDays *int    `toml:"lead_days"`
+}
+
+// DefaultDoneKeepDays is how many days a completed task stays in the Done section before it is archived.
+const DefaultDoneKeepDays = 7
+
+// DefaultDueSoonLeadDays is how many days ahead of its deadline a task goes to the Due soon section.
+const DefaultDueSoonLeadDays = 7
+
+// HeaderRegistry holds the section headers of backlog pages and which of them are enabled.
+// A disabled header is neither created nor recognised: lqd backlog leaves overdue or future scheduled tasks
+// with the other tasks, groom doesn't move tasks to Triaged, and a Focus section is a regular block.
+// The New and Unranked sections are always enabled, tasks need somewhere to go.
+// The Done section is disabled unless the settings enable it.
+type HeaderRegistry struct {
+	headers  []Header
+	disabled map[string]bool
+	keepDays int
+	leadDays int
+}
+
+// DefaultHeaderRegistry returns the registry of the default headers, all enabled but Done.
+func DefaultHeaderRegistry() *HeaderRegistry {
+	return &HeaderRegistry{
+		headers: defaultHeaders, disabled: map[string]bool{SectionNameDone: true},
+		keepDays: DefaultDoneKeepDays, leadDays: DefaultDueSoonLeadDays,
+	}
+}
+
+// NewHeaderRegistry applies the settings of each section, by section name, to the default headers.
+func NewHeaderRegistry(settings map[string]HeaderSettings) (*HeaderRegistry, error) {
+	registry := &HeaderRegistry{
+		headers:  make([]Header, 0, len(defaultHeaders)),
+		disabled: map[string]bool{SectionNameDone: true},
+		keepDays: DefaultDoneKeepDays,
+		leadDays: DefaultDueSoonLeadDays,
+	}
+
+	for name, setting := range settings {
+		if _, ok := defaultHeader(name); !ok {
+			return nil, fmt.Errorf("%w: unknown section %q", ErrInvalidHeader, name)
+		}
+
+		err := sectionDays(&registry.keepDays, "keep_days", setting.KeepDays, name, SectionNameDone)
+		if err != nil {
+			return nil, err
+		}
+
+		err = sectionDays(&registry.leadDays, "lead_days", setting.LeadDays, name, SectionNameDueSoon)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, header := range defaultHeaders {
+		setting, ok := settings[header.Name]
+		if !ok {
+			registry.headers = append(registry.headers, header)
+
+			continue
+		}
+
+		if setting.Emoji != "" {
+			header.Emoji = setting.Emoji
+		}
+
+		if setting.Label != "" {
+			header.Label = setting.Label
+		}
+
+		if setting.Suffix != nil {
+			header.Suffix = *setting.Suffix
+		}
+
+		if setting.Enabled != nil && !*setting.Enabled {
+			if header.Name == SectionNameNew || header.Name == SectionNameUnranked {
+				return nil, fmt.Errorf("%w: the %s section cannot be disabled", ErrInvalidHeader, header.Name)
+			}
+
+			registry.disabled[header.Name] = true
+		}
+
+		if setting.Enabled != nil && *setting.Enabled {
+			delete(registry.disabled, header.Name)
+		}
+
+		registry.headers = append(registry.headers, header)
+	}
+
+	return registry, nil
+}
+
+// sectionDays validates a number of days that only one section accepts, and sets it when given.
+func sectionDays(days *int, key string, value *int, name, only string) error {
+	if value == nil {
+		return nil
+	}
+
+	if name != only {
+		return fmt.Errorf("%w: %s only applies to the %s section", ErrInvalidHeader, key, only)
+	}
+
+	if *value < 0 {
+		return fmt.Errorf("%w: %s cannot be negative", ErrInvalidHeader, key)
+	}
+
+	*days = *value
+
+	return nil
+}
+
+// Get returns the header of a section, enabled or not.
+func (r *HeaderRegistry) Get(name string) Header {
+	for _, header := range r.headers {
+		if header.Name == name {
+			return header
+		}
+	}
+
+	return Header{} //nolint:exhaustruct // unknown section
+}
+
+// Enabled tells if lqd creates and recognises the header of a section.
+func (r *HeaderRegistry) Enabled(name string) bool {
+	return !r.disabled[name]
+}
+
+// DoneKeepDays returns how many days a completed task stays in the Done section before it is archived.
+func (r *HeaderRegistry) DoneKeepDays() int {
+	return r.keepDays
+}
+
+// DueSoonLeadDays returns how many days ahead of its deadline a task goes to the Due soon section.
+func (r *HeaderRegistry) DueSoonLeadDays() int {
+	return r.leadDays
+}
+
+// EnabledHeaders returns the enabled headers, in their default order on a backlog page.
+func (r *HeaderRegistry) EnabledHeaders() []Header {
+	var enabled []Header
+
+	for _, header := range r.headers {
+		if r.Enabled(header.Name) {
+			enabled = append(enabled, header)
+		}
+	}
+
+	return enabled
+}
+
+// Match returns the enabled header that a block text is, see Header.Matches.
+func (r *HeaderRegistry) Match(blockText string) (Header, bool) {
+	for _, header := range r.EnabledHeaders() {
+		if header.Matches(blockText) {
+			return header, true
+		}
+	}
+
+	return Header{}, false //nolint:exhaustruct // no header
+}
+
+// Is tells if a block text is the header of a section, when that section is enabled.
+func (r *HeaderRegistry) Is(name, blockText string) bool {
+	return r.Enabled(name) && r.Get(name).Matches(blockText)
+}
+
+// headers is the registry of the current run.
+//
+//nolint:gochecknoglobals // set once per run from the lqd config file
+var headers = &headerState{registry: DefaultHeaderRegistry()}
+
+type headerState struct {
+	mu       sync.Mutex
+	registry *HeaderRegistry
+}
+
+// SetHeaders sets the header registry used by lqd backlog, sync, groom and the dashboard. A nil registry
+// restores the defaults.
+func SetHeaders(registry *HeaderRegistry) {
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+
+	if registry == nil {
+		registry = DefaultHeaderRegistry()
+	}
+
+	headers.registry = registry
+}
+
+// Headers returns the header registry of the current run.
+func Headers() *HeaderRegistry {
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+
+	return headers.registry
+}
+
+// defaultHeader returns the default header of a section.
+func defaultHeader(name string) (Header, bool) {
+	for _, header := range defaultHeaders {
+		if header.Name == name {
+			return header, true
+		}
+	}
+
+	return Header{}, false //nolint:exhaustruct // unknown section
+}
+
+// sameSection tells if two headers are of the same section, whatever their text.
+func (h Header) sameSection(other Header) bool {
+	return h.Name == other.Name
+}
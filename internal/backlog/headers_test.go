@@ -0,0 +1,134 @@
+package backlog_test
+
+import (
+	"testing"
+
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHeaderRegistry(t *testing.T) {
+	empty := ""
+	disabled := false
+
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameNew:     {Emoji: "🆕", Label: "Neue Aufgaben", Suffix: &empty},
+		backlog.SectionNameOverdue: {Enabled: &disabled},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "🆕 Neue Aufgaben", registry.Get(backlog.SectionNameNew).String())
+	assert.Equal(t, backlog.HeaderOverdue, registry.Get(backlog.SectionNameOverdue))
+	assert.Equal(t, backlog.HeaderFocus, registry.Get(backlog.SectionNameFocus))
+
+	assert.False(t, registry.Enabled(backlog.SectionNameOverdue))
+	assert.True(t, registry.Enabled(backlog.SectionNameNew))
+	assert.Len(t, registry.EnabledHeaders(), 7)
+
+	header, ok := registry.Match("🆕 Neue Aufgaben")
+	assert.True(t, ok)
+	assert.Equal(t, backlog.SectionNameNew, header.Name)
+
+	_, ok = registry.Match("✨ New tasks")
+	assert.False(t, ok, "the default label is replaced")
+
+	_, ok = registry.Match("📅 Overdue tasks")
+	assert.False(t, ok, "a disabled header is not recognised")
+	assert.False(t, registry.Is(backlog.SectionNameOverdue, "📅 Overdue tasks"))
+}
+
+func TestNewHeaderRegistry_Done(t *testing.T) {
+	assert.False(t, backlog.DefaultHeaderRegistry().Enabled(backlog.SectionNameDone), "disabled by default")
+	assert.Equal(t, backlog.DefaultDoneKeepDays, backlog.DefaultHeaderRegistry().DoneKeepDays())
+
+	enabled := true
+	keepDays := 14
+
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameDone: {Enabled: &enabled, KeepDays: &keepDays},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, registry.Enabled(backlog.SectionNameDone))
+	assert.Equal(t, 14, registry.DoneKeepDays())
+	assert.True(t, registry.Is(backlog.SectionNameDone, "✅ Done tasks"))
+}
+
+func TestNewHeaderRegistry_DueSoon(t *testing.T) {
+	assert.True(t, backlog.DefaultHeaderRegistry().Enabled(backlog.SectionNameDueSoon))
+	assert.Equal(t, backlog.DefaultDueSoonLeadDays, backlog.DefaultHeaderRegistry().DueSoonLeadDays())
+
+	leadDays := 3
+
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameDueSoon: {LeadDays: &leadDays},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, registry.DueSoonLeadDays())
+	assert.True(t, registry.Is(backlog.SectionNameDueSoon, "⚠️ Due soon"))
+	assert.False(t, registry.Is(backlog.SectionNameDueSoon, "Due soon: the car insurance"))
+}
+
+func TestNewHeaderRegistry_invalid(t *testing.T) {
+	disabled := false
+	keepDays := 3
+	negative := -1
+
+	tests := []struct {
+		name     string
+		settings map[string]backlog.HeaderSettings
+		message  string
+	}{
+		{"unknown section", map[string]backlog.HeaderSettings{"later": {}}, `unknown section "later"`},
+		{
+			"new disabled", map[string]backlog.HeaderSettings{backlog.SectionNameNew: {Enabled: &disabled}},
+			"the new section cannot be disabled",
+		},
+		{
+			"unranked disabled", map[string]backlog.HeaderSettings{backlog.SectionNameUnranked: {Enabled: &disabled}},
+			"the unranked section cannot be disabled",
+		},
+		{
+			"keep days on another section", map[string]backlog.HeaderSettings{backlog.SectionNameNew: {KeepDays: &keepDays}},
+			"keep_days only applies to the done section",
+		},
+		{
+			"negative keep days", map[string]backlog.HeaderSettings{backlog.SectionNameDone: {KeepDays: &negative}},
+			"keep_days cannot be negative",
+		},
+		{
+			"lead days on another section", map[string]backlog.HeaderSettings{backlog.SectionNameDone: {LeadDays: &keepDays}},
+			"lead_days only applies to the due_soon section",
+		},
+		{
+			"negative lead days", map[string]backlog.HeaderSettings{backlog.SectionNameDueSoon: {LeadDays: &negative}},
+			"lead_days cannot be negative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := backlog.NewHeaderRegistry(test.settings)
+			require.ErrorIs(t, err, backlog.ErrInvalidHeader)
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	t.Cleanup(func() { backlog.SetHeaders(nil) })
+
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameFocus: {Label: "Fokus"},
+	})
+	require.NoError(t, err)
+
+	backlog.SetHeaders(registry)
+	assert.Same(t, registry, backlog.Headers())
+	assert.Equal(t, "🎯 Fokus tasks", backlog.Headers().Get(backlog.SectionNameFocus).String())
+
+	backlog.SetHeaders(nil)
+	assert.Equal(t, backlog.HeaderFocus, backlog.Headers().Get(backlog.SectionNameFocus))
+}
@@ -0,0 +1,191 @@
+package backlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	logseq "github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/fatih/color"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// Properties that set the WIP limits of a backlog on the config page.
+const (
+	PropertyMaxDoing = "max-doing"
+	PropertyMaxFocus = "max-focus"
+	PropertyMaxOpen  = "max-open"
+)
+
+// LimitWarningPrefix starts the block that lqd backlog keeps at the top of a backlog page over its WIP limits.
+// The block is rewritten on each run, and removed once the backlog is back within its limits.
+const LimitWarningPrefix = "🚧 Over the WIP limits:"
+
+// Limits are the WIP limits of a backlog; zero means no limit.
+type Limits struct {
+	// Doing is how many DOING tasks the backlog can have.
+	Doing int `json:"doing,omitempty"`
+	// Focus is how many tasks its Focus section can hold.
+	Focus int `json:"focus,omitempty"`
+	// Open is how many tasks its page can hold, whatever their section (Focus, Overdue, New tasks, Due soon,
+	// Scheduled, Triaged, Blocked, Unranked, and the ranked area), the Done section left out.
+	Open int `json:"open,omitempty"`
+}
+
+// IsZero tells if the backlog has no limits.
+func (l Limits) IsZero() bool {
+	return l == Limits{} //nolint:exhaustruct // the zero value
+}
+
+// Load is what a backlog page holds, to compare with its Limits.
+type Load struct {
+	Doing int
+	Focus int
+	Open  int
+}
+
+// Breaches describes each limit the load is over, e.g. "4 DOING tasks (max 3)".
+func (l Limits) Breaches(load Load) []string {
+	var breaches []string
+
+	check := func(count, limit int, singular, plural string) {
+		if limit > 0 && count > limit {
+			breaches = append(breaches, fmt.Sprintf("%s (max %d)", FormatCount(count, singular, plural), limit))
+		}
+	}
+
+	check(load.Doing, l.Doing, "DOING task", "DOING tasks")
+	check(load.Focus, l.Focus, "focus task", "focus tasks")
+	check(load.Open, l.Open, "open task", "open tasks")
+
+	return breaches
+}
+
+// ReportBreaches prints the limits a backlog is over in red, on the line of the backlog.
+func ReportBreaches(breaches []string) {
+	if len(breaches) > 0 {
+		color.Red(" over the WIP limits: %s", strings.Join(breaches, ", "))
+	}
+}
+
+// PageLoad counts the tasks on a backlog page: the refs above the Focus divider are its focus tasks,
+// and every ref is an open task, blocked and scheduled ones included, except those under the Done section.
+// DOING tasks are not added to the page, so doing is counted from the tasks of the backlog, see DoingCount.
+func PageLoad(page logseq.Page, doing int) Load {
+	registry := Headers()
+	focus := set.NewSet[string]()
+	open := set.NewSet[string]()
+	aboveFocus := FindSectionDivider(page, SectionNameFocus) != nil
+
+	for _, block := range page.Blocks() {
+		header, isDivider := registry.Match(logseqext.BlockContentText(block))
+		if isDivider && header.Name == SectionNameFocus {
+			aboveFocus = false
+		}
+
+		if isDivider && header.Name == SectionNameDone {
+			continue
+		}
+
+		block.Children().FindDeep(func(node content.Node) bool {
+			if ref, ok := node.(*content.BlockRef); ok {
+				open.Add(ref.ID)
+
+				if aboveFocus {
+					focus.Add(ref.ID)
+				}
+			}
+
+			return false
+		})
+	}
+
+	return Load{Doing: doing, Focus: focus.Size(), Open: open.Size()}
+}
+
+// DoingCount counts the DOING tasks among the tasks queried for a backlog, whether they are on its page or not.
+func DoingCount(tasks map[logseqapi.TaskUUID]logseqapi.TaskJSON) int {
+	count := 0
+
+	for _, task := range tasks {
+		if logseqapi.TaskDoing(task) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// parseLimits reads the WIP limits of a backlog from the properties of its line on the config page.
+// A value that is not a positive number is ignored.
+func parseLimits(block *content.Block) Limits {
+	limit := func(property string) int {
+		value, err := strconv.Atoi(logseqext.BlockPropertyText(block, property))
+		if err != nil || value < 0 {
+			return 0
+		}
+
+		return value
+	}
+
+	return Limits{Doing: limit(PropertyMaxDoing), Focus: limit(PropertyMaxFocus), Open: limit(PropertyMaxOpen)}
+}
+
+// updateLimitWarning writes the breaches of the WIP limits in a block at the top of the page, or removes the block
+// when there are none. It returns true if the page changed.
+func updateLimitWarning(page logseq.Page, breaches []string) bool {
+	var warning *content.Block
+
+	for _, block := range page.Blocks() {
+		if strings.HasPrefix(logseqext.BlockContentText(block), LimitWarningPrefix) {
+			warning = block
+
+			break
+		}
+	}
+
+	if len(breaches) == 0 {
+		if warning == nil {
+			return false
+		}
+
+		warning.RemoveSelf()
+
+		return true
+	}
+
+	blocks := page.Blocks()
+	text := LimitWarningPrefix + " " + strings.Join(breaches, ", ")
+
+	// Sections and tasks may have been added above the warning during the run.
+	if warning != nil && warning == blocks[0] && logseqext.BlockContentText(warning) == text {
+		return false
+	}
+
+	if warning != nil {
+		warning.RemoveSelf()
+		blocks = page.Blocks()
+	}
+
+	var first *content.Block
+	if len(blocks) > 0 {
+		first = blocks[0]
+	}
+
+	logseqext.AddSibling(page, content.NewBlock(content.NewParagraph(content.NewText(text))), first)
+
+	return true
+}
+
+// limitBreaches returns the breaches of the WIP limits of a backlog page, after processing it.
+func limitBreaches(page logseq.Page, limits Limits, doing int) []string {
+	if limits.IsZero() {
+		return nil
+	}
+
+	return limits.Breaches(PageLoad(page, doing))
+}
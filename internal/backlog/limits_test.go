@@ -0,0 +1,36 @@
+package backlog_test
+
+import (
+	"testing"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_Breaches(t *testing.T) {
+	limits := backlog.Limits{Doing: 3, Focus: 1, Open: 0}
+
+	assert.Empty(t, limits.Breaches(backlog.Load{Doing: 3, Focus: 1, Open: 100}), "at the limits, and no open limit")
+	assert.Equal(t, []string{"4 DOING tasks (max 3)", "2 focus tasks (max 1)"},
+		limits.Breaches(backlog.Load{Doing: 4, Focus: 2, Open: 100}))
+	assert.Equal(t, []string{"51 open tasks (max 50)"},
+		backlog.Limits{Doing: 0, Focus: 0, Open: 50}.Breaches(backlog.Load{Doing: 9, Focus: 9, Open: 51}))
+}
+
+func TestLimits_IsZero(t *testing.T) {
+	assert.True(t, backlog.Limits{}.IsZero())
+	assert.False(t, backlog.Limits{Doing: 0, Focus: 0, Open: 1}.IsZero())
+}
+
+func TestDoingCount(t *testing.T) {
+	// DOING tasks are not on the backlog page, so they are counted from the queried tasks.
+	tasks := map[logseqapi.TaskUUID]logseqapi.TaskJSON{
+		"a": {UUID: "a", Marker: "DOING"}, //nolint:exhaustruct
+		"b": {UUID: "b", Marker: "TODO"},  //nolint:exhaustruct
+		"c": {UUID: "c", Marker: "DOING"}, //nolint:exhaustruct
+	}
+
+	assert.Equal(t, 2, backlog.DoingCount(tasks))
+	assert.Zero(t, backlog.DoingCount(nil))
+}
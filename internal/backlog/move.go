@@ -0,0 +1,147 @@
+package backlog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/fatih/color"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// ErrCannotMove is returned by a move directive whose task cannot go to the target backlog.
+var ErrCannotMove = errors.New("cannot move the task")
+
+// Move directives, typed in front of a block ref: >>[[backlog/home]] ((uuid)) or #move/home ((uuid)).
+const (
+	MoveMarker    = ">>"
+	MoveTagPrefix = "move/"
+)
+
+// moveDirective tells if a node in front of a block ref is a move directive, returning the backlog it names.
+// For a >>[[page]] link, marker is the text holding the >> before it.
+func moveDirective(node content.Node) (string, *content.Text, bool) {
+	switch typedNode := node.(type) {
+	case *content.Hashtag:
+		target, ok := strings.CutPrefix(typedNode.To, MoveTagPrefix)
+
+		return target, nil, ok && target != ""
+	case *content.PageLink:
+		marker, ok := typedNode.PreviousSibling().(*content.Text)
+		if ok && strings.TrimSpace(marker.Value) == MoveMarker {
+			return typedNode.To, marker, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// findBacklog returns the backlog a move directive names: by its page title, or by the last part of it
+// ("home" for "backlog/home").
+func findBacklog(backlogs []SingleBacklogConfig, name string) (SingleBacklogConfig, bool) {
+	for _, backlog := range backlogs {
+		if strings.EqualFold(backlog.BacklogPage, name) || strings.EqualFold(path.Base(backlog.BacklogPage), name) {
+			return backlog, true
+		}
+	}
+
+	return SingleBacklogConfig{}, false //nolint:exhaustruct // not found
+}
+
+// resolveMoveTargets sets the source and target backlogs of the move directives found on a backlog page.
+// A move to an unknown backlog is dropped with a warning, and its directive is left on the page.
+func resolveMoveTargets(directives []blockDirective, pageTitle string,
+	backlogs []SingleBacklogConfig) []blockDirective {
+	var source *SingleBacklogConfig
+
+	for i := range backlogs {
+		if backlogs[i].BacklogPage == pageTitle {
+			source = &backlogs[i]
+		}
+	}
+
+	resolved := directives[:0]
+
+	for _, directive := range directives {
+		if directive.Kind == directiveMove {
+			target, ok := findBacklog(backlogs, directive.MoveTo)
+			if !ok {
+				color.Yellow("[backlog] WARNING: block %s: no backlog named %q to move it to", directive.UUID,
+					directive.MoveTo)
+
+				continue
+			}
+
+			directive.Target = &target
+			directive.Source = source
+		}
+
+		resolved = append(resolved, directive)
+	}
+
+	return resolved
+}
+
+// moveTask tags a task with the first input page of its target backlog, so the target query finds it,
+// and removes the hashtags of the input pages of the backlog it leaves, so it doesn't come back.
+// A backlog with a custom query cannot be told to pick a task up.
+func moveTask(block *content.Block, directive *blockDirective) error {
+	target := directive.Target
+
+	if directive.Source != nil && directive.Source.BacklogPage == target.BacklogPage {
+		return fmt.Errorf("%w: it is already in %s", ErrCannotMove, target.BacklogPage)
+	}
+
+	if target.Query != "" || len(target.InputPages) == 0 {
+		return fmt.Errorf("%w: %s has a custom query, tag the task by hand", ErrCannotMove, target.BacklogPage)
+	}
+
+	if directive.Source != nil {
+		for _, page := range directive.Source.InputPages {
+			logseqext.RemoveTag(block, page)
+		}
+	}
+
+	for _, page := range target.InputPages {
+		if logseqext.HasDirectTag(block, page) {
+			return nil
+		}
+	}
+
+	err := logseqext.AddTag(block, target.InputPages[0])
+	if err != nil {
+		return fmt.Errorf("failed to tag the task for %s: %w", target.BacklogPage, err)
+	}
+
+	return nil
+}
+
+// movedRefs returns the block refs moved by the applied directives, by target backlog page.
+func movedRefs(groups []directiveGroup, applied []bool) map[string]*set.Set[string] {
+	moved := map[string]*set.Set[string]{}
+
+	for gi, grp := range groups {
+		if !applied[gi] {
+			continue
+		}
+
+		for _, item := range grp.items {
+			if item.Kind != directiveMove {
+				continue
+			}
+
+			page := item.Target.BacklogPage
+			if moved[page] == nil {
+				moved[page] = set.NewSet[string]()
+			}
+
+			moved[page].Add(item.UUID)
+		}
+	}
+
+	return moved
+}
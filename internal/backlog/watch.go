@@ -0,0 +1,294 @@
+package backlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+	"github.com/andreoliwa/logseq-doctor/pkg/set"
+)
+
+// DefaultWatchDebounce is how long Watch waits after the last write of a burst before running the backlogs:
+// Logseq saves a page several times while it is being typed.
+const DefaultWatchDebounce = 2 * time.Second
+
+// watchedDirs are the folders of the graph holding the Markdown files of the pages.
+var watchedDirs = []string{"journals", "pages"} //nolint:gochecknoglobals
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Dir is the graph directory.
+	Dir string
+	// ConfigPage is the page listing the backlogs, and ConfigFile the file declaring them instead, if any:
+	// a change to either runs all the backlogs.
+	ConfigPage string
+	ConfigFile string
+	// Debounce is the quiet time after the last write before the backlogs run, DefaultWatchDebounce if zero.
+	Debounce time.Duration
+}
+
+// watcher keeps what Watch needs between two bursts of writes.
+type watcher struct {
+	newProc      func() Backlog
+	reader       ConfigReader
+	partialNames []string
+	opts         WatchOptions
+	// pageRefs holds the pages and tags referenced by each Markdown file, lowercased, by path.
+	// The references a file had before a change matter too: a task that lost its tag leaves the backlog.
+	pageRefs map[string]*set.Set[string]
+}
+
+// Watch runs the backlogs matching partialNames (all of them when empty), then watches the journals and pages
+// of the graph and runs again, after each burst of writes, only the backlogs whose input pages or tagged tasks
+// changed. The files written by the runs themselves are ignored, so they don't trigger another run.
+// newProc is called before each run: a Backlog built once would query the graph as it was when the watch started.
+// It returns when ctx is done.
+func Watch(ctx context.Context, newProc func() Backlog, reader ConfigReader, partialNames []string,
+	opts WatchOptions) error {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWatchDebounce
+	}
+
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", opts.Dir, err)
+	}
+
+	opts.Dir = dir
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching files: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	for _, dir := range watchedDirs {
+		err = fsWatcher.Add(filepath.Join(opts.Dir, dir))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	if opts.ConfigFile != "" {
+		opts.ConfigFile, err = filepath.Abs(opts.ConfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", opts.ConfigFile, err)
+		}
+
+		err = fsWatcher.Add(filepath.Dir(opts.ConfigFile))
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", opts.ConfigFile, err)
+		}
+	}
+
+	w := &watcher{
+		newProc: newProc, reader: reader, partialNames: partialNames, opts: opts,
+		pageRefs: make(map[string]*set.Set[string]),
+	}
+	w.indexGraph()
+	w.run(partialNames)
+
+	return w.loop(ctx, fsWatcher)
+}
+
+// loop collects the changed files until the writes stop for the debounce time, then runs their backlogs.
+func (w *watcher) loop(ctx context.Context, fsWatcher *fsnotify.Watcher) error {
+	changed := set.NewSet[string]()
+
+	debounce := time.NewTimer(w.opts.Debounce)
+	debounce.Stop()
+
+	fmt.Printf("Watching %s for changes (press Ctrl+C to stop)\n", w.opts.Dir)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if w.relevant(event) {
+				changed.Add(event.Name)
+				debounce.Reset(w.opts.Debounce)
+			}
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			color.Yellow("warning: watching files: %v", err)
+		case <-debounce.C:
+			w.handle(changed.ValuesSorted())
+			changed = set.NewSet[string]()
+		}
+	}
+}
+
+// relevant tells if a file event can change a backlog: a Markdown file of the graph or the config file.
+func (w *watcher) relevant(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+		!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	if event.Name == w.opts.ConfigFile {
+		return true
+	}
+
+	return filepath.Ext(event.Name) == ".md" && slices.Contains(watchedDirs, filepath.Base(filepath.Dir(event.Name)))
+}
+
+// handle runs the backlogs affected by the files changed in a burst of writes.
+func (w *watcher) handle(paths []string) {
+	config, err := w.reader.ReadConfig()
+	if err != nil {
+		color.Red("failed to read config: %v", err)
+
+		return
+	}
+
+	runAll := false
+	affected := set.NewSet[string]()
+
+	for _, path := range paths {
+		all, pages := w.affected(path, config)
+		runAll = runAll || all
+
+		for _, page := range pages {
+			affected.Add(page)
+		}
+	}
+
+	switch {
+	case runAll:
+		w.run(w.partialNames)
+	case affected.Size() > 0:
+		w.run(affected.ValuesSorted())
+	}
+}
+
+// affected returns the backlog pages a changed file can change, or true when all of them must run again.
+// Own writes only refresh the references of the file.
+func (w *watcher) affected(path string, config *Config) (bool, []string) {
+	refs := w.pageRefs[path]
+	if refs == nil {
+		refs = set.NewSet[string]()
+	}
+
+	current := readPageRefs(path)
+	w.pageRefs[path] = current
+
+	if persist.OwnWrite(path) {
+		return false, nil
+	}
+
+	if path == w.opts.ConfigFile {
+		return true, nil
+	}
+
+	title := strings.ToLower(logseqext.PageTitleFromFileName(path))
+	if title == strings.ToLower(w.opts.ConfigPage) || title == strings.ToLower(config.FocusPage) {
+		return true, nil
+	}
+
+	refs.Update(current)
+	refs.Add(title)
+
+	var pages []string
+
+	for _, backlogConfig := range config.Backlogs {
+		if !w.selected(backlogConfig.BacklogPage) {
+			continue
+		}
+
+		if title == strings.ToLower(backlogConfig.BacklogPage) || backlogConfig.Query != "" ||
+			slices.ContainsFunc(backlogConfig.InputPages, func(input string) bool {
+				return refs.Contains(strings.ToLower(input))
+			}) {
+			pages = append(pages, backlogConfig.BacklogPage)
+		}
+	}
+
+	return false, pages
+}
+
+// selected tells if a backlog page matches the partial names given to Watch, like in ProcessAll.
+func (w *watcher) selected(backlogPage string) bool {
+	if len(w.partialNames) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(w.partialNames, func(partialName string) bool {
+		return strings.Contains(strings.ToLower(backlogPage), strings.ToLower(partialName))
+	})
+}
+
+// run processes the backlogs matching the names; an error is printed, and the next change runs them again.
+func (w *watcher) run(partialNames []string) {
+	err := w.newProc().ProcessAll(partialNames)
+	if err != nil {
+		color.Red("%v", err)
+	}
+}
+
+// indexGraph reads the pages and tags referenced by each Markdown file of the graph.
+func (w *watcher) indexGraph() {
+	for _, dir := range watchedDirs {
+		entries, err := os.ReadDir(filepath.Join(w.opts.Dir, dir))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ".md" {
+				path := filepath.Join(w.opts.Dir, dir, entry.Name())
+				w.pageRefs[path] = readPageRefs(path)
+			}
+		}
+	}
+}
+
+// readPageRefs returns the pages and tags referenced by a Markdown file, lowercased: [[page]], #tag, #[[tag]]
+// and the values of tags:: properties. A file that cannot be read (e.g. removed) references nothing.
+func readPageRefs(path string) *set.Set[string] {
+	refs := set.NewSet[string]()
+
+	data, err := os.ReadFile(path) //nolint:gosec // graph files chosen by the user
+	if err != nil {
+		return refs
+	}
+
+	text := string(data)
+
+	for _, tag := range logseqext.ExtractDirectTags(text) {
+		refs.Add(strings.ToLower(tag))
+	}
+
+	for line := range strings.Lines(text) {
+		_, value, found := strings.Cut(line, "tags::")
+		if !found {
+			continue
+		}
+
+		for tag := range strings.SplitSeq(value, ",") {
+			if tag = strings.Trim(strings.TrimSpace(tag), "[]#"); tag != "" {
+				refs.Add(strings.ToLower(tag))
+			}
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,187 @@
+package backlog_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreoliwa/logseq-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+// runRecorder is a Backlog that sends the names given to each ProcessAll call.
+type runRecorder struct {
+	runs chan []string
+}
+
+func (r *runRecorder) Graph() *logseq.Graph { return nil }
+
+func (r *runRecorder) ProcessAll(partialNames []string) error {
+	r.runs <- partialNames
+
+	return nil
+}
+
+func (r *runRecorder) ProcessOne(string, func() (*logseqapi.CategorizedTasks, error)) (*backlog.Result, error) {
+	return nil, nil //nolint:nilnil
+}
+
+// taskRecorder is a Backlog that sends the first line of the #home tasks found by its API on each ProcessAll call.
+type taskRecorder struct {
+	api   logseqapi.LogseqAPI
+	tasks chan []string
+}
+
+func (r *taskRecorder) Graph() *logseq.Graph { return nil }
+
+func (r *taskRecorder) ProcessAll([]string) error {
+	jsonStr, err := r.api.PostQuery("(and [[home]] (task TODO))")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	tasks, err := logseqapi.ExtractTasksFromJSON(jsonStr)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	lines := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		line, _, _ := strings.Cut(task.Content, "\n")
+		lines = append(lines, line)
+	}
+
+	r.tasks <- lines
+
+	return nil
+}
+
+func (r *taskRecorder) ProcessOne(string, func() (*logseqapi.CategorizedTasks, error)) (*backlog.Result, error) {
+	return nil, nil //nolint:nilnil
+}
+
+func nextRun(t *testing.T, runs chan []string) []string {
+	t.Helper()
+
+	select {
+	case names := <-runs:
+		return names
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the backlogs did not run")
+
+		return nil
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(pages, 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "journals"), 0o700))
+
+	notes := filepath.Join(pages, "notes.md")
+	require.NoError(t, os.WriteFile(notes, []byte("- TODO Fix the sink #home\n"), 0o600))
+
+	configFile := writeConfigFile(t, "backlogs.toml", `
+focus_page = "bk/Focus"
+
+[[backlogs]]
+page = "bk/home"
+input = ["home"]
+
+[[backlogs]]
+page = "bk/work"
+input = ["work", "Office"]
+`)
+
+	recorder := &runRecorder{runs: make(chan []string, 10)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- backlog.Watch(ctx, func() backlog.Backlog { return recorder },
+			backlog.NewFileConfigReader(configFile, "backlog"), nil,
+			backlog.WatchOptions{Dir: dir, ConfigPage: "backlog", ConfigFile: configFile, Debounce: 50 * time.Millisecond})
+	}()
+
+	assert.Nil(t, nextRun(t, recorder.runs), "all the backlogs run first, once the files are watched")
+
+	require.NoError(t, os.WriteFile(filepath.Join(pages, "meeting.md"), []byte("- TODO Call #[[office]]\n"), 0o600))
+	assert.Equal(t, []string{"bk/work"}, nextRun(t, recorder.runs), "a task tagged with an input page")
+
+	require.NoError(t, persist.WriteFile(filepath.Join(pages, "bk___home.md"), []byte("- ((uuid))\n"), 0o600))
+	require.NoError(t, os.WriteFile(notes, []byte("- TODO Fix the sink\n"), 0o600))
+	assert.Equal(t, []string{"bk/home"}, nextRun(t, recorder.runs),
+		"the task lost its tag, and the page written by lqd is ignored")
+
+	require.NoError(t, os.WriteFile(filepath.Join(pages, "bk___work.md"), []byte("- DONE ((uuid))\n"), 0o600))
+	assert.Equal(t, []string{"bk/work"}, nextRun(t, recorder.runs), "a directive typed on the backlog page")
+
+	require.NoError(t, os.WriteFile(configFile, []byte("focus_page = \"bk/Focus\"\n"), 0o600))
+	assert.Nil(t, nextRun(t, recorder.runs), "a config change runs all the backlogs")
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Empty(t, recorder.runs, "no other run")
+}
+
+func TestWatch_QueriesTheChangedGraph(t *testing.T) {
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "pages")
+	require.NoError(t, os.MkdirAll(pages, 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logseq"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), []byte("{}"), 0o600))
+
+	notes := filepath.Join(pages, "notes.md")
+	require.NoError(t, os.WriteFile(notes, []byte("- TODO Fix the sink #home\n"), 0o600))
+
+	configFile := writeConfigFile(t, "backlogs.toml", `
+[[backlogs]]
+page = "bk/home"
+input = ["home"]
+`)
+
+	tasks := make(chan []string, 10)
+	newProc := func() backlog.Backlog {
+		// The offline API writes the missing id:: properties, like "lqd backlog" does.
+		api := logseqapi.NewOfflineLogseqAPI(dir, logseqapi.OfflineOptions{WriteMissingIDs: true})
+
+		return &taskRecorder{api: api, tasks: tasks}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- backlog.Watch(ctx, newProc, backlog.NewFileConfigReader(configFile, "backlog"), nil,
+			backlog.WatchOptions{Dir: dir, ConfigPage: "backlog", ConfigFile: configFile, Debounce: 50 * time.Millisecond})
+	}()
+
+	assert.Equal(t, []string{"TODO Fix the sink #home"}, nextRun(t, tasks))
+
+	data, err := os.ReadFile(notes)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "id:: ", "the first run wrote the id of the task")
+
+	edited := strings.Replace(string(data), "Fix the sink", "Fix the sink and the tap", 1) +
+		"- TODO Paint the door #home\n"
+	require.NoError(t, os.WriteFile(notes, []byte(edited), 0o600))
+	assert.Equal(t, []string{"TODO Fix the sink and the tap #home", "TODO Paint the door #home"}, nextRun(t, tasks),
+		"the second run sees the edited task, and writes the id of the new one")
+
+	data, err = os.ReadFile(notes)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "id:: "))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Empty(t, tasks, "no other run")
+}
@@ -2,6 +2,7 @@ package backlog
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/andreoliwa/logseq-doctor/internal"
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-doctor/pkg/set"
 )
 
@@ -21,16 +23,23 @@ type pageState struct {
 	firstBlock       *content.Block
 	dividerNewTasks  *content.Block
 	dividerOverdue   *content.Block
+	dividerDueSoon   *content.Block
 	dividerFocus     *content.Block
 	dividerScheduled *content.Block
 	dividerTriaged   *content.Block
 	dividerUnranked  *content.Block
+	dividerBlocked   *content.Block
+	dividerDone      *content.Block
 
 	deletedCount            int
 	movedCount              int
 	movedScheduledCount     int
 	movedFromScheduledCount int
 	unpinnedCount           int
+	movedFromBlockedCount   int
+	movedFromDueSoonCount   int
+	doneCount               int
+	archivedCount           int
 
 	result            *Result
 	pinnedBlockRefs   *set.Set[string]
@@ -38,54 +47,134 @@ type pageState struct {
 	scheduledBlockRefs *set.Set[string] // UUIDs already in the Scheduled section
 	seenBlockRefs     *set.Set[string] // UUIDs seen during the current scan (for deduplication)
 	unscheduledRefs   *set.Set[string] // UUIDs removed from Scheduled because they lost their scheduled date
+	dueSoonRefs       *set.Set[string] // UUIDs of the tasks with a deadline in the next days
+	newlyDueSoonRefs  *set.Set[string] // UUIDs of due soon tasks to add to the Due soon section
+	notDueSoonRefs    *set.Set[string] // UUIDs removed from Due soon because their deadline moved away
+	blockedRefs       *set.Set[string] // UUIDs of the tasks waiting for open blockers
+	newlyBlockedRefs  *set.Set[string] // UUIDs of blocked tasks to add to the Blocked section
+	unblockedRefs     *set.Set[string] // UUIDs removed from Blocked because their blockers are done
+	completedRefs     *set.Set[string] // UUIDs of DONE tasks to add to the Done section
+	reopenedRefs      *set.Set[string] // UUIDs removed from the Done section because their task is open again
+	taskDone          func(uuid string) bool // tells if an obsolete ref is a DONE task, nil without a Done section
+	nextOccurrences   map[string]time.Time // next scheduled day of the repeating tasks, by UUID
 	directives        []blockDirective // pending task modifications found on the backlog page
+	sectionOrder      []Header         // configured order of the sections, see placeSection
+	headers           *HeaderRegistry  // section headers of the run, see Headers
+	limitBreaches     []string         // WIP limits the page is over, see Limits.Breaches
 }
 
-func newPageState() *pageState {
+func newPageState(sectionOrder []Header) *pageState {
 	return &pageState{ //nolint:exhaustruct // zero values for all pointer/int fields are correct defaults
-		result:             &Result{FocusRefsFromPage: set.NewSet[string](), ShowQuickCapture: false},
+		sectionOrder:       sectionOrder,
+		headers:            Headers(),
+		result: &Result{
+			FocusRefsFromPage: set.NewSet[string](), DueSoonRefs: set.NewSet[string](), ShowQuickCapture: false,
+			MovedRefs: map[string]*set.Set[string]{}, AutoFocusRefs: nil, RankedRefs: nil,
+		},
 		pinnedBlockRefs:    set.NewSet[string](),
 		triagedBlockRefs:   set.NewSet[string](),
 		scheduledBlockRefs: set.NewSet[string](),
 		seenBlockRefs:      set.NewSet[string](),
 		unscheduledRefs:    set.NewSet[string](),
+		dueSoonRefs:        set.NewSet[string](),
+		newlyDueSoonRefs:   set.NewSet[string](),
+		notDueSoonRefs:     set.NewSet[string](),
+		blockedRefs:        set.NewSet[string](),
+		newlyBlockedRefs:   set.NewSet[string](),
+		unblockedRefs:      set.NewSet[string](),
+		completedRefs:      set.NewSet[string](),
+		reopenedRefs:       set.NewSet[string](),
 	}
 }
 
 func insertAndRemoveRefs(
 	graph *logseq.Graph, logseqAPI logseqapi.LogseqAPI, pageTitle string,
 	newBlockRefs, obsoleteBlockRefs, overdueBlockRefs, futureScheduledBlockRefs *set.Set[string],
+	dueSoonBlockRefs, blockedBlockRefs *set.Set[string],
 	taskLookup map[logseqapi.TaskUUID]logseqapi.TaskJSON,
-	currentTime func() time.Time,
+	sectionOrder []Header, backlogs []SingleBacklogConfig, limits Limits, currentTime func() time.Time,
 ) (*Result, error) {
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	page, err := transaction.OpenPage(pageTitle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open page for transaction: %w", err)
 	}
 
-	state := newPageState()
+	state := newPageState(sectionOrder)
+
+	// Without their section, overdue and future scheduled tasks stay with the other tasks.
+	if !state.headers.Enabled(SectionNameOverdue) {
+		overdueBlockRefs = set.NewSet[string]()
+	}
+
+	if !state.headers.Enabled(SectionNameScheduled) {
+		futureScheduledBlockRefs = set.NewSet[string]()
+	}
+
+	// An approaching deadline matters more than a later scheduled date.
+	if state.headers.Enabled(SectionNameDueSoon) {
+		state.dueSoonRefs = dueSoonBlockRefs
+		futureScheduledBlockRefs = futureScheduledBlockRefs.Diff(dueSoonBlockRefs)
+	}
+
+	// A blocked task waits in its section whatever its dates, there is nothing to do about it yet.
+	if state.headers.Enabled(SectionNameBlocked) {
+		state.blockedRefs = blockedBlockRefs
+		overdueBlockRefs = overdueBlockRefs.Diff(blockedBlockRefs)
+		futureScheduledBlockRefs = futureScheduledBlockRefs.Diff(blockedBlockRefs)
+		state.dueSoonRefs = state.dueSoonRefs.Diff(blockedBlockRefs)
+	}
+
+	doneEnabled := state.headers.Enabled(SectionNameDone)
+	if doneEnabled {
+		state.taskDone = func(uuid string) bool { return isTaskDone(logseqAPI, uuid) }
+	}
+
+	state.nextOccurrences = nextOccurrences(taskLookup, currentTime)
 
 	normalised := NormalizeHeaderText(page)
 	scanPageBlocks(page, state, obsoleteBlockRefs, overdueBlockRefs, futureScheduledBlockRefs)
-	directivesApplied := applyDirectives(graph, logseqAPI, state.directives, currentTime)
+	state.directives = resolveMoveTargets(state.directives, pageTitle, backlogs)
+	directivesApplied, movedRefs, doneRefs := applyDirectives(graph, logseqAPI, state.directives, currentTime)
+	state.result.MovedRefs = movedRefs
+
+	if doneEnabled {
+		state.completedRefs.Update(doneRefs)
+	}
+
 	insertOverdueTasks(page, state, overdueBlockRefs)
 
 	// Merge refs removed from Scheduled (no longer future-dated) so they are re-inserted as new tasks.
 	newBlockRefs.Update(state.unscheduledRefs)
+	newBlockRefs.Update(state.unblockedRefs)
+	newBlockRefs.Update(state.notDueSoonRefs)
+	newBlockRefs.Update(state.reopenedRefs.Diff(state.seenBlockRefs))
 
 	save := insertNewTasks(page, state, newBlockRefs, overdueBlockRefs, futureScheduledBlockRefs)
 	save = save || normalised || directivesApplied
 
+	insertDueSoonTasks(page, state)
 	insertScheduledTasks(page, state, futureScheduledBlockRefs)
+	insertBlockedTasks(page, state)
+	insertDoneTasks(page, state, currentTime())
+
+	err = archiveDoneTasks(transaction, pageTitle, state, state.headers.DoneKeepDays(), currentTime())
+	if err != nil {
+		return nil, err
+	}
 
 	sortTriagedSection(state, taskLookup)
 	save = logseqext.RemoveEmptyBlocks(save,
-		state.dividerNewTasks, state.dividerOverdue, state.dividerScheduled,
-		state.dividerTriaged, state.dividerUnranked)
+		state.dividerNewTasks, state.dividerOverdue, state.dividerDueSoon, state.dividerScheduled,
+		state.dividerTriaged, state.dividerBlocked, state.dividerUnranked, state.dividerDone)
+
+	state.limitBreaches = limitBreaches(page, limits, DoingCount(taskLookup))
+	save = updateLimitWarning(page, state.limitBreaches) || save
 	save = reportCounts(state, save)
 
+	collectFocusRefs(page, state)
+
 	if save {
 		err = transaction.Save()
 		if err != nil {
@@ -103,13 +192,14 @@ func insertAndRemoveRefs(
 }
 
 // NormalizeHeaderText scans all top-level blocks on the page and normalizes any
-// block whose text node contains a known header keyword:
-//   - fixes the text to the canonical "emoji Label tasks" form
+// block whose text node is an enabled header (see Header.Matches):
+//   - fixes the text to the canonical "emoji Label suffix" form
 //   - upgrades a plain Paragraph container to a Heading level 1
 //
 // Returns true if any block was changed.
 func NormalizeHeaderText(page logseq.Page) bool {
 	changed := false
+	registry := Headers()
 
 	for _, block := range page.Blocks() {
 		block.Children().FindDeep(func(node content.Node) bool {
@@ -120,33 +210,30 @@ func NormalizeHeaderText(page logseq.Page) bool {
 
 			trimmed := strings.TrimSpace(text.Value)
 
-			for _, hdr := range allHeaders {
-				if !hdr.Matches(trimmed) {
-					continue
-				}
-
-				// Fix text to canonical form.
-				if trimmed != hdr.String() {
-					suffix := text.Value[len(trimmed):]
-					text.Value = hdr.String() + suffix
-					changed = true
-				}
-
-				// Upgrade Paragraph → Heading(1) if needed.
-				if para, ok := node.Parent().(*content.Paragraph); ok {
-					heading := content.NewHeading(1)
+			hdr, ok := registry.Match(trimmed)
+			if !ok {
+				return false
+			}
 
-					for child := para.FirstChild(); child != nil; child = para.FirstChild() {
-						para.RemoveChild(child)
-						heading.AddChild(child)
-					}
+			// Fix text to canonical form.
+			if trimmed != hdr.String() {
+				suffix := text.Value[len(strings.TrimRight(text.Value, " \t")):]
+				text.Value = hdr.String() + suffix
+				changed = true
+			}
 
-					para.ReplaceWith(heading)
+			// Upgrade Paragraph → Heading(1) if needed.
+			if para, ok := node.Parent().(*content.Paragraph); ok {
+				heading := content.NewHeading(1)
 
-					changed = true
+				for child := para.FirstChild(); child != nil; child = para.FirstChild() {
+					para.RemoveChild(child)
+					heading.AddChild(child)
 				}
 
-				break
+				para.ReplaceWith(heading)
+
+				changed = true
 			}
 
 			return false
@@ -176,7 +263,7 @@ func collectScheduledRefs(page logseq.Page, state *pageState) {
 	for _, block := range page.Blocks() {
 		block.Children().FindDeep(func(node content.Node) bool {
 			if text, ok := node.(*content.Text); ok {
-				if HeaderScheduled.Matches(text.Value) {
+				if state.headers.Is(SectionNameScheduled, text.Value) {
 					scheduledBlock = block
 				}
 			}
@@ -208,7 +295,7 @@ func collectTriagedRefs(page logseq.Page, state *pageState) {
 	for _, block := range page.Blocks() {
 		block.Children().FindDeep(func(node content.Node) bool {
 			if text, ok := node.(*content.Text); ok {
-				if HeaderTriaged.Matches(text.Value) {
+				if state.headers.Is(SectionNameTriaged, text.Value) {
 					state.dividerTriaged = block
 				}
 			}
@@ -259,26 +346,37 @@ func processAllBlocks(
 	}
 }
 
-// recordSectionDivider updates state with a block if its text matches a known section header.
+// recordSectionDivider updates state with a block if its text is an enabled section header.
 func recordSectionDivider(block *content.Block, textValue string, state *pageState) {
-	switch {
-	case HeaderNewTasks.Matches(textValue):
+	header, ok := state.headers.Match(textValue)
+	if !ok {
+		return
+	}
+
+	switch header.Name {
+	case SectionNameNew:
 		state.dividerNewTasks = block
-	case HeaderOverdue.Matches(textValue):
+	case SectionNameOverdue:
 		state.dividerOverdue = block
-	case HeaderFocus.Matches(textValue):
+	case SectionNameDueSoon:
+		state.dividerDueSoon = block
+	case SectionNameFocus:
 		state.dividerFocus = block
-	case HeaderScheduled.Matches(textValue):
+	case SectionNameScheduled:
 		state.dividerScheduled = block
-	case HeaderTriaged.Matches(textValue):
+	case SectionNameTriaged:
 		state.dividerTriaged = block
-	case HeaderUnranked.Matches(textValue):
+	case SectionNameUnranked:
 		state.dividerUnranked = block
+	case SectionNameBlocked:
+		state.dividerBlocked = block
+	case SectionNameDone:
+		state.dividerDone = block
 	}
 }
 
 // processBlockRef decides whether to delete, pin, unpin, or keep a block ref.
-// It also collects directive annotations (CANCELED, WAITING, priority) prepended to the ref.
+// It also collects the directives typed around the ref (task markers, priorities, dates, moves, tags).
 func processBlockRef(
 	node content.Node, blockRef *content.BlockRef, block *content.Block,
 	state *pageState,
@@ -290,6 +388,12 @@ func processBlockRef(
 	underUnranked := state.dividerUnranked != nil && internal.IsAncestor(block, state.dividerUnranked)
 	underScheduled := state.dividerScheduled != nil && internal.IsAncestor(block, state.dividerScheduled)
 
+	if state.dividerDone != nil && internal.IsAncestor(block, state.dividerDone) {
+		handleDoneRef(blockRef, state, obsoleteBlockRefs)
+
+		return
+	}
+
 	if handleBlockRefGuards(blockRef, state, underTriaged, underUnranked, underScheduled, obsoleteBlockRefs) {
 		return
 	}
@@ -363,14 +467,31 @@ func categorizeBlockRef(
 	obsoleteBlockRefs, overdueBlockRefs, futureScheduledBlockRefs *set.Set[string],
 	underTriaged bool,
 ) bool {
+	underBlocked := state.dividerBlocked != nil && internal.IsAncestor(block, state.dividerBlocked)
+	underDueSoon := state.dividerDueSoon != nil && internal.IsAncestor(block, state.dividerDueSoon)
+
 	switch {
 	case obsoleteBlockRefs.Contains(blockRef.ID) && !underTriaged:
-		state.deletedCount++
+		if state.taskDone != nil && state.taskDone(blockRef.ID) {
+			state.completedRefs.Add(blockRef.ID)
+		} else {
+			state.deletedCount++
+		}
+
+		return true
+
+	case state.blockedRefs.Contains(blockRef.ID):
+		if underBlocked {
+			return false
+		}
+
+		state.newlyBlockedRefs.Add(blockRef.ID)
 
 		return true
 
 	case overdueBlockRefs.Contains(blockRef.ID):
-		if nextChildHasPin(node) {
+		// A task put in focus by a FocusPolicy is pinned there, like with 📌.
+		if nextChildHasPin(node) || (state.dividerFocus == nil && hasAutoFocusMarker(block)) {
 			state.pinnedBlockRefs.Add(blockRef.ID)
 
 			return false
@@ -380,6 +501,15 @@ func categorizeBlockRef(
 
 		return true
 
+	case state.dueSoonRefs.Contains(blockRef.ID):
+		if underDueSoon {
+			return false
+		}
+
+		state.newlyDueSoonRefs.Add(blockRef.ID)
+
+		return true
+
 	case futureScheduledBlockRefs.Contains(blockRef.ID):
 		if state.dividerScheduled == nil || !internal.IsAncestor(block, state.dividerScheduled) {
 			state.movedScheduledCount++
@@ -387,6 +517,20 @@ func categorizeBlockRef(
 
 		return true
 
+	case underBlocked:
+		// All its blockers are done: it goes back to the new tasks.
+		state.unblockedRefs.Add(blockRef.ID)
+		state.movedFromBlockedCount++
+
+		return true
+
+	case underDueSoon:
+		// Its deadline was removed or postponed: it goes back to the new tasks.
+		state.notDueSoonRefs.Add(blockRef.ID)
+		state.movedFromDueSoonCount++
+
+		return true
+
 	default:
 		return handleDefaultBlockRef(node, blockRef, state, underScheduled, futureScheduledBlockRefs)
 	}
@@ -405,8 +549,10 @@ func insertOverdueTasks(page logseq.Page, state *pageState, overdueBlockRefs *se
 		}
 
 		if state.dividerOverdue == nil {
-			state.dividerOverdue = content.NewBlock(HeaderOverdue.NewHeading())
-			logseqext.AddSibling(page, state.dividerOverdue, state.firstBlock, state.dividerFocus)
+			state.dividerOverdue = content.NewBlock(state.headers.Get(SectionNameOverdue).NewHeading())
+			if !placeSection(page, state, SectionNameOverdue, state.dividerOverdue) {
+				logseqext.AddSibling(page, state.dividerOverdue, state.firstBlock, state.dividerFocus)
+			}
 		}
 
 		overdueTask := content.NewBlock(content.NewParagraph(
@@ -417,6 +563,61 @@ func insertOverdueTasks(page logseq.Page, state *pageState, overdueBlockRefs *se
 	}
 }
 
+// placeSection inserts a new section divider where the configured section order wants it:
+// after the closest section before it in the order that is on the page, otherwise before the closest one after it.
+// It returns false, inserting nothing, when the order doesn't list the section or no listed section is on the page;
+// the caller then uses the default place.
+func placeSection(page logseq.Page, state *pageState, name string, divider *content.Block) bool {
+	index := slices.IndexFunc(state.sectionOrder, func(header Header) bool { return header.Name == name })
+	if index < 0 {
+		return false
+	}
+
+	for i := index - 1; i >= 0; i-- {
+		if existing := state.divider(state.sectionOrder[i]); existing != nil {
+			page.InsertBlockAfter(divider, existing)
+
+			return true
+		}
+	}
+
+	for _, next := range state.sectionOrder[index+1:] {
+		if existing := state.divider(next); existing != nil {
+			page.InsertBlockBefore(divider, existing)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// divider returns the divider block of a section found on the page, or nil.
+func (s *pageState) divider(header Header) *content.Block {
+	switch header.Name {
+	case SectionNameFocus:
+		return s.dividerFocus
+	case SectionNameOverdue:
+		return s.dividerOverdue
+	case SectionNameDueSoon:
+		return s.dividerDueSoon
+	case SectionNameNew:
+		return s.dividerNewTasks
+	case SectionNameTriaged:
+		return s.dividerTriaged
+	case SectionNameScheduled:
+		return s.dividerScheduled
+	case SectionNameUnranked:
+		return s.dividerUnranked
+	case SectionNameBlocked:
+		return s.dividerBlocked
+	case SectionNameDone:
+		return s.dividerDone
+	}
+
+	return nil
+}
+
 // insertNewTasks inserts new task refs under the new-tasks divider (creating it if needed).
 // Returns updated save flag.
 func insertNewTasks(
@@ -440,18 +641,23 @@ func insertNewTasks(
 			continue
 		}
 
+		if state.blockedRefs.Contains(blockRef) {
+			state.newlyBlockedRefs.Add(blockRef)
+
+			continue
+		}
+
+		if state.dueSoonRefs.Contains(blockRef) {
+			state.newlyDueSoonRefs.Add(blockRef)
+
+			continue
+		}
+
 		if state.dividerNewTasks == nil {
-			state.dividerNewTasks = content.NewBlock(HeaderNewTasks.NewHeading())
-
-			if state.dividerUnranked != nil {
-				// Always insert directly before Unranked — it is the definitive anchor,
-				// even if Focus or Overdue sections exist above it.
-				page.InsertBlockBefore(state.dividerNewTasks, state.dividerUnranked)
-			} else {
-				logseqext.AddSibling(
-					page, state.dividerNewTasks, state.firstBlock,
-					state.dividerOverdue, state.dividerFocus,
-				)
+			state.dividerNewTasks = content.NewBlock(state.headers.Get(SectionNameNew).NewHeading())
+
+			if !placeSection(page, state, SectionNameNew, state.dividerNewTasks) {
+				placeNewTasksDivider(page, state)
 			}
 		}
 
@@ -465,7 +671,24 @@ func insertNewTasks(
 	return true
 }
 
+// placeNewTasksDivider inserts the new-tasks divider at its default place.
+func placeNewTasksDivider(page logseq.Page, state *pageState) {
+	if state.dividerUnranked != nil {
+		// Always insert directly before Unranked — it is the definitive anchor,
+		// even if Focus or Overdue sections exist above it.
+		page.InsertBlockBefore(state.dividerNewTasks, state.dividerUnranked)
+
+		return
+	}
+
+	logseqext.AddSibling(
+		page, state.dividerNewTasks, state.firstBlock,
+		state.dividerDueSoon, state.dividerOverdue, state.dividerFocus,
+	)
+}
+
 // insertScheduledTasks moves future-scheduled tasks to the bottom of the page.
+// A repeating task is shown once, with its next occurrence.
 func insertScheduledTasks(page logseq.Page, state *pageState, futureScheduledBlockRefs *set.Set[string]) {
 	if futureScheduledBlockRefs.Size() == 0 {
 		return
@@ -473,12 +696,41 @@ func insertScheduledTasks(page logseq.Page, state *pageState, futureScheduledBlo
 
 	for _, blockRef := range futureScheduledBlockRefs.ValuesSorted() {
 		if state.dividerScheduled == nil {
-			state.dividerScheduled = content.NewBlock(HeaderScheduled.NewHeading())
-			page.AddBlock(state.dividerScheduled)
+			state.dividerScheduled = content.NewBlock(state.headers.Get(SectionNameScheduled).NewHeading())
+			if !placeSection(page, state, SectionNameScheduled, state.dividerScheduled) {
+				page.AddBlock(state.dividerScheduled)
+			}
+		}
+
+		entry := content.NewBlock(content.NewBlockRef(blockRef))
+		if next, ok := state.nextOccurrences[blockRef]; ok {
+			entry = content.NewBlock(content.NewParagraph(
+				content.NewBlockRef(blockRef),
+				content.NewText(" 🔁 "+logseqext.FormatLogseqDate(next)),
+			))
+		}
+
+		state.dividerScheduled.AddChild(entry)
+	}
+}
+
+// nextOccurrences returns the next scheduled day of the repeating tasks of a backlog, by UUID.
+func nextOccurrences(
+	taskLookup map[logseqapi.TaskUUID]logseqapi.TaskJSON, currentTime func() time.Time,
+) map[string]time.Time {
+	next := make(map[string]time.Time)
+
+	for uuid, task := range taskLookup {
+		if logseqapi.TaskRepeater(task).IsZero() {
+			continue
 		}
 
-		state.dividerScheduled.AddChild(content.NewBlock(content.NewBlockRef(blockRef)))
+		if scheduled, _ := logseqapi.TaskDates(task, currentTime); scheduled > 0 {
+			next[uuid] = logseqext.JournalDayToTime(scheduled)
+		}
 	}
+
+	return next
 }
 
 // reportCounts prints colored summaries and returns updated save flag.
@@ -510,12 +762,64 @@ func reportCounts(state *pageState, save bool) bool {
 		state.result.ShowQuickCapture = true
 	}
 
+	if state.newlyDueSoonRefs.Size() > 0 {
+		color.Yellow(" %s due soon", FormatCount(state.newlyDueSoonRefs.Size(), "task is", "tasks are"))
+
+		save = true
+		state.result.ShowQuickCapture = true
+	}
+
+	if state.movedFromDueSoonCount > 0 {
+		color.Yellow(" %s no longer due soon and moved to new tasks",
+			FormatCount(state.movedFromDueSoonCount, "task was", "tasks were"))
+
+		save = true
+		state.result.ShowQuickCapture = true
+	}
+
+	if state.newlyBlockedRefs.Size() > 0 {
+		color.Red(" %s moved to blocked tasks",
+			FormatCount(state.newlyBlockedRefs.Size(), "task was", "tasks were"))
+
+		save = true
+	}
+
+	if state.movedFromBlockedCount > 0 {
+		color.Yellow(" %s unblocked and moved to new tasks",
+			FormatCount(state.movedFromBlockedCount, "task was", "tasks were"))
+
+		save = true
+		state.result.ShowQuickCapture = true
+	}
+
 	if state.unpinnedCount > 0 {
 		color.Cyan(" %s unpinned", FormatCount(state.unpinnedCount, "task was", "tasks were"))
 
 		save = true
 	}
 
+	if state.doneCount > 0 {
+		color.Green(" %s done", FormatCount(state.doneCount, "task was", "tasks were"))
+
+		save = true
+	}
+
+	if state.reopenedRefs.Size() > 0 {
+		color.Yellow(" %s reopened", FormatCount(state.reopenedRefs.Size(), "task was", "tasks were"))
+
+		save = true
+		state.result.ShowQuickCapture = true
+	}
+
+	if state.archivedCount > 0 {
+		color.Blue(" %s archived", FormatCount(state.archivedCount, "task was", "tasks were"))
+
+		save = true
+	}
+
+	// The warning block is saved by updateLimitWarning, only when it changes.
+	ReportBreaches(state.limitBreaches)
+
 	return save
 }
 
@@ -652,14 +956,8 @@ func taskSortKeyLess(left, right taskSortKey) bool {
 	return left.id < right.id
 }
 
-// focusSectionHeaders are the section dividers on the Focus page.
-// Used to find the insertion point for new block refs.
-//
-//nolint:gochecknoglobals // constant lookup table for section detection
-var focusSectionHeaders = []Header{HeaderOverdue, HeaderNewTasks, HeaderTriaged, HeaderScheduled}
-
 // AddBlockRefToFocusPage adds a block ref ((uuid)) to the Focus page.
-func AddBlockRefToFocusPage(transaction *logseq.Transaction, focusPageTitle, uuid string) error {
+func AddBlockRefToFocusPage(transaction *persist.Transaction, focusPageTitle, uuid string) error {
 	page, err := transaction.OpenPage(focusPageTitle)
 	if err != nil {
 		return fmt.Errorf("failed to open Focus page: %w", err)
@@ -677,27 +975,33 @@ func AddBlockRefToFocusPage(transaction *logseq.Transaction, focusPageTitle, uui
 	return nil
 }
 
-// FindFirstSectionDivider finds the first block whose text matches a known section header.
+// FindFirstSectionDivider finds the first block that is a section header on the Focus page,
+// i.e. any enabled header but Focus itself. Used to find the insertion point for new block refs.
 func FindFirstSectionDivider(page logseq.Page) *content.Block {
-	for _, block := range page.Blocks() {
-		blockText := logseqext.BlockContentText(block)
+	registry := Headers()
 
-		for _, header := range focusSectionHeaders {
-			if header.Matches(blockText) {
-				return block
-			}
+	for _, block := range page.Blocks() {
+		header, ok := registry.Match(logseqext.BlockContentText(block))
+		if ok && header.Name != SectionNameFocus && header.Name != SectionNameUnranked {
+			return block
 		}
 	}
 
 	return nil
 }
 
-// regularAreaSectionHeaders are section headers that mark the boundary of the regular area.
-// A top-level block matching any of these is a section divider, not part of the regular area.
-//
-//nolint:gochecknoglobals // constant lookup table for regular area detection
-var regularAreaSectionHeaders = []Header{
-	HeaderFocus, HeaderOverdue, HeaderNewTasks, HeaderTriaged, HeaderScheduled, HeaderUnranked,
+// FindSectionDivider finds the top-level block that is the header of a section, or nil.
+// Unlike logseqext.FindBlockContainingText, a block that merely mentions the label is not a match.
+func FindSectionDivider(page logseq.Page, name string) *content.Block {
+	registry := Headers()
+
+	for _, block := range page.Blocks() {
+		if registry.Is(name, logseqext.BlockContentText(block)) {
+			return block
+		}
+	}
+
+	return nil
 }
 
 // BlockRefExistsUnder returns true if a block ref with the given UUID exists
@@ -723,20 +1027,10 @@ func BlockRefExistsUnder(parent *content.Block, uuid logseqapi.TaskUUID) bool {
 // Section dividers (Focus, Overdue, New tasks, Triaged, Scheduled, Unranked) and their children
 // are not part of the regular area. Since we walk only top-level blocks, child refs are never seen.
 func RemoveBlockRefFromRegularArea(page logseq.Page, uuid logseqapi.TaskUUID) {
-	for _, block := range page.Blocks() {
-		blockText := logseqext.BlockContentText(block)
-
-		isDivider := false
-
-		for _, header := range regularAreaSectionHeaders {
-			if header.Matches(blockText) {
-				isDivider = true
+	registry := Headers()
 
-				break
-			}
-		}
-
-		if isDivider {
+	for _, block := range page.Blocks() {
+		if _, isDivider := registry.Match(logseqext.BlockContentText(block)); isDivider {
 			continue
 		}
 
@@ -775,7 +1069,7 @@ func RemoveBlockRefFromRegularArea(page logseq.Page, uuid logseqapi.TaskUUID) {
 // it is removed from there. If it's already in Triaged, no duplicate is added.
 // Creates the Triaged section if it doesn't exist.
 func MoveBlockRefToTriagedSection(
-	transaction *logseq.Transaction, backlogPage string, uuid logseqapi.TaskUUID, triagedText, scheduledText string,
+	transaction *persist.Transaction, backlogPage string, uuid logseqapi.TaskUUID, triagedText, scheduledText string,
 ) error {
 	page, err := transaction.OpenPage(backlogPage)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-doctor/internal/testutils"
 	logseq "github.com/andreoliwa/logseq-go"
 	"github.com/andreoliwa/logseq-go/content"
@@ -38,7 +39,7 @@ func TestFindFirstSectionDivider_NoDivider(t *testing.T) {
 
 func TestAddBlockRefToFocusPage_NoSectionDivider(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	initialPage, err := graph.OpenPage("bk")
 	require.NoError(t, err)
@@ -59,7 +60,7 @@ func TestAddBlockRefToFocusPage_NoSectionDivider(t *testing.T) {
 
 func TestAddBlockRefToFocusPage_InsertsBeforeSectionDivider(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	err := backlog.AddBlockRefToFocusPage(transaction, "focus-with-sections", "inserted-uuid")
 	require.NoError(t, err)
@@ -92,7 +93,7 @@ func TestAddBlockRefToFocusPage_InsertsBeforeSectionDivider(t *testing.T) {
 
 func TestMoveBlockRefToTriagedSection_ExistingSection(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// backlog-with-triaged-child.md has a Triaged section divider with one child
 	err := backlog.MoveBlockRefToTriagedSection(
@@ -114,7 +115,7 @@ func TestMoveBlockRefToTriagedSection_ExistingSection(t *testing.T) {
 
 func TestMoveBlockRefToTriagedSection_CreatesSectionBeforeScheduled(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	err := backlog.MoveBlockRefToTriagedSection(
 		transaction, "backlog-no-someday", "new-triaged-uuid",
@@ -180,7 +181,7 @@ const uuidNestedInSection = "e1e1e1e1-e1e1-e1e1-e1e1-e1e1e1e1e1e1"
 
 func TestMoveBlockRefToTriagedSection_MovesFromRegularArea(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// uuidRegularTask exists in regular area of backlog-with-regular-task
 	err := backlog.MoveBlockRefToTriagedSection(
@@ -208,7 +209,7 @@ func TestMoveBlockRefToTriagedSection_MovesFromRegularArea(t *testing.T) {
 
 func TestMoveBlockRefToTriagedSection_AlreadyInTriaged_Idempotent(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	err := backlog.MoveBlockRefToTriagedSection(
 		transaction, "backlog-triaged-only", uuidAlreadyTriaged,
@@ -240,7 +241,7 @@ func TestMoveBlockRefToTriagedSection_AlreadyInTriaged_Idempotent(t *testing.T)
 
 func TestMoveBlockRefToTriagedSection_InBothAreas_RemovesFromRegular(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	err := backlog.MoveBlockRefToTriagedSection(
 		transaction, "backlog-duplicate-task", uuidDupTask,
@@ -288,7 +289,7 @@ func collectBlockRefUUIDs(block *content.Block) []string {
 
 func TestMoveBlockRefToTriagedSection_NestedInTriaged_Idempotent(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// uuidTriagedNested is nested 2 levels under Triaged (not a direct child).
 	// It should be recognized as already in Triaged and not added again.
@@ -325,7 +326,7 @@ func TestMoveBlockRefToTriagedSection_NestedInTriaged_Idempotent(t *testing.T) {
 
 func TestMoveBlockRefToTriagedSection_RegularAreaAfterNewTasks(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// uuidRegularAfterNewTasks is a top-level block ref that appears AFTER the New tasks divider.
 	// It should still be considered part of the regular area and be moved to Triaged.
@@ -354,7 +355,7 @@ func TestMoveBlockRefToTriagedSection_RegularAreaAfterNewTasks(t *testing.T) {
 
 func TestMoveBlockRefToTriagedSection_DoesNotRemoveFromNewTasksChildren(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// uuidUnderNewTasks is a child of the New tasks divider, not in the regular area.
 	// MoveBlockRefToTriagedSection should not remove it from there.
@@ -379,7 +380,7 @@ func TestMoveBlockRefToTriagedSection_DoesNotRemoveFromNewTasksChildren(t *testi
 
 func TestMoveBlockRefToTriagedSection_MovesFromNestedNamedSection(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	// uuidNestedInSection is nested 2 levels under a non-divider section ("Features > Outline").
 	// It should be removed from that nested position and added to Triaged.
@@ -0,0 +1,473 @@
+// Package config reads the lqd configuration file and resolves settings with the precedence
+// flag > environment variable > config file > default.
+//
+// The file is TOML with one section per command, e.g.:
+//
+//	[logseq]
+//	graph_path = "~/logseq/my-graph"
+//
+//	[backlog]
+//	config_page = "backlog"
+//
+//	[tidy-up]
+//	forbidden_refs = ["quick capture", "inbox"]
+//
+// Named graph profiles live under [graphs.NAME]; see Graph.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrUnknownKey is returned when a key is not one of the known settings.
+var ErrUnknownKey = errors.New("unknown config key")
+
+// ErrInvalidValue is returned when a value cannot be converted to the type of a setting.
+var ErrInvalidValue = errors.New("invalid config value")
+
+// ErrUnknownGraph is returned when a graph name has no [graphs.NAME] profile.
+var ErrUnknownGraph = errors.New("unknown graph")
+
+// ErrInvalidGraphName is returned for graph profile names that are not made of letters, digits, "-" and "_".
+// Names end up in PocketBase filters and URLs, so they are kept simple.
+var ErrInvalidGraphName = errors.New("invalid graph name")
+
+// Keys of the known settings, in "section.name" form.
+const (
+	KeyGraph                = "logseq.graph"
+	KeyGraphPath            = "logseq.graph_path"
+	KeyHostURL              = "logseq.host_url"
+	KeyAPIToken             = "logseq.api_token"
+	KeyOffline              = "logseq.offline"
+	KeyPocketBaseURL        = "pocketbase.url"
+	KeyPocketBaseUsername   = "pocketbase.username"
+	KeyPocketBasePassword   = "pocketbase.password"
+	KeyPocketBaseCollection = "pocketbase.collection"
+	KeyDashboardPort        = "dashboard.port"
+	KeyBacklogConfigPage    = "backlog.config_page"
+	KeyBacklogConfigFile    = "backlog.config_file"
+	KeyBacklogWorkers       = "backlog.workers"
+	KeyBacklogFillFocus     = "backlog.fill_focus"
+	KeyBacklogFocusPer      = "backlog.focus_per_backlog"
+	KeyBacklogFocusLimit    = "backlog.focus_limit"
+	KeyBacklogFocusOverdueA = "backlog.focus_overdue_a"
+	KeyGroomOlderThan       = "groom.older_than"
+	KeyGroomLimit           = "groom.limit"
+	KeyTidyUpForbiddenRefs  = "tidy-up.forbidden_refs"
+	KeyUndoDir              = "undo.dir"
+	KeyUndoKeep             = "undo.keep"
+)
+
+// Kind is the type of the value of a setting, used to write it to the file.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindBool
+	// KindList is a list of strings: a TOML array in the file, comma-separated in env vars and on the command line.
+	KindList
+	// KindPath is a file system path; a leading "~/" in the config file is expanded to the home directory.
+	KindPath
+)
+
+// Setting describes a configuration key.
+type Setting struct {
+	Key     string
+	Env     string
+	Default string
+	Kind    Kind
+	// Secret values are masked by "lqd config show".
+	Secret bool
+}
+
+// Source tells where a resolved value came from.
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+	// SourceGraph is the source of values taken from the selected graph profile.
+	SourceGraph Source = "graph"
+)
+
+// Settings returns the known settings, in display order.
+func Settings() []Setting {
+	return []Setting{
+		{Key: KeyGraph, Env: "LQD_GRAPH", Default: "", Kind: KindString, Secret: false},
+		{Key: KeyGraphPath, Env: "LOGSEQ_GRAPH_PATH", Default: "", Kind: KindPath, Secret: false},
+		{Key: KeyHostURL, Env: "LOGSEQ_HOST_URL", Default: "", Kind: KindString, Secret: false},
+		{Key: KeyAPIToken, Env: "LOGSEQ_API_TOKEN", Default: "", Kind: KindString, Secret: true},
+		{Key: KeyOffline, Env: "LQD_OFFLINE", Default: "false", Kind: KindBool, Secret: false},
+		{Key: KeyPocketBaseURL, Env: "POCKETBASE_URL", Default: "http://127.0.0.1:8090", Kind: KindString, Secret: false},
+		{Key: KeyPocketBaseUsername, Env: "POCKETBASE_USERNAME", Default: "", Kind: KindString, Secret: false},
+		{Key: KeyPocketBasePassword, Env: "POCKETBASE_PASSWORD", Default: "", Kind: KindString, Secret: true},
+		{Key: KeyPocketBaseCollection, Env: "", Default: "lqd_tasks", Kind: KindString, Secret: false},
+		{Key: KeyDashboardPort, Env: "LQD_SERVE_PORT", Default: "8091", Kind: KindInt, Secret: false},
+		{Key: KeyBacklogConfigPage, Env: "", Default: "backlog", Kind: KindString, Secret: false},
+		{Key: KeyBacklogConfigFile, Env: "", Default: "", Kind: KindPath, Secret: false},
+		{Key: KeyBacklogWorkers, Env: "", Default: "4", Kind: KindInt, Secret: false},
+		{Key: KeyBacklogFillFocus, Env: "", Default: "false", Kind: KindBool, Secret: false},
+		{Key: KeyBacklogFocusPer, Env: "", Default: "2", Kind: KindInt, Secret: false},
+		{Key: KeyBacklogFocusLimit, Env: "", Default: "10", Kind: KindInt, Secret: false},
+		{Key: KeyBacklogFocusOverdueA, Env: "", Default: "true", Kind: KindBool, Secret: false},
+		{Key: KeyGroomOlderThan, Env: "", Default: "1 year", Kind: KindString, Secret: false},
+		{Key: KeyGroomLimit, Env: "", Default: "10", Kind: KindInt, Secret: false},
+		{Key: KeyTidyUpForbiddenRefs, Env: "", Default: "quick capture, inbox", Kind: KindList, Secret: false},
+		{Key: KeyUndoDir, Env: "LQD_UNDO_DIR", Default: DefaultUndoDir(), Kind: KindPath, Secret: false},
+		{Key: KeyUndoKeep, Env: "", Default: "100", Kind: KindInt, Secret: false},
+	}
+}
+
+// Lookup returns the setting for key.
+func Lookup(key string) (Setting, error) {
+	for _, setting := range Settings() {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+
+	return Setting{}, fmt.Errorf("%w: %s", ErrUnknownKey, key) //nolint:exhaustruct
+}
+
+// Default returns the default value of a known setting, or "" for an unknown key.
+func Default(key string) string {
+	setting, _ := Lookup(key)
+
+	return setting.Default
+}
+
+// DefaultPath returns the location of the config file: $LQD_CONFIG if set,
+// otherwise config.toml in $XDG_CONFIG_HOME/lqd or ~/.config/lqd.
+func DefaultPath() string {
+	if path := os.Getenv("LQD_CONFIG"); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "lqd", "config.toml")
+}
+
+// DefaultUndoDir returns where the undo journal is kept: lqd/undo in $XDG_STATE_HOME or ~/.local/state.
+func DefaultUndoDir() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "lqd", "undo")
+}
+
+// Config holds the values read from the config file.
+type Config struct {
+	path   string
+	values map[string]any
+
+	// raw is the text of the file, and setKeys the keys set since it was loaded; see Save.
+	raw     string
+	setKeys []setKey
+
+	// graph is the selected graph profile, if any; see SelectGraph.
+	graph       *Graph
+	graphSource Source
+}
+
+// Load reads the config file at path. A missing file is not an error: it yields an empty config
+// that can still be saved with Set and Save.
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path, values: make(map[string]any)}
+
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // the path is chosen by the user
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	_, err = toml.Decode(string(data), &cfg.values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	cfg.raw = string(data)
+
+	return cfg, nil
+}
+
+// Path returns the file the config was loaded from and will be saved to.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// DecodeSection decodes a whole section of the file (e.g. a table of tables) into target.
+func (c *Config) DecodeSection(section string, target any) error {
+	value, ok := c.values[section]
+	if !ok {
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	err := toml.NewEncoder(&buf).Encode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode section %s: %w", section, err)
+	}
+
+	_, err = toml.Decode(buf.String(), target)
+	if err != nil {
+		return fmt.Errorf("failed to decode section %s: %w", section, err)
+	}
+
+	return nil
+}
+
+// FileValue returns the value of key in the file, as text. Lists are joined with ", ".
+// Graph profile fields are read with "graphs.NAME.FIELD" keys.
+func (c *Config) FileValue(key string) (string, bool) {
+	if name, field, ok := SplitGraphKey(key); ok {
+		return c.graphFileValue(name, field)
+	}
+
+	section, name, _ := strings.Cut(key, ".")
+
+	table, ok := c.values[section].(map[string]any)
+	if !ok {
+		return "", false
+	}
+
+	value, ok := table[name]
+	if !ok {
+		return "", false
+	}
+
+	return formatValue(value), true
+}
+
+// Resolve returns the value of a setting from the environment, the file or its default, in this order,
+// together with where it came from. Flags are resolved by the caller, which knows about them.
+//
+// When a graph profile is selected, its values rank at the level it was selected from:
+// a profile chosen with --graph or LQD_GRAPH beats the env vars, a default profile from the file does not.
+func (c *Config) Resolve(key string) (string, Source) {
+	if value, ok := c.graph.value(key); ok && (c.graphSource != SourceFile || !envIsSet(key)) {
+		return value, SourceGraph
+	}
+
+	return c.resolveGlobal(key)
+}
+
+// resolveGlobal resolves a setting ignoring the selected graph profile.
+func (c *Config) resolveGlobal(key string) (string, Source) {
+	setting, err := Lookup(key)
+	if err != nil {
+		value, _ := c.FileValue(key)
+
+		return value, SourceFile
+	}
+
+	if setting.Env != "" {
+		if value := os.Getenv(setting.Env); value != "" {
+			return value, SourceEnv
+		}
+	}
+
+	if value, ok := c.FileValue(key); ok {
+		if setting.Kind == KindPath {
+			value = ExpandHome(value)
+		}
+
+		return value, SourceFile
+	}
+
+	return setting.Default, SourceDefault
+}
+
+// envIsSet reports whether the env var of a setting has a value.
+func envIsSet(key string) bool {
+	setting, err := Lookup(key)
+
+	return err == nil && setting.Env != "" && os.Getenv(setting.Env) != ""
+}
+
+// Get returns the resolved value of a setting, see Resolve.
+func (c *Config) Get(key string) string {
+	value, _ := c.Resolve(key)
+
+	return value
+}
+
+// GetList returns the resolved value of a list setting.
+func (c *Config) GetList(key string) []string {
+	return SplitList(c.Get(key))
+}
+
+// GetInt returns the resolved value of an integer setting.
+func (c *Config) GetInt(key string) (int, error) {
+	value := c.Get(key)
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s = %q is not a number", ErrInvalidValue, key, value)
+	}
+
+	return number, nil
+}
+
+// GetBool returns the resolved value of a boolean setting; invalid values are false.
+func (c *Config) GetBool(key string) bool {
+	enabled, _ := strconv.ParseBool(c.Get(key))
+
+	return enabled
+}
+
+// Set stores a value for a known setting or a graph profile field ("graphs.NAME.FIELD") in the config,
+// converted to the setting's kind.
+// Call Save to write it to the file.
+func (c *Config) Set(key, value string) error {
+	if name, field, ok := SplitGraphKey(key); ok {
+		c.setGraphValue(name, field, value)
+
+		return nil
+	}
+
+	setting, err := Lookup(key)
+	if err != nil {
+		return err
+	}
+
+	var typed any
+
+	//nolint:exhaustive // KindString and KindPath are stored as they are
+	switch setting.Kind {
+	case KindInt:
+		typed, err = strconv.Atoi(value)
+	case KindBool:
+		typed, err = strconv.ParseBool(value)
+	case KindList:
+		typed = SplitList(value)
+	default:
+		typed = value
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s = %q: %w", ErrInvalidValue, key, value, err)
+	}
+
+	section, name, _ := strings.Cut(key, ".")
+
+	table, ok := c.values[section].(map[string]any)
+	if !ok {
+		table = make(map[string]any)
+		c.values[section] = table
+	}
+
+	table[name] = typed
+	c.setKeys = append(c.setKeys, setKey{table: []string{section}, name: name})
+
+	return nil
+}
+
+// Save writes the config to its file, creating the directory if needed.
+// Only the lines of the keys set since it was loaded are rewritten: the comments and the order of the file
+// are kept, and new keys go at the end of their section (see encode for the files it cannot edit this way).
+// The file may hold API tokens and passwords, so it is only readable by the user.
+func (c *Config) Save() error {
+	err := os.MkdirAll(filepath.Dir(c.path), 0o700) //nolint:mnd
+	if err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := c.encode()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(c.path, data, 0o600) //nolint:mnd
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// ExpandHome replaces a leading "~" with the home directory of the user.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// SplitList splits a comma-separated list, trimming spaces and dropping empty items.
+func SplitList(value string) []string {
+	items := make([]string, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// formatValue renders a value decoded from TOML as text.
+func formatValue(value any) string {
+	switch typed := value.(type) {
+	case []any:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, formatValue(item))
+		}
+
+		return strings.Join(items, ", ")
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		return "{" + strings.Join(keys, ", ") + "}"
+	default:
+		return fmt.Sprint(typed)
+	}
+}
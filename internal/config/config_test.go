@@ -0,0 +1,286 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+)
+
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o600))
+
+	return path
+}
+
+func TestLoad_MissingFileIsEmpty(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.toml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "backlog", cfg.Get(config.KeyBacklogConfigPage))
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	_, err := config.Load(writeConfig(t, "[backlog\n"))
+	require.Error(t, err)
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	t.Setenv("LOGSEQ_GRAPH_PATH", "")
+	t.Setenv("POCKETBASE_URL", "")
+
+	cfg, err := config.Load(writeConfig(t, `
+[logseq]
+graph_path = "/from/file"
+
+[pocketbase]
+url = "http://file:8090"
+`))
+	require.NoError(t, err)
+
+	value, source := cfg.Resolve(config.KeyGraphPath)
+	assert.Equal(t, "/from/file", value)
+	assert.Equal(t, config.SourceFile, source)
+
+	t.Setenv("LOGSEQ_GRAPH_PATH", "/from/env")
+
+	value, source = cfg.Resolve(config.KeyGraphPath)
+	assert.Equal(t, "/from/env", value)
+	assert.Equal(t, config.SourceEnv, source)
+
+	value, source = cfg.Resolve(config.KeyGroomOlderThan)
+	assert.Equal(t, "1 year", value)
+	assert.Equal(t, config.SourceDefault, source)
+}
+
+func TestGetTypedValues(t *testing.T) {
+	t.Setenv("LQD_SERVE_PORT", "")
+	t.Setenv("LQD_OFFLINE", "")
+
+	cfg, err := config.Load(writeConfig(t, `
+[logseq]
+offline = true
+
+[dashboard]
+port = 9000
+
+[tidy-up]
+forbidden_refs = ["someday", "Read later"]
+`))
+	require.NoError(t, err)
+
+	port, err := cfg.GetInt(config.KeyDashboardPort)
+	require.NoError(t, err)
+	assert.Equal(t, 9000, port)
+	assert.True(t, cfg.GetBool(config.KeyOffline))
+	assert.Equal(t, []string{"someday", "Read later"}, cfg.GetList(config.KeyTidyUpForbiddenRefs))
+}
+
+func TestSetAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lqd", "config.toml")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.Set(config.KeyGroomLimit, "25"))
+	require.NoError(t, cfg.Set(config.KeyTidyUpForbiddenRefs, "inbox, someday"))
+	require.NoError(t, cfg.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reloaded, err := config.Load(path)
+	require.NoError(t, err)
+
+	limit, err := reloaded.GetInt(config.KeyGroomLimit)
+	require.NoError(t, err)
+	assert.Equal(t, 25, limit)
+	assert.Equal(t, []string{"inbox", "someday"}, reloaded.GetList(config.KeyTidyUpForbiddenRefs))
+}
+
+func TestSave_KeepsCommentsAndOrder(t *testing.T) {
+	path := writeConfig(t, `# lqd settings
+
+[tidy-up]
+# Pages that must not be referenced.
+forbidden_refs = [
+  "inbox", # the default one
+  "someday",
+]
+
+[logseq]
+  graph_path = "~/old" # my graph
+offline = true
+
+[graphs.work]
+path = "/graphs/work"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set(config.KeyGraphPath, "~/new"))
+	require.NoError(t, cfg.Set(config.KeyTidyUpForbiddenRefs, "inbox"))
+	require.NoError(t, cfg.Set(config.KeyHostURL, "http://localhost:12315"))
+	require.NoError(t, cfg.Set(config.KeyGroomLimit, "25"))
+	require.NoError(t, cfg.Set("graphs.work.api_token", "secret"))
+	require.NoError(t, cfg.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# lqd settings
+
+[tidy-up]
+# Pages that must not be referenced.
+forbidden_refs = ["inbox"]
+
+[logseq]
+  graph_path = "~/new" # my graph
+offline = true
+host_url = "http://localhost:12315"
+
+[graphs.work]
+path = "/graphs/work"
+api_token = "secret"
+
+[groom]
+limit = 25
+`, string(data))
+}
+
+func TestSave_InlineTableIsEncodedAgain(t *testing.T) {
+	path := writeConfig(t, `logseq = { graph_path = "~/old" } # cannot be edited in place
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set(config.KeyGraphPath, "~/new"))
+	require.NoError(t, cfg.Save())
+
+	reloaded, err := config.Load(path)
+	require.NoError(t, err)
+
+	value, ok := reloaded.FileValue(config.KeyGraphPath)
+	assert.True(t, ok)
+	assert.Equal(t, "~/new", value)
+}
+
+func TestSet_Errors(t *testing.T) {
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, cfg.Set("nope.key", "x"), config.ErrUnknownKey)
+	require.ErrorIs(t, cfg.Set(config.KeyGroomLimit, "many"), config.ErrInvalidValue)
+	require.ErrorIs(t, cfg.Set(config.KeyOffline, "maybe"), config.ErrInvalidValue)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("LQD_CONFIG", "/custom/lqd.toml")
+	assert.Equal(t, "/custom/lqd.toml", config.DefaultPath())
+
+	t.Setenv("LQD_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	assert.Equal(t, filepath.Join("/xdg", "lqd", "config.toml"), config.DefaultPath())
+}
+
+const graphsConfig = `
+[logseq]
+graph = "work"
+host_url = "http://global:12315"
+
+[graphs.work]
+path = "/graphs/work"
+pocketbase_collection = "work_tasks"
+
+[graphs.personal]
+path = "/graphs/personal"
+host_url = "http://personal:12315"
+backlog_config_page = "personal backlogs"
+`
+
+func TestGraphs(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, graphsConfig))
+	require.NoError(t, err)
+
+	graphs, err := cfg.Graphs()
+	require.NoError(t, err)
+	require.Len(t, graphs, 2)
+	assert.Equal(t, "personal", graphs[0].Name)
+	assert.Equal(t, "work", graphs[1].Name)
+
+	t.Setenv("LOGSEQ_HOST_URL", "")
+
+	work, err := cfg.Graph("work")
+	require.NoError(t, err)
+	assert.Equal(t, "http://global:12315", work.HostURL, "empty fields fall back to the global settings")
+	assert.Equal(t, "work_tasks", work.PocketBaseCollection)
+
+	personal, err := cfg.Graph("personal")
+	require.NoError(t, err)
+	assert.Equal(t, "lqd_tasks", personal.PocketBaseCollection)
+	assert.Equal(t, "personal backlogs", personal.BacklogConfigPage)
+	assert.Equal(t, "backlog", work.BacklogConfigPage)
+
+	_, err = cfg.Graph("nope")
+	require.ErrorIs(t, err, config.ErrUnknownGraph)
+}
+
+func TestSelectGraph_Precedence(t *testing.T) {
+	t.Setenv("LQD_GRAPH", "")
+	t.Setenv("LOGSEQ_GRAPH_PATH", "/from/env")
+	t.Setenv("LOGSEQ_HOST_URL", "")
+
+	cfg, err := config.Load(writeConfig(t, graphsConfig))
+	require.NoError(t, err)
+
+	// The default profile comes from the file, so env vars still win over it.
+	require.NoError(t, cfg.SelectDefaultGraph())
+	assert.Equal(t, "work", cfg.CurrentGraph().Name)
+	assert.Equal(t, "/from/env", cfg.Get(config.KeyGraphPath))
+	assert.Equal(t, "work_tasks", cfg.Get(config.KeyPocketBaseCollection))
+
+	// A profile chosen with --graph wins over env vars.
+	require.NoError(t, cfg.SelectGraph("personal", config.SourceFlag))
+	value, source := cfg.Resolve(config.KeyGraphPath)
+	assert.Equal(t, "/graphs/personal", value)
+	assert.Equal(t, config.SourceGraph, source)
+	assert.Equal(t, "http://personal:12315", cfg.Get(config.KeyHostURL))
+	assert.Equal(t, "personal backlogs", cfg.Get(config.KeyBacklogConfigPage))
+
+	require.ErrorIs(t, cfg.SelectGraph("nope", config.SourceFlag), config.ErrUnknownGraph)
+	require.NoError(t, cfg.SelectGraph("", ""))
+	assert.Empty(t, cfg.CurrentGraph().Name)
+}
+
+func TestSetGraphKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("graphs.work.path", "/graphs/work"))
+	require.NoError(t, cfg.Save())
+
+	reloaded, err := config.Load(path)
+	require.NoError(t, err)
+
+	value, ok := reloaded.FileValue("graphs.work.path")
+	assert.True(t, ok)
+	assert.Equal(t, "/graphs/work", value)
+
+	require.ErrorIs(t, cfg.Set("graphs.work.colour", "blue"), config.ErrUnknownKey)
+}
+
+func TestGraphs_InvalidName(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, "[graphs.\"it's mine\"]\npath = \"/x\"\n"))
+	require.NoError(t, err)
+
+	_, err = cfg.Graphs()
+	require.ErrorIs(t, err, config.ErrInvalidGraphName)
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// setKey is a key set since the config was loaded: Save only edits its line in the file.
+type setKey struct {
+	table []string // e.g. ["logseq"], or ["graphs", "work"] for a graph profile
+	name  string
+}
+
+// encode returns the contents of the config file with the keys set since it was loaded.
+// Only their lines are edited, so the comments and the order of the file are kept.
+// A file these edits cannot follow (e.g. a section written as an inline table or with dotted keys)
+// is encoded again as a whole, in the order of the encoder and without its comments.
+func (c *Config) encode() ([]byte, error) {
+	full, err := encodeTOML(c.values)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := c.raw
+
+	for _, key := range c.setKeys {
+		line, lineErr := encodeTOML(map[string]any{key.name: c.tableValue(key.table, key.name)})
+		if lineErr != nil {
+			return nil, lineErr
+		}
+
+		edited = setLine(edited, key.table, key.name, strings.TrimSuffix(line, "\n"))
+	}
+
+	// The edits must give the same values, e.g. the key was not set in an inline table too.
+	var decoded map[string]any
+
+	_, err = toml.Decode(edited, &decoded)
+	if err == nil {
+		same, encodeErr := encodeTOML(decoded)
+		if encodeErr == nil && same == full {
+			return []byte(edited), nil
+		}
+	}
+
+	return []byte(full), nil
+}
+
+// tableValue returns the value of a key in a table of the config, nested tables included.
+func (c *Config) tableValue(table []string, name string) any {
+	values := c.values
+
+	for _, part := range table {
+		values, _ = values[part].(map[string]any)
+	}
+
+	return values[name]
+}
+
+func encodeTOML(value any) (string, error) {
+	var buf bytes.Buffer
+
+	err := toml.NewEncoder(&buf).Encode(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// setLine replaces the line of a key in a table of a TOML document, keeping its indentation and its comment.
+// A missing key is added at the end of its table, and a missing table at the end of the document.
+func setLine(text string, table []string, name, line string) string {
+	lines := strings.SplitAfter(text, "\n")
+	header := strings.Join(table, ".")
+
+	start := slices.IndexFunc(lines, func(l string) bool { return tableHeader(l) == header })
+	if start < 0 {
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+
+		if text != "" {
+			text += "\n"
+		}
+
+		return text + "[" + header + "]\n" + line + "\n"
+	}
+
+	end := len(lines)
+
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
+			end = i
+
+			break
+		}
+	}
+
+	for i := start + 1; i < end; i++ {
+		if keyName(lines[i]) != name {
+			continue
+		}
+
+		last, comment := valueEnd(lines, i, end)
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+
+		if comment != "" {
+			comment = " " + comment
+		}
+
+		replaced := append(slices.Clone(lines[:i]), indent+line+comment+"\n")
+
+		return strings.Join(append(replaced, lines[last+1:]...), "")
+	}
+
+	// After the last line of the table, before the blank lines that separate it from the next one.
+	insert := end
+	for insert > start+1 && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+
+	if insert == len(lines) && !strings.HasSuffix(lines[insert-1], "\n") {
+		lines[insert-1] += "\n"
+	}
+
+	return strings.Join(slices.Insert(lines, insert, line+"\n"), "")
+}
+
+// tableHeader returns the name of the table a line starts, e.g. "graphs.work" for `[graphs."work"]`,
+// or "" if it starts none.
+func tableHeader(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "[[") {
+		return ""
+	}
+
+	name, _, ok := strings.Cut(trimmed[1:], "]")
+	if !ok {
+		return ""
+	}
+
+	return strings.NewReplacer(" ", "", "\t", "", `"`, "", "'", "").Replace(name)
+}
+
+// keyName returns the key a line sets, without quotes, or "" if it sets none.
+func keyName(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+
+	key, _, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return ""
+	}
+
+	return strings.Trim(strings.TrimSpace(key), `"'`)
+}
+
+// valueEnd scans the value of the key set on lines[start], which may span several lines (arrays, multi-line
+// strings), and returns the index of its last line and the comment that ends it, if any.
+func valueEnd(lines []string, start, end int) (int, string) {
+	var (
+		depth int
+		quote string // the delimiter of the string being scanned, if any
+	)
+
+	_, value, _ := strings.Cut(lines[start], "=")
+
+	for i := start; i < end; i++ {
+		text := strings.TrimRight(lines[i], "\r\n")
+		if i == start {
+			text = strings.TrimRight(value, "\r\n")
+		}
+
+		for j := 0; j < len(text); j++ {
+			switch {
+			case quote != "":
+				if text[j] == '\\' && quote[0] == '"' {
+					j++
+				} else if strings.HasPrefix(text[j:], quote) {
+					j += len(quote) - 1
+					quote = ""
+				}
+			case strings.HasPrefix(text[j:], `"""`) || strings.HasPrefix(text[j:], "'''"):
+				quote = text[j : j+3]
+				j += 2
+			case text[j] == '"' || text[j] == '\'':
+				quote = text[j : j+1]
+			case text[j] == '[' || text[j] == '{':
+				depth++
+			case text[j] == ']' || text[j] == '}':
+				depth--
+			case text[j] == '#':
+				if depth <= 0 {
+					return i, text[j:]
+				}
+
+				j = len(text)
+			}
+		}
+
+		// Single-quoted and double-quoted strings end with their line.
+		if len(quote) == 1 {
+			quote = ""
+		}
+
+		if quote == "" && depth <= 0 {
+			return i, ""
+		}
+	}
+
+	return end - 1, ""
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// graphsSection is the table holding the named graph profiles.
+const graphsSection = "graphs"
+
+// graphNameRe matches the allowed graph profile names.
+var graphNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Graph is a named graph profile, read from a [graphs.NAME] table:
+//
+//	[graphs.work]
+//	path = "~/logseq/work"
+//	host_url = "http://localhost:12315"
+//	api_token = "..."
+//	pocketbase_collection = "lqd_tasks"
+//	backlog_config_file = "~/logseq/work-backlogs.toml"
+//	backlog_config_page = "backlog"
+//
+// Empty fields fall back to the global settings (logseq.host_url, pocketbase.collection, backlog.config_file, ...).
+type Graph struct {
+	Name                 string `toml:"-"`
+	Path                 string `toml:"path"`
+	HostURL              string `toml:"host_url"`
+	APIToken             string `toml:"api_token"` //nolint:gosec // not a hard-coded credential
+	PocketBaseCollection string `toml:"pocketbase_collection"`
+	BacklogConfigFile    string `toml:"backlog_config_file"`
+	BacklogConfigPage    string `toml:"backlog_config_page"`
+}
+
+// graphFields maps the settings a profile overrides to the profile fields.
+func graphFields() map[string]string {
+	return map[string]string{
+		KeyGraphPath:            "path",
+		KeyHostURL:              "host_url",
+		KeyAPIToken:             "api_token",
+		KeyPocketBaseCollection: "pocketbase_collection",
+		KeyBacklogConfigFile:    "backlog_config_file",
+		KeyBacklogConfigPage:    "backlog_config_page",
+	}
+}
+
+// value returns the profile value that overrides a setting, if the profile has one.
+func (g *Graph) value(key string) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+
+	var value string
+
+	switch key {
+	case KeyGraphPath:
+		value = g.Path
+	case KeyHostURL:
+		value = g.HostURL
+	case KeyAPIToken:
+		value = g.APIToken
+	case KeyPocketBaseCollection:
+		value = g.PocketBaseCollection
+	case KeyBacklogConfigFile:
+		value = g.BacklogConfigFile
+	case KeyBacklogConfigPage:
+		value = g.BacklogConfigPage
+	}
+
+	return value, value != ""
+}
+
+// Graphs returns the graph profiles of the file, sorted by name, as they are written (no fallbacks applied).
+func (c *Config) Graphs() ([]Graph, error) {
+	profiles := make(map[string]Graph)
+
+	err := c.DecodeSection(graphsSection, &profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	graphs := make([]Graph, 0, len(profiles))
+
+	for name, graph := range profiles {
+		if !graphNameRe.MatchString(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidGraphName, name)
+		}
+
+		graph.Name = name
+		graph.Path = ExpandHome(graph.Path)
+		graph.BacklogConfigFile = ExpandHome(graph.BacklogConfigFile)
+		graphs = append(graphs, graph)
+	}
+
+	sort.Slice(graphs, func(i, j int) bool { return graphs[i].Name < graphs[j].Name })
+
+	return graphs, nil
+}
+
+// Graph returns the graph profile with the given name, with empty fields filled from the global settings.
+func (c *Config) Graph(name string) (Graph, error) {
+	graph, err := c.profile(name)
+	if err != nil {
+		return graph, err
+	}
+
+	for key, field := range graphFields() {
+		if _, ok := graph.value(key); !ok {
+			global, _ := c.resolveGlobal(key)
+			graph.set(field, global)
+		}
+	}
+
+	return graph, nil
+}
+
+// profile returns the graph profile with the given name as it is written in the file.
+func (c *Config) profile(name string) (Graph, error) {
+	graphs, err := c.Graphs()
+	if err != nil {
+		return Graph{}, err //nolint:exhaustruct
+	}
+
+	for _, graph := range graphs {
+		if graph.Name == name {
+			return graph, nil
+		}
+	}
+
+	return Graph{}, fmt.Errorf("%w: %q (add a [graphs.%s] table to %s)", ErrUnknownGraph, name, name, c.path) //nolint:exhaustruct,lll
+}
+
+// SplitGraphKey splits a "graphs.NAME.FIELD" key into the profile name and field.
+func SplitGraphKey(key string) (string, string, bool) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 || parts[0] != graphsSection { //nolint:mnd
+		return "", "", false
+	}
+
+	for _, field := range graphFields() {
+		if field == parts[2] {
+			return parts[1], parts[2], graphNameRe.MatchString(parts[1])
+		}
+	}
+
+	return "", "", false
+}
+
+// graphFileValue returns the value of a "graphs.NAME.FIELD" key in the file.
+func (c *Config) graphFileValue(name, field string) (string, bool) {
+	graphs, _ := c.values[graphsSection].(map[string]any)
+	profile, _ := graphs[name].(map[string]any)
+
+	value, ok := profile[field]
+	if !ok {
+		return "", false
+	}
+
+	return formatValue(value), true
+}
+
+// setGraphValue stores a profile field, creating the [graphs.NAME] table if needed.
+func (c *Config) setGraphValue(name, field, value string) {
+	graphs, ok := c.values[graphsSection].(map[string]any)
+	if !ok {
+		graphs = make(map[string]any)
+		c.values[graphsSection] = graphs
+	}
+
+	profile, ok := graphs[name].(map[string]any)
+	if !ok {
+		profile = make(map[string]any)
+		graphs[name] = profile
+	}
+
+	profile[field] = value
+	c.setKeys = append(c.setKeys, setKey{table: []string{graphsSection, name}, name: field})
+}
+
+// set assigns a profile field by its TOML name.
+func (g *Graph) set(field, value string) {
+	switch field {
+	case "path":
+		g.Path = value
+	case "host_url":
+		g.HostURL = value
+	case "api_token":
+		g.APIToken = value
+	case "pocketbase_collection":
+		g.PocketBaseCollection = value
+	case "backlog_config_file":
+		g.BacklogConfigFile = value
+	case "backlog_config_page":
+		g.BacklogConfigPage = value
+	}
+}
+
+// SelectGraph makes the profile the source of the graph settings (path, API URL and token, PocketBase collection,
+// backlog config).
+// source tells where the name came from, which decides whether env vars still win over the profile; see Resolve.
+// An empty name clears the selection.
+func (c *Config) SelectGraph(name string, source Source) error {
+	c.graph = nil
+	c.graphSource = ""
+
+	if name == "" {
+		return nil
+	}
+
+	graph, err := c.profile(name)
+	if err != nil {
+		return err
+	}
+
+	c.graph = &graph
+	c.graphSource = source
+
+	return nil
+}
+
+// SelectDefaultGraph selects the profile named by LQD_GRAPH or logseq.graph, if any.
+func (c *Config) SelectDefaultGraph() error {
+	name, source := c.resolveGlobal(KeyGraph)
+
+	return c.SelectGraph(name, source)
+}
+
+// CurrentGraph returns the selected profile, or an unnamed profile built from the global settings.
+func (c *Config) CurrentGraph() Graph {
+	name := ""
+	if c.graph != nil {
+		name = c.graph.Name
+	}
+
+	return Graph{
+		Name:                 name,
+		Path:                 c.Get(KeyGraphPath),
+		HostURL:              c.Get(KeyHostURL),
+		APIToken:             c.Get(KeyAPIToken),
+		PocketBaseCollection: c.Get(KeyPocketBaseCollection),
+		BacklogConfigFile:    c.Get(KeyBacklogConfigFile),
+		BacklogConfigPage:    c.Get(KeyBacklogConfigPage),
+	}
+}
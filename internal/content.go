@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-go"
 	"os"
 	"strings"
@@ -46,7 +47,7 @@ func AppendRawMarkdownToJournal(graph *logseq.Graph, date time.Time, //nolint:cy
 
 	_, err = os.Stat(path)
 	if err == nil {
-		bytes, readErr := os.ReadFile(path)
+		bytes, readErr := persist.ReadFile(path)
 		if readErr != nil {
 			return 0, fmt.Errorf("error reading journal file: %w", readErr)
 		}
@@ -65,32 +66,25 @@ func AppendRawMarkdownToJournal(graph *logseq.Graph, date time.Time, //nolint:cy
 		newline = "\r\n"
 	}
 
-	var flags int
-	if empty {
-		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
-	} else {
-		flags = os.O_WRONLY | os.O_APPEND
-
+	newContents := rawMarkdown
+	if !empty {
 		// Add a newline before the new content if the original content doesn't end with a newline.
 		if !strings.HasSuffix(originalContents, newline) {
 			rawMarkdown = newline + rawMarkdown
 		}
+
+		newContents = originalContents + rawMarkdown
 	}
 
 	const perm = 0644
 
-	file, err := os.OpenFile(path, flags, perm)
-	if err != nil {
-		return 0, fmt.Errorf("error opening journal file: %w", err)
-	}
-	defer file.Close()
-
-	size, err := file.WriteString(rawMarkdown)
+	// The whole file is written, so the change goes to the undo journal and is known as an own write.
+	err = persist.WriteFile(path, []byte(newContents), perm)
 	if err != nil {
 		return 0, fmt.Errorf("error writing journal file: %w", err)
 	}
 
-	return size, nil
+	return len(rawMarkdown), nil
 }
 
 // TODO: use and improve this function when appending tasks to the current journal.
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-doctor/internal/testutils"
 	"github.com/stretchr/testify/require"
 
@@ -97,3 +98,33 @@ func TestAppendRawMarkdownToJournal(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendRawMarkdownToJournal_RecordsTheWrite(t *testing.T) {
+	undoDir := filepath.Join(t.TempDir(), "undo")
+
+	persist.StartJournal(undoDir, 10, "lqd task add")
+	t.Cleanup(func() { persist.StartJournal("", 0, "") })
+
+	graph := testutils.NewStubGraph(t, "stub-graph")
+	date := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+
+	path, err := graph.JournalPath(date)
+	require.NoError(t, err)
+
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	_, err = internal.AppendRawMarkdownToJournal(graph, date, "- TODO Water the plants\n")
+	require.NoError(t, err)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, persist.OwnWrite(path), "known as an own write, so --watch ignores it")
+
+	runs, err := persist.Runs(undoDir)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Len(t, runs[0].Files, 1)
+	assert.Equal(t, string(before), runs[0].Files[0].Before)
+	assert.Equal(t, string(after), runs[0].Files[0].After)
+}
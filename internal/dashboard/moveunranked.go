@@ -9,17 +9,11 @@ import (
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 )
 
-func allSectionHeaders() []backlog.Header {
-	return []backlog.Header{
-		backlog.HeaderFocus, backlog.HeaderOverdue, backlog.HeaderNewTasks,
-		backlog.HeaderTriaged, backlog.HeaderScheduled, backlog.HeaderUnranked,
-	}
-}
-
 // MoveToUnranked moves the given task UUIDs from the regular area of backlogPage
-// to under the "🔢 Unranked tasks" section divider, creating the divider if absent.
+// to under the Unranked section divider (see backlog.Headers), creating the divider if absent.
 //
 // graphPath is the path to the Logseq graph root directory.
 // backlogPageName is the page name (e.g. "my-backlog", without .md extension).
@@ -30,7 +24,15 @@ func MoveToUnranked(graphPath, backlogPageName string, uuids []string) error {
 	}
 
 	graph := logseqapi.OpenGraphFromPath(graphPath)
-	transaction := graph.NewTransaction()
+
+	return persist.RetryOnConflict(func() error { //nolint:wrapcheck
+		return moveToUnranked(graph, backlogPageName, uuids)
+	})
+}
+
+// moveToUnranked opens the backlog page and moves the tasks.
+func moveToUnranked(graph *logseq.Graph, backlogPageName string, uuids []string) error {
+	transaction := persist.NewTransaction(graph)
 
 	page, err := transaction.OpenPage(backlogPageName)
 	if err != nil {
@@ -42,7 +44,7 @@ func MoveToUnranked(graphPath, backlogPageName string, uuids []string) error {
 		uuidSet[u] = true
 	}
 
-	unrankedDivider := logseqext.FindBlockContainingText(page, backlog.HeaderUnranked.Label)
+	unrankedDivider := backlog.FindSectionDivider(page, backlog.SectionNameUnranked)
 	toMove := collectBlocksToMove(page, uuidSet)
 
 	if len(toMove) == 0 {
@@ -98,17 +100,11 @@ func collectBlocksToMove(page logseq.Page, uuidSet map[string]bool) []*content.B
 	return toMove
 }
 
-// isSectionHeaderBlock reports whether block's text matches any known section header.
+// isSectionHeaderBlock reports whether block's text is an enabled section header.
 func isSectionHeaderBlock(block *content.Block) bool {
-	blockText := logseqext.BlockContentText(block)
-
-	for _, header := range allSectionHeaders() {
-		if header.Matches(blockText) {
-			return true
-		}
-	}
+	_, ok := backlog.Headers().Match(logseqext.BlockContentText(block))
 
-	return false
+	return ok
 }
 
 // ensureUnrankedDivider returns the existing divider block, or creates and inserts one.
@@ -117,8 +113,10 @@ func ensureUnrankedDivider(page logseq.Page, existing *content.Block) *content.B
 		return existing
 	}
 
-	dividerBlock := content.NewBlock(content.NewParagraph(content.NewText(backlog.HeaderUnranked.String())))
-	scheduledDivider := logseqext.FindBlockContainingText(page, backlog.HeaderScheduled.Label)
+	registry := backlog.Headers()
+	dividerBlock := content.NewBlock(content.NewParagraph(content.NewText(
+		registry.Get(backlog.SectionNameUnranked).String())))
+	scheduledDivider := backlog.FindSectionDivider(page, backlog.SectionNameScheduled)
 
 	if scheduledDivider != nil {
 		page.InsertBlockBefore(dividerBlock, scheduledDivider)
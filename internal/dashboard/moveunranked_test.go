@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
 	"github.com/andreoliwa/logseq-doctor/internal/dashboard"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -133,3 +134,29 @@ func TestMoveToUnrankedPreservesExistingDivider(t *testing.T) {
 	assert.Contains(t, resultStr, uuid2)
 	assert.Contains(t, resultStr, uuid3)
 }
+
+func TestMoveToUnrankedUsesConfiguredHeader(t *testing.T) {
+	registry, err := backlog.NewHeaderRegistry(map[string]backlog.HeaderSettings{
+		backlog.SectionNameUnranked: {Emoji: "🔽", Label: "Ohne Rang"},
+	})
+	require.NoError(t, err)
+
+	backlog.SetHeaders(registry)
+	t.Cleanup(func() { backlog.SetHeaders(nil) })
+
+	// A block that only mentions the label is not the divider.
+	pageContent := "- ((" + uuid1 + "))\n- Ohne Rang tasks are sorted later\n- ((" + uuid2 + "))\n"
+	graphDir := makeTestGraph(t, pageContent)
+
+	err = dashboard.MoveToUnranked(graphDir, testBacklogPage, []string{uuid2})
+	require.NoError(t, err)
+
+	result, err := os.ReadFile(filepath.Join(graphDir, "pages", testBacklogPage+".md"))
+	require.NoError(t, err)
+
+	resultStr := string(result)
+
+	assert.Contains(t, resultStr, "🔽 Ohne Rang tasks", "the configured divider should be created")
+	assert.Less(t, strings.Index(resultStr, "🔽 Ohne Rang tasks"), strings.Index(resultStr, uuid2))
+	assert.Contains(t, resultStr, "- Ohne Rang tasks are sorted later")
+}
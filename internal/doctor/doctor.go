@@ -0,0 +1,269 @@
+// Package doctor checks the lqd setup: the graph, the Logseq API, PocketBase and the backlog config.
+// Each check returns a Result instead of failing, so every problem is reported in one run.
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/pocketbase"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip is used when a check cannot run because an earlier one failed.
+	StatusSkip Status = "skip"
+)
+
+// Check names, as shown in the report.
+const (
+	CheckGraph            = "graph"
+	CheckJournalTitles    = "journal titles"
+	CheckJournalFiles     = "journal files"
+	CheckLogseqAPI        = "logseq api"
+	CheckPocketBase       = "pocketbase"
+	CheckPocketBaseAuth   = "pocketbase auth"
+	CheckPocketBaseSchema = "pocketbase schema"
+	CheckBacklogConfig    = "backlog config"
+)
+
+// journalFileNameFormat is the only journal file name format lqd reads, see logseqext.JournalDateFromFileName.
+const journalFileNameFormat = "yyyy_MM_dd"
+
+// Result is the outcome of one check.
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Failed tells if one of the results is a failure.
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Skip returns skipped results for checks that depend on a failed one.
+func Skip(reason string, checks ...string) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, Result{Check: check, Status: StatusSkip, Message: reason})
+	}
+
+	return results
+}
+
+// Graph checks that the graph directory exists and has a logseq/config.edn file.
+func Graph(path string) Result {
+	if path == "" {
+		return Result{CheckGraph, StatusFail, "no graph path: set LOGSEQ_GRAPH_PATH or logseq.graph_path in the config file"}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return Result{CheckGraph, StatusFail, path + " is not a directory"}
+	}
+
+	_, err = os.Stat(filepath.Join(path, "logseq", "config.edn"))
+	if err != nil {
+		return Result{CheckGraph, StatusFail, path + " has no logseq/config.edn: is it a Logseq graph?"}
+	}
+
+	return Result{CheckGraph, StatusOK, path}
+}
+
+// JournalFormats checks that the journal title and file name formats of the graph can be parsed.
+// A title format other than the one lqd writes dates with is a warning: those dates won't link to journals.
+func JournalFormats(graphPath string, date time.Time) []Result {
+	titleFormat := logseqext.ReadJournalTitleFormat(graphPath)
+	title := logseqext.FormatJournalTitle(date, titleFormat)
+
+	var titles Result
+
+	parsed, err := logseqext.ParseJournalTitle(title, titleFormat)
+
+	switch {
+	case err != nil || !sameDay(parsed, date):
+		titles = Result{CheckJournalTitles, StatusFail, fmt.Sprintf("cannot parse journal titles in format %q", titleFormat)}
+	case "[["+title+"]]" != logseqext.FormatLogseqDate(date):
+		titles = Result{CheckJournalTitles, StatusWarn, fmt.Sprintf(
+			"journals are titled %q, but lqd writes dates as %s: they won't link to journals",
+			title, logseqext.FormatLogseqDate(date))}
+	default:
+		titles = Result{CheckJournalTitles, StatusOK, fmt.Sprintf("%q, e.g. %s", titleFormat, title)}
+	}
+
+	return []Result{titles, journalFiles(graphPath)}
+}
+
+// journalFiles checks the journal file name format, and that the journal files follow it.
+func journalFiles(graphPath string) Result {
+	fileFormat := logseqext.ReadJournalFileNameFormat(graphPath)
+	if fileFormat != journalFileNameFormat {
+		return Result{CheckJournalFiles, StatusFail, fmt.Sprintf(
+			"journal file names in format %q are not supported, only %q", fileFormat, journalFileNameFormat)}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(graphPath, "journals"))
+	if err != nil {
+		return Result{CheckJournalFiles, StatusWarn, "no journals directory"}
+	}
+
+	var invalid []string
+
+	valid := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+
+		if _, ok := logseqext.JournalDateFromFileName(entry.Name()); ok {
+			valid++
+		} else {
+			invalid = append(invalid, entry.Name())
+		}
+	}
+
+	if len(invalid) > 0 {
+		return Result{CheckJournalFiles, StatusWarn, fmt.Sprintf(
+			"%d file(s) not named %s.md are ignored, e.g. %s", len(invalid), journalFileNameFormat, invalid[0])}
+	}
+
+	return Result{CheckJournalFiles, StatusOK, fmt.Sprintf("%d journal(s) named %s.md", valid, fileFormat)}
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// LogseqAPI checks that the Logseq HTTP API answers a query with the configured token.
+func LogseqAPI(api logseqapi.LogseqAPI, hostURL string) Result {
+	_, err := api.PostDatascriptQuery("[:find (count ?p) :where [?p :block/name]]")
+
+	switch {
+	case err == nil:
+		return Result{CheckLogseqAPI, StatusOK, hostURL}
+	case errors.Is(err, logseqapi.ErrMissingConfig):
+		return Result{CheckLogseqAPI, StatusFail, err.Error()}
+	case errors.Is(err, logseqapi.ErrInvalidResponseStatus):
+		return Result{CheckLogseqAPI, StatusFail, fmt.Sprintf("%s: check LOGSEQ_API_TOKEN", err)}
+	default:
+		return Result{CheckLogseqAPI, StatusFail, fmt.Sprintf(
+			"%s: is Logseq running with the HTTP APIs server on %s?", err, hostURL)}
+	}
+}
+
+// PocketBase checks that PocketBase is up, that the credentials work,
+// and that the tasks collection has the fields of pocketbase.TasksSchema.
+func PocketBase(url, username, password, collection string) []Result {
+	checks := []string{CheckPocketBaseAuth, CheckPocketBaseSchema}
+
+	if !pocketbase.IsReady(url + "/api/health") {
+		return append([]Result{{CheckPocketBase, StatusFail,
+			fmt.Sprintf("no answer from %s/api/health: start it with 'pocketbase serve'", url)}},
+			Skip("PocketBase is not running", checks...)...)
+	}
+
+	results := []Result{{CheckPocketBase, StatusOK, url}}
+
+	client, err := pocketbase.NewClient(url, username, password)
+	if err != nil {
+		return append(append(results, Result{CheckPocketBaseAuth, StatusFail, err.Error()}),
+			Skip("not authenticated", CheckPocketBaseSchema)...)
+	}
+
+	results = append(results, Result{CheckPocketBaseAuth, StatusOK, "logged in as " + username})
+
+	return append(results, schema(client, collection))
+}
+
+func schema(client *pocketbase.Client, collection string) Result {
+	fields, exists, err := client.CollectionFields(collection)
+	if err != nil {
+		return Result{CheckPocketBaseSchema, StatusFail, err.Error()}
+	}
+
+	if !exists {
+		return Result{CheckPocketBaseSchema, StatusFail, fmt.Sprintf(
+			"collection %s not found: run 'lqd sync --init' to create it", collection)}
+	}
+
+	tasksSchema := pocketbase.TasksSchema(collection)
+
+	mismatches := pocketbase.SchemaMismatches(tasksSchema, fields)
+	if len(mismatches) > 0 && len(mismatches) == len(pocketbase.MissingFields(tasksSchema, fields)) {
+		return Result{CheckPocketBaseSchema, StatusFail, fmt.Sprintf(
+			"collection %s: %s; run 'lqd sync' to add them", collection, strings.Join(mismatches, ", "))}
+	}
+
+	if len(mismatches) > 0 {
+		return Result{CheckPocketBaseSchema, StatusFail, fmt.Sprintf(
+			"collection %s: %s; run 'lqd sync --init' to recreate it", collection, strings.Join(mismatches, ", "))}
+	}
+
+	return Result{CheckPocketBaseSchema, StatusOK, "collection " + collection}
+}
+
+// BacklogConfig checks that the backlog config lists at least one backlog.
+// Without a config file, the backlogs are read from the config page, which must exist.
+func BacklogConfig(graphPath, configPage, configFile string, reader backlog.ConfigReader) Result {
+	if configFile == "" && !pageExists(graphPath, configPage) {
+		return Result{CheckBacklogConfig, StatusFail, fmt.Sprintf("page %q not found in the graph", configPage)}
+	}
+
+	config, err := reader.ReadConfig()
+	if err != nil {
+		return Result{CheckBacklogConfig, StatusFail, err.Error()}
+	}
+
+	if len(config.Backlogs) == 0 && configFile != "" {
+		return Result{CheckBacklogConfig, StatusFail, "no backlogs declared in " + configFile}
+	}
+
+	if len(config.Backlogs) == 0 {
+		return Result{CheckBacklogConfig, StatusFail, fmt.Sprintf(
+			"no pages found in the backlog page %q: add a block with page references or tags for each backlog",
+			configPage)}
+	}
+
+	names := make([]string, 0, len(config.Backlogs))
+	for _, single := range config.Backlogs {
+		names = append(names, single.BacklogPage)
+	}
+
+	return Result{CheckBacklogConfig, StatusOK, fmt.Sprintf("%d backlog(s): %s", len(names), strings.Join(names, ", "))}
+}
+
+// pageExists tells if a page has a file in the graph. Titles are matched case-insensitively, like Logseq does.
+func pageExists(graphPath, title string) bool {
+	entries, err := os.ReadDir(filepath.Join(graphPath, "pages"))
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if strings.EqualFold(logseqext.PageTitleFromFileName(entry.Name()), title) {
+			return true
+		}
+	}
+
+	return false
+}
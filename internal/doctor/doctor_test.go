@@ -0,0 +1,185 @@
+package doctor_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
+	"github.com/andreoliwa/logseq-doctor/internal/backlog"
+	"github.com/andreoliwa/logseq-doctor/internal/doctor"
+)
+
+func newGraphDir(t *testing.T, configEDN string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logseq"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "journals"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pages"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), []byte(configEDN), 0o600))
+
+	return dir
+}
+
+func TestGraph(t *testing.T) {
+	assert.Equal(t, doctor.StatusFail, doctor.Graph("").Status)
+	assert.Equal(t, doctor.StatusFail, doctor.Graph(filepath.Join(t.TempDir(), "missing")).Status)
+
+	result := doctor.Graph(t.TempDir())
+	assert.Equal(t, doctor.StatusFail, result.Status)
+	assert.Contains(t, result.Message, "has no logseq/config.edn")
+
+	dir := newGraphDir(t, "{}")
+	assert.Equal(t, doctor.Result{Check: doctor.CheckGraph, Status: doctor.StatusOK, Message: dir}, doctor.Graph(dir))
+}
+
+func TestJournalFormats(t *testing.T) {
+	date := time.Date(2026, time.March, 21, 0, 0, 0, 0, time.UTC)
+
+	t.Run("the format lqd writes dates with", func(t *testing.T) {
+		dir := newGraphDir(t, `{:journal/page-title-format "EEEE, dd.MM.yyyy"}`)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "journals", "2026_03_21.md"), nil, 0o600))
+
+		results := doctor.JournalFormats(dir, date)
+		assert.Equal(t, []doctor.Result{
+			{
+				Check: doctor.CheckJournalTitles, Status: doctor.StatusOK,
+				Message: `"EEEE, dd.MM.yyyy", e.g. Saturday, 21.03.2026`,
+			},
+			{Check: doctor.CheckJournalFiles, Status: doctor.StatusOK, Message: "1 journal(s) named yyyy_MM_dd.md"},
+		}, results)
+	})
+
+	t.Run("another title format", func(t *testing.T) {
+		dir := newGraphDir(t, `{:journal/page-title-format "MMM do, yyyy"}`)
+
+		results := doctor.JournalFormats(dir, date)
+		assert.Equal(t, doctor.StatusWarn, results[0].Status)
+		assert.Contains(t, results[0].Message, `journals are titled "Mar 21st, 2026"`)
+	})
+
+	t.Run("unsupported file name format and stray files", func(t *testing.T) {
+		dir := newGraphDir(t, `{:journal/file-name-format "yyyy-MM-dd"}`)
+		assert.Equal(t, doctor.StatusFail, doctor.JournalFormats(dir, date)[1].Status)
+
+		dir = newGraphDir(t, `{}`)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "journals", "notes.md"), nil, 0o600))
+
+		result := doctor.JournalFormats(dir, date)[1]
+		assert.Equal(t, doctor.StatusWarn, result.Status)
+		assert.Contains(t, result.Message, "e.g. notes.md")
+	})
+}
+
+type fakeAPI struct {
+	logseqapi.LogseqAPI
+
+	err error
+}
+
+func (f *fakeAPI) PostDatascriptQuery(string) (string, error) {
+	return "[[1]]", f.err
+}
+
+func TestLogseqAPI(t *testing.T) {
+	assert.Equal(t, doctor.StatusOK, doctor.LogseqAPI(&fakeAPI{}, "http://localhost:12315").Status) //nolint:exhaustruct
+
+	result := doctor.LogseqAPI(&fakeAPI{err: logseqapi.ErrMissingConfig}, "") //nolint:exhaustruct
+	assert.Equal(t, doctor.StatusFail, result.Status)
+
+	result = doctor.LogseqAPI(&fakeAPI{ //nolint:exhaustruct
+		err: fmt.Errorf("status 401 Unauthorized: %w", logseqapi.ErrInvalidResponseStatus),
+	}, "")
+	assert.Contains(t, result.Message, "check LOGSEQ_API_TOKEN")
+}
+
+func TestPocketBase(t *testing.T) {
+	handler := func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api/health":
+			writer.WriteHeader(http.StatusOK)
+		case "/api/collections/_superusers/auth-with-password":
+			assert.NoError(t, json.NewEncoder(writer).Encode(map[string]string{"token": "token"}))
+		case "/api/collections/lqd_tasks":
+			_, err := writer.Write([]byte(`{"fields":[{"name":"name","type":"text"}]}`))
+			assert.NoError(t, err)
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	results := doctor.PocketBase(server.URL, "admin@example.com", "secret", "lqd_tasks")
+	require.Len(t, results, 3)
+	assert.Equal(t, doctor.StatusOK, results[0].Status)
+	assert.Equal(t, doctor.StatusOK, results[1].Status)
+	assert.Equal(t, doctor.StatusFail, results[2].Status)
+	assert.Contains(t, results[2].Message, "missing field status")
+	assert.Contains(t, results[2].Message, "run 'lqd sync' to add them")
+
+	results = doctor.PocketBase(server.URL, "admin@example.com", "secret", "other_tasks")
+	assert.Contains(t, results[2].Message, "collection other_tasks not found")
+
+	server.Close()
+
+	results = doctor.PocketBase(server.URL, "admin@example.com", "secret", "lqd_tasks")
+	assert.Equal(t, doctor.StatusFail, results[0].Status)
+	assert.Equal(t, doctor.StatusSkip, results[1].Status)
+	assert.Equal(t, doctor.StatusSkip, results[2].Status)
+}
+
+type fakeReader struct {
+	config *backlog.Config
+	err    error
+}
+
+func (f *fakeReader) ReadConfig() (*backlog.Config, error) {
+	return f.config, f.err
+}
+
+func TestBacklogConfig(t *testing.T) {
+	dir := newGraphDir(t, "{}")
+
+	result := doctor.BacklogConfig(dir, "backlog", "", &fakeReader{}) //nolint:exhaustruct
+	assert.Equal(t, doctor.StatusFail, result.Status)
+	assert.Contains(t, result.Message, `page "backlog" not found`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pages", "Backlog.md"), []byte("- [[work]]\n"), 0o600))
+
+	result = doctor.BacklogConfig(dir, "backlog", "", &fakeReader{config: &backlog.Config{}}) //nolint:exhaustruct
+	assert.Equal(t, doctor.StatusFail, result.Status)
+	assert.Contains(t, result.Message, "no pages found")
+
+	result = doctor.BacklogConfig(dir, "backlog", "", &fakeReader{config: nil, err: errors.New("boom")}) //nolint:err113
+	assert.Equal(t, doctor.Result{Check: doctor.CheckBacklogConfig, Status: doctor.StatusFail, Message: "boom"}, result)
+
+	result = doctor.BacklogConfig(dir, "backlog", "", &fakeReader{ //nolint:exhaustruct
+		config: &backlog.Config{FocusPage: "backlog/Focus", Backlogs: []backlog.SingleBacklogConfig{
+			{BacklogPage: "backlog/work", Icon: "", InputPages: []string{"work"}},
+		}},
+	})
+	assert.Equal(t, doctor.Result{
+		Check: doctor.CheckBacklogConfig, Status: doctor.StatusOK, Message: "1 backlog(s): backlog/work",
+	}, result)
+}
+
+func TestBacklogConfig_configFile(t *testing.T) {
+	dir := newGraphDir(t, "{}")
+
+	result := doctor.BacklogConfig(dir, "backlog", "backlogs.toml", &fakeReader{config: &backlog.Config{}}) //nolint:exhaustruct
+	assert.Equal(t, doctor.Result{
+		Check: doctor.CheckBacklogConfig, Status: doctor.StatusFail, Message: "no backlogs declared in backlogs.toml",
+	}, result)
+}
@@ -14,6 +14,7 @@ import (
 	logseqapi "github.com/andreoliwa/logseq-doctor/internal/api"
 	"github.com/andreoliwa/logseq-doctor/internal/backlog"
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 )
 
 const reGroomDays = 90
@@ -259,8 +260,9 @@ func FormatGroomSummary(counts Counts, remaining int, olderThan string) string {
 // WriteOpts holds config-derived values needed for write-back operations.
 // These are passed from cmd/ to avoid circular imports with internal/backlog.
 type WriteOpts struct {
-	FocusPageTitle       string
-	BacklogPageTitle     string
+	FocusPageTitle   string
+	BacklogPageTitle string
+	// TriagedSectionText is the label of the Triaged section; empty when the section is disabled.
 	TriagedSectionText   string
 	ScheduledSectionText string
 	CurrentTime          func() time.Time
@@ -291,7 +293,7 @@ func EnsureBlockOnDisk(graph *logseq.Graph, groomAPI logseqapi.LogseqAPI, task m
 		return false, false
 	}
 
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 
 	page, err := logseqapi.OpenPageForBlock(transaction, blockInfo)
 	if err != nil {
@@ -313,7 +315,7 @@ func EnsureBlockOnDisk(graph *logseq.Graph, groomAPI logseqapi.LogseqAPI, task m
 	}
 
 	// Re-open the page to pick up the freshly-written file.
-	transaction2 := graph.NewTransaction()
+	transaction2 := persist.NewTransaction(graph)
 
 	page2, err := logseqapi.OpenPageForBlock(transaction2, blockInfo)
 	if err != nil {
@@ -340,7 +342,18 @@ func ApplyGroomAction(
 		return fmt.Errorf("failed to find block %s: %w", uuid, err)
 	}
 
-	transaction := graph.NewTransaction()
+	// The page may be edited in Logseq while the user is choosing an action: apply it again on the new contents.
+	return persist.RetryOnConflict(func() error { //nolint:wrapcheck
+		return applyGroomActionToPage(graph, blockInfo, action, uuid, groomedDate, opts)
+	})
+}
+
+// applyGroomActionToPage opens the page of the block and applies a groom action to it.
+func applyGroomActionToPage(
+	graph *logseq.Graph, blockInfo *logseqapi.BlockQueryInfo, action *Action,
+	uuid, groomedDate string, opts *WriteOpts,
+) error {
+	transaction := persist.NewTransaction(graph)
 
 	page, err := logseqapi.OpenPageForBlock(transaction, blockInfo)
 	if err != nil {
@@ -367,7 +380,7 @@ func ApplyGroomAction(
 
 // applyActionToBlock applies the specific groom action to a block.
 func applyActionToBlock(
-	transaction *logseq.Transaction, action *Action,
+	transaction *persist.Transaction, action *Action,
 	block *content.Block, groomedDate, uuid string, opts *WriteOpts,
 ) error {
 	switch action.Name {
@@ -396,7 +409,7 @@ func applyCancelAction(block *content.Block, cancelTime time.Time) error {
 
 // applyFocusAction marks the block groomed and adds a reference to the Focus page.
 func applyFocusAction(
-	transaction *logseq.Transaction, block *content.Block,
+	transaction *persist.Transaction, block *content.Block,
 	groomedDate, uuid, focusPageTitle string,
 ) error {
 	logseqext.BlockProperties(block).Set(GroomPropertyGroomed, content.NewText(groomedDate))
@@ -410,9 +423,10 @@ func applyFocusAction(
 }
 
 // applyPriorityAction sets priority on the block, marks it groomed,
-// and adds a reference to the Triaged section of the backlog page (if the task has a backlog).
+// and adds a reference to the Triaged section of the backlog page (if the task has a backlog and the section
+// is enabled).
 func applyPriorityAction(
-	transaction *logseq.Transaction, block *content.Block,
+	transaction *persist.Transaction, block *content.Block,
 	groomedDate, uuid string, priority content.PriorityValue, opts *WriteOpts,
 ) error {
 	priorityErr := logseqext.SetPriority(block, priority)
@@ -422,7 +436,7 @@ func applyPriorityAction(
 
 	logseqext.BlockProperties(block).Set(GroomPropertyGroomed, content.NewText(groomedDate))
 
-	if opts.BacklogPageTitle != "" {
+	if opts.BacklogPageTitle != "" && opts.TriagedSectionText != "" {
 		triagedErr := backlog.MoveBlockRefToTriagedSection(
 			transaction, opts.BacklogPageTitle, uuid, opts.TriagedSectionText, opts.ScheduledSectionText,
 		)
@@ -14,8 +14,8 @@ import (
 	"github.com/andreoliwa/logseq-go/content"
 )
 
-// ErrNoParagraph is returned when SetPriority is called on a block with no paragraph or heading.
-var ErrNoParagraph = errors.New("block has no paragraph to insert priority into")
+// ErrNoParagraph is returned when SetPriority or SetTaskDate is called on a block with no paragraph or heading.
+var ErrNoParagraph = errors.New("block has no paragraph to insert into")
 
 // JournalDayDivisorYear is used to extract the year from a journalDay integer (YYYYMMDD).
 const JournalDayDivisorYear = 10000
@@ -86,6 +86,60 @@ func blockHasIDProperty(block *content.Block, uuid string) bool {
 	return found
 }
 
+// BlockPropertyText returns the text of a property of a block, e.g. "💼" for "icon:: 💼",
+// or an empty string if the block doesn't have it. Like blockHasIDProperty, it searches the content nodes,
+// without creating a Properties node as block.Properties() would.
+func BlockPropertyText(block *content.Block, name string) string {
+	var text strings.Builder
+
+	block.Content().FindDeep(func(node content.Node) bool {
+		props, ok := node.(*content.Properties)
+		if !ok {
+			return false
+		}
+
+		for _, value := range props.Get(name) {
+			if t, ok := value.(*content.Text); ok {
+				text.WriteString(t.Value)
+			}
+		}
+
+		return text.Len() > 0
+	})
+
+	return strings.TrimSpace(text.String())
+}
+
+// BlockPropertyDate returns the date in a block property written with FormatLogseqDate, like cancelled::.
+// The parser reads the [[date]] back as a page link, not as text. It returns the zero time without a date.
+func BlockPropertyDate(block *content.Block, name string) time.Time {
+	var date time.Time
+
+	block.Content().FindDeep(func(node content.Node) bool {
+		props, ok := node.(*content.Properties)
+		if !ok {
+			return false
+		}
+
+		for _, value := range props.Get(name) {
+			switch typedValue := value.(type) {
+			case *content.PageLink:
+				date, _ = ParseLogseqDate(typedValue.To)
+			case *content.Text:
+				date, _ = ParseLogseqDate(strings.TrimSpace(typedValue.Value))
+			}
+
+			if !date.IsZero() {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	return date
+}
+
 // BlockContentText extracts the text content from a block's content nodes.
 func BlockContentText(block *content.Block) string {
 	var text string
@@ -218,6 +272,76 @@ func SetTaskTodo(block *content.Block) error {
 	return nil
 }
 
+// SetTaskStatus changes the task marker to any status, e.g. DONE or NOW.
+func SetTaskStatus(block *content.Block, status content.TaskStatus) error {
+	taskMarker := findTaskMarker(block)
+
+	if taskMarker == nil {
+		replaceHeadingTaskKeyword(block, status.String())
+
+		return nil
+	}
+
+	_, err := taskMarker.WithStatus(status)
+	if err != nil {
+		return fmt.Errorf("failed to change task status to %s: %w", status.String(), err)
+	}
+
+	return nil
+}
+
+// CompleteTask marks a task DONE. A task with a repeating SCHEDULED or DEADLINE date stays open instead:
+// its repeating dates move to their next occurrence, and DOING or NOW go back to TODO or LATER, like Logseq does.
+// It tells if the task repeats.
+func CompleteTask(block *content.Block, today time.Time) (bool, error) {
+	repeated := false
+
+	for _, node := range block.Content() {
+		var container content.HasChildren
+
+		switch typedNode := node.(type) {
+		case *content.Paragraph:
+			container = typedNode
+		case *content.Heading:
+			container = typedNode
+		default:
+			continue
+		}
+
+		for _, child := range container.Children() {
+			text, ok := child.(*content.Text)
+			if !ok {
+				continue
+			}
+
+			for _, found := range FindTaskDates(text.Value) {
+				if found.Repeater.IsZero() {
+					continue
+				}
+
+				next := found.Keyword + ": " + FormatRepeatingTaskDate(found.Repeater.Next(found.Date, today), found.Repeater)
+				text.Value = strings.Replace(text.Value, found.Text, next, 1)
+				repeated = true
+			}
+		}
+	}
+
+	if !repeated {
+		return false, SetTaskStatus(block, content.TaskStatusDone)
+	}
+
+	if marker := findTaskMarker(block); marker != nil {
+		switch marker.Status { //nolint:exhaustive // other statuses stay as they are
+		case content.TaskStatusDoing:
+			return true, SetTaskStatus(block, content.TaskStatusTodo)
+		case content.TaskStatusNow:
+			return true, SetTaskStatus(block, content.TaskStatusLater)
+		}
+	}
+
+	return true, nil
+}
+
 // SetPriority sets or replaces the priority marker ([#A]/[#B]/[#C]) on a block.
 // If a Priority node exists, it is updated in place. Otherwise, a new Priority node
 // is inserted after the TaskMarker (or at the start of the first paragraph or heading).
@@ -301,6 +425,65 @@ func insertPriority(
 	return nil
 }
 
+// SetTaskDate sets the SCHEDULED or DEADLINE date of a task (keyword is TaskDateScheduled or TaskDateDeadline),
+// replacing the current one in place and keeping its repeater. A new date gets a line of its own below the first
+// line of the task, after the other task date if there is one, like Logseq writes them.
+func SetTaskDate(block *content.Block, keyword string, date time.Time) error {
+	line := keyword + ": " + FormatTaskDate(date)
+
+	var firstContainer, lastDateLine content.Node
+
+	for _, node := range block.Content() {
+		var container content.HasChildren
+
+		switch typedNode := node.(type) {
+		case *content.Paragraph:
+			container = typedNode
+		case *content.Heading:
+			container = typedNode
+		default:
+			continue
+		}
+
+		if firstContainer == nil {
+			firstContainer = node
+		}
+
+		for _, child := range container.Children() {
+			text, ok := child.(*content.Text)
+			if !ok {
+				continue
+			}
+
+			for _, found := range FindTaskDates(text.Value) {
+				if found.Keyword == keyword {
+					line = keyword + ": " + FormatRepeatingTaskDate(date, found.Repeater)
+					text.Value = strings.Replace(text.Value, found.Text, line, 1)
+
+					return nil
+				}
+
+				if node != firstContainer {
+					lastDateLine = node
+				}
+			}
+		}
+	}
+
+	if firstContainer == nil {
+		return ErrNoParagraph
+	}
+
+	after := firstContainer
+	if lastDateLine != nil {
+		after = lastDateLine
+	}
+
+	block.InsertChildAfter(content.NewParagraph(content.NewText(line)), after)
+
+	return nil
+}
+
 // priorityRegex matches Logseq priority markers like [#A], [#B], [#C] in content strings.
 var priorityRegex = regexp.MustCompile(`\[#([ABC])\]`)
 
@@ -3,6 +3,7 @@ package logseqext_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
 	"github.com/andreoliwa/logseq-doctor/internal/testutils"
@@ -102,6 +103,107 @@ func TestSetTaskTodo_HeadingBlock(t *testing.T) {
 	assert.Equal(t, "#### TODO 1. Fix the config", strings.TrimSpace(out))
 }
 
+func TestSetTaskStatus_HeadingBlock(t *testing.T) {
+	block := parseBlock(t, "#### TODO 1. Fix the config")
+
+	err := logseqext.SetTaskStatus(block, content.TaskStatusNow)
+	require.NoError(t, err)
+
+	out, err := logseq.AsString(block)
+	require.NoError(t, err)
+	assert.Equal(t, "#### NOW 1. Fix the config", strings.TrimSpace(out))
+}
+
+func TestSetTaskDate(t *testing.T) {
+	date := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		block    string
+		keyword  string
+		expected string
+	}{
+		{
+			"new date", "TODO Fix the config", logseqext.TaskDateScheduled,
+			"TODO Fix the config\nSCHEDULED: <2025-04-30 Wed>",
+		},
+		{
+			"replaced date", "TODO Fix the config\nDEADLINE: <2025-04-15 Tue>", logseqext.TaskDateDeadline,
+			"TODO Fix the config\nDEADLINE: <2025-04-30 Wed>",
+		},
+		{
+			"after the other date", "TODO Fix the config\nSCHEDULED: <2025-04-15 Tue>", logseqext.TaskDateDeadline,
+			"TODO Fix the config\nSCHEDULED: <2025-04-15 Tue>\nDEADLINE: <2025-04-30 Wed>",
+		},
+		{
+			"repeater kept", "TODO Water plants\nSCHEDULED: <2025-04-15 Tue .+1w>", logseqext.TaskDateScheduled,
+			"TODO Water plants\nSCHEDULED: <2025-04-30 Wed .+1w>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block := parseBlock(t, test.block)
+
+			err := logseqext.SetTaskDate(block, test.keyword, date)
+			require.NoError(t, err)
+
+			out, err := logseq.AsString(block)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, strings.TrimSpace(out))
+		})
+	}
+}
+
+func TestCompleteTask(t *testing.T) {
+	today := time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		block    string
+		repeated bool
+		expected string
+	}{
+		{
+			"no repeater", "TODO Fix the config\nSCHEDULED: <2025-04-10 Thu>", false,
+			"DONE Fix the config\nSCHEDULED: <2025-04-10 Thu>",
+		},
+		{
+			"from today", "DOING Water plants\nSCHEDULED: <2025-04-10 Thu .+1w>", true,
+			"TODO Water plants\nSCHEDULED: <2025-04-20 Sun .+1w>",
+		},
+		{
+			"to the future", "NOW Pay rent\nDEADLINE: <2025-03-01 Sat ++1m>", true,
+			"LATER Pay rent\nDEADLINE: <2025-05-01 Thu ++1m>",
+		},
+		{
+			"from the date", "TODO Stretch\nSCHEDULED: <2025-04-10 Thu +1d>\nDEADLINE: <2025-04-30 Wed>", true,
+			"TODO Stretch\nSCHEDULED: <2025-04-11 Fri +1d>\nDEADLINE: <2025-04-30 Wed>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block := parseBlock(t, test.block)
+
+			repeated, err := logseqext.CompleteTask(block, today)
+			require.NoError(t, err)
+			assert.Equal(t, test.repeated, repeated)
+
+			out, err := logseq.AsString(block)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, strings.TrimSpace(out))
+		})
+	}
+}
+
+func TestBlockPropertyDate(t *testing.T) {
+	block := parseBlock(t, "((67c48ea4-92cd-4b27-8202-ec1f4fe4ec59))\ncompleted:: [[Friday, 11.04.2025]]")
+
+	assert.Equal(t, time.Date(2025, 4, 11, 0, 0, 0, 0, time.UTC), logseqext.BlockPropertyDate(block, "completed"))
+	assert.True(t, logseqext.BlockPropertyDate(block, "cancelled").IsZero())
+}
+
 func TestSetTaskCanceled_NonTaskBlock(t *testing.T) {
 	graph := testutils.NewStubGraph(t, "stub-graph")
 	page, err := graph.OpenPage("finder")
@@ -1,9 +1,12 @@
 package logseqext
 
 import (
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -39,6 +42,31 @@ func ReadJournalTitleFormat(graphPath string) string {
 	return string(matches[1])
 }
 
+// journalFileFormatRe matches the :journal/file-name-format line in config.edn.
+var journalFileFormatRe = regexp.MustCompile(`(?m):journal/file-name-format\s+"([^"]+)"`)
+
+// ReadJournalFileNameFormat reads the date format of the journal file names from logseq/config.edn
+// (e.g. "yyyy_MM_dd"). Returns the Logseq default "yyyy_MM_dd" if the file cannot be read or the key is absent.
+func ReadJournalFileNameFormat(graphPath string) string {
+	const defaultFormat = "yyyy_MM_dd"
+
+	if graphPath == "" {
+		return defaultFormat
+	}
+
+	data, err := os.ReadFile(filepath.Join(graphPath, "logseq", "config.edn"))
+	if err != nil {
+		return defaultFormat
+	}
+
+	matches := journalFileFormatRe.FindSubmatch(data)
+	if len(matches) < 2 { //nolint:mnd
+		return defaultFormat
+	}
+
+	return string(matches[1])
+}
+
 // DateYYYYMMDD returns the current date in YYYYMMDD format.
 func DateYYYYMMDD(time time.Time) int {
 	currentDate := time.Year()*10000 + int(time.Month())*100 + time.Day()
@@ -81,3 +109,204 @@ func JournalDayToTime(journalDay int) time.Time {
 
 	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
 }
+
+// journalTitleTokens lists the date-fns tokens supported by FormatJournalTitle, longest first.
+//
+//nolint:gochecknoglobals
+var journalTitleTokens = []string{"yyyy", "yy", "MMMM", "MMM", "MM", "M", "EEEE", "EEE", "EE", "E", "do", "dd", "d"}
+
+// FormatJournalTitle formats date with a JS-style (date-fns) journal title format such as
+// "EEE do, MMM yyyy", as returned by ReadJournalTitleFormat.
+// Characters that are not format tokens are copied verbatim.
+func FormatJournalTitle(date time.Time, jsFormat string) string {
+	var builder strings.Builder
+
+	for rest := jsFormat; rest != ""; {
+		token := journalTitleTokenAt(rest)
+		if token == "" {
+			builder.WriteByte(rest[0])
+			rest = rest[1:]
+
+			continue
+		}
+
+		builder.WriteString(formatJournalTitleToken(date, token))
+		rest = rest[len(token):]
+	}
+
+	return builder.String()
+}
+
+// journalTitleGoLayouts maps each date-fns token to the equivalent Go layout element.
+// Go has no ordinal days, so "do" is parsed as a plain day after stripping the suffix.
+//
+//nolint:gochecknoglobals
+var journalTitleGoLayouts = map[string]string{
+	"yyyy": "2006", "yy": "06", "MMMM": "January", "MMM": "Jan", "MM": "01", "M": "1",
+	"EEEE": "Monday", "EEE": "Mon", "EE": "Mon", "E": "Mon", "do": "2", "dd": "02", "d": "2",
+}
+
+// ordinalSuffixRe matches an ordinal day such as "13th" or "1st".
+var ordinalSuffixRe = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)\b`)
+
+// ParseJournalTitle parses a journal page title written with a JS-style journal title format;
+// it is the inverse of FormatJournalTitle.
+func ParseJournalTitle(title, jsFormat string) (time.Time, error) {
+	var layout strings.Builder
+
+	for rest := jsFormat; rest != ""; {
+		token := journalTitleTokenAt(rest)
+		if token == "" {
+			layout.WriteByte(rest[0])
+			rest = rest[1:]
+
+			continue
+		}
+
+		layout.WriteString(journalTitleGoLayouts[token])
+		rest = rest[len(token):]
+	}
+
+	if strings.Contains(jsFormat, "do") {
+		title = ordinalSuffixRe.ReplaceAllString(title, "$1")
+	}
+
+	date, err := time.Parse(layout.String(), title)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("journal title %q does not match format %q: %w", title, jsFormat, err)
+	}
+
+	return date, nil
+}
+
+// journalTitleTokenAt returns the date-fns token at the start of text, or "" for a literal character.
+func journalTitleTokenAt(text string) string {
+	for _, candidate := range journalTitleTokens {
+		if strings.HasPrefix(text, candidate) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func formatJournalTitleToken(date time.Time, token string) string {
+	switch token {
+	case "yyyy":
+		return date.Format("2006")
+	case "yy":
+		return date.Format("06")
+	case "MMMM":
+		return date.Format("January")
+	case "MMM":
+		return date.Format("Jan")
+	case "MM":
+		return date.Format("01")
+	case "M":
+		return date.Format("1")
+	case "EEEE":
+		return date.Format("Monday")
+	case "do":
+		return ordinal(date.Day())
+	case "dd":
+		return date.Format("02")
+	case "d":
+		return date.Format("2")
+	default: // "EEE", "EE", "E"
+		return date.Format("Mon")
+	}
+}
+
+// ordinal returns the English ordinal of day: 1st, 2nd, 3rd, 4th, ..., 11th, 21st.
+func ordinal(day int) string {
+	suffix := "th"
+
+	if day%100 < 11 || day%100 > 13 { //nolint:mnd
+		switch day % 10 { //nolint:mnd
+		case 1:
+			suffix = "st"
+		case 2: //nolint:mnd
+			suffix = "nd"
+		case 3: //nolint:mnd
+			suffix = "rd"
+		}
+	}
+
+	return strconv.Itoa(day) + suffix
+}
+
+// JournalDateFromFileName parses a journal file name like "2025_04_13.md" (Logseq's default
+// :journal/file-name-format "yyyy_MM_dd"). The boolean is false for any other file name.
+func JournalDateFromFileName(fileName string) (time.Time, bool) {
+	date, err := time.Parse("2006_01_02", strings.TrimSuffix(filepath.Base(fileName), ".md"))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// PageTitleFromFileName converts a page file name back into the page title, following Logseq's
+// :file/name-format :triple-lowbar convention: "___" stands for "/" and other reserved characters
+// are URL-encoded (e.g. "bk___home.md" is the page "bk/home").
+func PageTitleFromFileName(fileName string) string {
+	title := strings.TrimSuffix(filepath.Base(fileName), ".md")
+	title = strings.ReplaceAll(title, "___", "/")
+
+	unescaped, err := url.PathUnescape(title)
+	if err != nil {
+		return title
+	}
+
+	return unescaped
+}
+
+// Keywords of the task dates written below the first line of a task, e.g. "SCHEDULED: <2025-04-15 Tue>".
+const (
+	TaskDateScheduled = "SCHEDULED"
+	TaskDateDeadline  = "DEADLINE"
+)
+
+// taskDateRe matches a SCHEDULED or DEADLINE date; the weekday, a repeater and anything else inside the brackets
+// are optional.
+var taskDateRe = regexp.MustCompile(`\b(SCHEDULED|DEADLINE):\s*<(\d{4}-\d{2}-\d{2})([^>]*)>`)
+
+// TaskDate is a SCHEDULED or DEADLINE date found in a text.
+type TaskDate struct {
+	Keyword  string // TaskDateScheduled or TaskDateDeadline
+	Date     time.Time
+	Repeater Repeater // e.g. ".+1w" in "SCHEDULED: <2025-04-15 Tue .+1w>"; zero if the date doesn't repeat
+	Text     string   // the matched text, e.g. "SCHEDULED: <2025-04-15 Tue>"
+}
+
+// FindTaskDates returns the SCHEDULED and DEADLINE dates of a text, in order. Invalid dates are skipped.
+func FindTaskDates(text string) []TaskDate {
+	var dates []TaskDate
+
+	for _, match := range taskDateRe.FindAllStringSubmatch(text, -1) {
+		date, err := time.Parse(time.DateOnly, match[2])
+		if err != nil {
+			continue
+		}
+
+		repeater, _ := ParseRepeater(match[3])
+		dates = append(dates, TaskDate{Keyword: match[1], Date: date, Repeater: repeater, Text: match[0]})
+	}
+
+	return dates
+}
+
+// FormatTaskDate formats a date the way Logseq writes task dates: "<2025-04-15 Tue>".
+func FormatTaskDate(date time.Time) string {
+	return "<" + date.Format("2006-01-02 Mon") + ">"
+}
+
+// FormatRepeatingTaskDate formats a date with its repeater: "<2025-04-15 Tue .+1w>".
+// Without a repeater, it is the same as FormatTaskDate.
+func FormatRepeatingTaskDate(date time.Time, repeater Repeater) string {
+	if repeater.IsZero() {
+		return FormatTaskDate(date)
+	}
+
+	return "<" + date.Format("2006-01-02 Mon") + " " + repeater.String() + ">"
+}
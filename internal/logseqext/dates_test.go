@@ -67,6 +67,41 @@ func TestFormatLogseqDate_DifferentDay(t *testing.T) {
 	assert.Equal(t, "[[Monday, 06.01.2025]]", result)
 }
 
+func TestFindTaskDates(t *testing.T) {
+	dates := logseqext.FindTaskDates(" SCHEDULED: <2025-04-20 Sun> and DEADLINE: <2025-04-30> SCHEDULED: <2025-02-30>")
+	require.Len(t, dates, 2)
+
+	assert.Equal(t, logseqext.TaskDateScheduled, dates[0].Keyword)
+	assert.Equal(t, time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC), dates[0].Date)
+	assert.Equal(t, "SCHEDULED: <2025-04-20 Sun>", dates[0].Text)
+
+	assert.Equal(t, logseqext.TaskDateDeadline, dates[1].Keyword)
+	assert.Equal(t, "DEADLINE: <2025-04-30>", dates[1].Text)
+
+	assert.Empty(t, logseqext.FindTaskDates("no dates here"))
+}
+
+func TestFindTaskDates_Repeater(t *testing.T) {
+	dates := logseqext.FindTaskDates("SCHEDULED: <2025-04-20 Sun .+2w> DEADLINE: <2025-04-30 Wed>")
+	require.Len(t, dates, 2)
+
+	assert.Equal(t, ".+2w", dates[0].Repeater.String())
+	assert.Equal(t, "SCHEDULED: <2025-04-20 Sun .+2w>", dates[0].Text)
+	assert.True(t, dates[1].Repeater.IsZero())
+}
+
+func TestFormatTaskDate(t *testing.T) {
+	assert.Equal(t, "<2025-04-30 Wed>", logseqext.FormatTaskDate(time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestFormatRepeatingTaskDate(t *testing.T) {
+	date := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+	repeater, _ := logseqext.ParseRepeater("++1m")
+
+	assert.Equal(t, "<2025-04-30 Wed ++1m>", logseqext.FormatRepeatingTaskDate(date, repeater))
+	assert.Equal(t, "<2025-04-30 Wed>", logseqext.FormatRepeatingTaskDate(date, logseqext.Repeater{})) //nolint:exhaustruct
+}
+
 func TestJournalDayToTime(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -130,6 +165,21 @@ func TestReadJournalTitleFormat(t *testing.T) {
 	})
 }
 
+func TestReadJournalFileNameFormat(t *testing.T) {
+	t.Run("missing config file returns default", func(t *testing.T) {
+		assert.Equal(t, "yyyy_MM_dd", logseqext.ReadJournalFileNameFormat(t.TempDir()))
+	})
+
+	t.Run("config with key returns extracted format", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(dir+"/logseq", 0o755))
+
+		edn := `{:journal/page-title-format "EEEE, dd.MM.yyyy" :journal/file-name-format "yyyy-MM-dd"}`
+		require.NoError(t, os.WriteFile(dir+"/logseq/config.edn", []byte(edn), 0o600))
+		assert.Equal(t, "yyyy-MM-dd", logseqext.ReadJournalFileNameFormat(dir))
+	})
+}
+
 func TestJournalDayToTime_RoundTrip(t *testing.T) {
 	// DateYYYYMMDD → JournalDayToTime should round-trip
 	original := time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC)
@@ -137,3 +187,46 @@ func TestJournalDayToTime_RoundTrip(t *testing.T) {
 	result := logseqext.JournalDayToTime(journalDay)
 	assert.Equal(t, original, result)
 }
+
+func TestFormatJournalTitle(t *testing.T) {
+	date := time.Date(2025, time.April, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"EEE do, MMM yyyy", "Sun 13th, Apr 2025"},
+		{"EEEE, dd.MM.yyyy", "Sunday, 13.04.2025"},
+		{"MMM do, yyyy", "Apr 13th, 2025"},
+		{"yyyy-MM-dd", "2025-04-13"},
+		{"MMMM d, yy", "April 13, 25"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			assert.Equal(t, test.expected, logseqext.FormatJournalTitle(date, test.format))
+		})
+	}
+}
+
+func TestFormatJournalTitle_Ordinals(t *testing.T) {
+	for day, expected := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 22: "22nd"} {
+		date := time.Date(2025, time.March, day, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, expected, logseqext.FormatJournalTitle(date, "do"))
+	}
+}
+
+func TestJournalDateFromFileName(t *testing.T) {
+	date, ok := logseqext.JournalDateFromFileName("/graph/journals/2024_12_24.md")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.December, 24, 0, 0, 0, 0, time.UTC), date)
+
+	_, ok = logseqext.JournalDateFromFileName("notes.md")
+	assert.False(t, ok)
+}
+
+func TestPageTitleFromFileName(t *testing.T) {
+	assert.Equal(t, "bk/home", logseqext.PageTitleFromFileName("pages/bk___home.md"))
+	assert.Equal(t, "What? Why", logseqext.PageTitleFromFileName("What%3F Why.md"))
+	assert.Equal(t, "plain", logseqext.PageTitleFromFileName("plain.md"))
+}
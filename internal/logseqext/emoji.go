@@ -0,0 +1,67 @@
+package logseqext
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	variationSelector = '\ufe0f' // VS16, emoji presentation
+	keycap            = '\u20e3'
+
+	// The Unicode blocks of the emojis, from Mahjong tiles to the Symbols and Pictographs Extended-A.
+	emojiBlockStart = 0x1f000
+	emojiBlockEnd   = 0x1faff
+
+	skinToneStart = 0x1f3fb
+	skinToneEnd   = 0x1f3ff
+)
+
+// FirstEmoji returns the first emoji found in text, or an empty string.
+// Sequences are kept whole: variation selectors, skin tones, keycaps and emojis joined by a zero-width joiner
+// (e.g. "🏷️" or "👩‍💻") are returned as one emoji.
+func FirstEmoji(text string) string {
+	for start, r := range text {
+		if !isEmojiBase(r) {
+			continue
+		}
+
+		end := start + utf8.RuneLen(r)
+
+		for end < len(text) {
+			next, size := utf8.DecodeRuneInString(text[end:])
+
+			switch {
+			case next == variationSelector || next == keycap || isSkinTone(next):
+				end += size
+			case next == zeroWidthJoiner:
+				joined, joinedSize := utf8.DecodeRuneInString(text[end+size:])
+				if !isEmojiBase(joined) {
+					return text[start:end]
+				}
+
+				end += size + joinedSize
+			default:
+				return text[start:end]
+			}
+		}
+
+		return text[start:end]
+	}
+
+	return ""
+}
+
+// isEmojiBase tells if a rune starts an emoji: pictographs and other symbols, but not letters, digits or punctuation.
+func isEmojiBase(r rune) bool {
+	if r < unicode.MaxLatin1 {
+		return false
+	}
+
+	return unicode.Is(unicode.So, r) || (r >= emojiBlockStart && r <= emojiBlockEnd)
+}
+
+func isSkinTone(r rune) bool {
+	return r >= skinToneStart && r <= skinToneEnd
+}
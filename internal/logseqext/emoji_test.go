@@ -0,0 +1,30 @@
+package logseqext_test
+
+import (
+	"testing"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstEmoji(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"", ""},
+		{"no emoji here, © 2026 ± 1", ""},
+		{"💼 work and [[office]]", "💼"},
+		{"For my [[house]] 🏠", "🏠"},
+		{"🏷️ triaged", "🏷️"},
+		{"⏰ first, then 📅", "⏰"},
+		{"coding 👩‍💻 at night", "👩‍💻"},
+		{"thumbs 👍🏽 up", "👍🏽"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			assert.Equal(t, test.expected, logseqext.FirstEmoji(test.text))
+		})
+	}
+}
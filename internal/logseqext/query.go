@@ -0,0 +1,471 @@
+package logseqext
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQuery is returned when a simple query cannot be parsed.
+var ErrInvalidQuery = errors.New("invalid simple query")
+
+// ErrUnsupportedQuery is returned for simple-query forms that ParseSimpleQuery does not evaluate.
+var ErrUnsupportedQuery = errors.New("unsupported simple query")
+
+// QueryBlock holds the attributes of a block that a simple query can filter on.
+type QueryBlock struct {
+	Content string
+	Marker  string
+	// Priority is "A", "B", "C"... or empty when the block has no priority.
+	Priority string
+	// PageName is the lowercase name of the page that contains the block.
+	PageName string
+	// JournalDay is the YYYYMMDD date of a journal page, 0 for regular pages.
+	JournalDay int
+	// PathRefs holds the lowercase names of the pages referenced by the block, its ancestors and its page.
+	PathRefs []string
+	// Properties holds the property values as text, keyed by lowercase name.
+	Properties map[string]string
+}
+
+// QueryOptions holds the context used to resolve dates in "between" filters.
+type QueryOptions struct {
+	Today              time.Time
+	JournalTitleFormat string
+}
+
+// SimpleQuery is a parsed Logseq simple query, ready to be matched against blocks.
+type SimpleQuery struct {
+	match queryMatcher
+}
+
+type queryMatcher func(block *QueryBlock) bool
+
+// Match reports whether the block satisfies the query.
+func (q SimpleQuery) Match(block QueryBlock) bool {
+	return q.match(&block)
+}
+
+// ParseSimpleQuery parses the simple-query subset used on backlog pages: and/or/not, page refs and tags,
+// full-text strings, task, priority, property, between and page.
+// The text may also be the map form {:title "..." :query (...)} returned by LogseqFinder.FindFirstQuery.
+// Other forms (sort-by, page-property, page-tags...) return ErrUnsupportedQuery.
+func ParseSimpleQuery(text string, opts QueryOptions) (SimpleQuery, error) {
+	parser := &queryParser{input: text, pos: 0}
+
+	var nodes []queryNode
+
+	for parser.skipSpace(); parser.pos < len(parser.input); parser.skipSpace() {
+		node, err := parser.parseNode()
+		if err != nil {
+			return SimpleQuery{match: nil}, err
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		return SimpleQuery{match: nil}, fmt.Errorf("%w: empty query", ErrInvalidQuery)
+	}
+
+	// Several top-level forms are combined with "and", like Logseq does.
+	root := nodes[0]
+	if len(nodes) > 1 {
+		root = queryNode{kind: queryList, text: "", items: append([]queryNode{{kind: querySymbol, text: "and"}}, nodes...)}
+	}
+
+	compiler := queryCompiler{opts: opts}
+
+	match, err := compiler.compile(root)
+	if err != nil {
+		return SimpleQuery{match: nil}, err
+	}
+
+	return SimpleQuery{match: match}, nil
+}
+
+type queryNodeKind int
+
+const (
+	querySymbol queryNodeKind = iota
+	queryString
+	queryRef
+	queryList
+	queryMap
+)
+
+// queryNode is a parsed EDN-like form of a simple query.
+type queryNode struct {
+	kind  queryNodeKind
+	text  string
+	items []queryNode
+}
+
+type queryParser struct {
+	input string
+	pos   int
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n,", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *queryParser) parseNode() (queryNode, error) {
+	rest := p.input[p.pos:]
+
+	switch {
+	case rest[0] == '(':
+		return p.parseCollection(queryList, ')')
+	case rest[0] == '{':
+		return p.parseCollection(queryMap, '}')
+	case rest[0] == ')' || rest[0] == '}':
+		return queryNode{}, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalidQuery, rest[0], p.pos)
+	case rest[0] == '"':
+		return p.parseString()
+	case strings.HasPrefix(rest, "[["):
+		return p.parseRef(len("[["))
+	case strings.HasPrefix(rest, "#[["):
+		return p.parseRef(len("#[["))
+	}
+
+	end := strings.IndexAny(rest, " \t\r\n,(){}\"")
+	if end < 0 {
+		end = len(rest)
+	}
+
+	p.pos += end
+
+	if strings.HasPrefix(rest, "#") && end > 1 {
+		return queryNode{kind: queryRef, text: rest[1:end], items: nil}, nil
+	}
+
+	return queryNode{kind: querySymbol, text: rest[:end], items: nil}, nil
+}
+
+func (p *queryParser) parseCollection(kind queryNodeKind, closing byte) (queryNode, error) {
+	start := p.pos
+	p.pos++
+
+	node := queryNode{kind: kind, text: "", items: nil}
+
+	for {
+		p.skipSpace()
+
+		if p.pos >= len(p.input) {
+			return queryNode{}, fmt.Errorf("%w: unclosed %q at position %d", ErrInvalidQuery, p.input[start], start)
+		}
+
+		if p.input[p.pos] == closing {
+			p.pos++
+
+			return node, nil
+		}
+
+		item, err := p.parseNode()
+		if err != nil {
+			return queryNode{}, err
+		}
+
+		node.items = append(node.items, item)
+	}
+}
+
+func (p *queryParser) parseString() (queryNode, error) {
+	var builder strings.Builder
+
+	for i := p.pos + 1; i < len(p.input); i++ {
+		switch p.input[i] {
+		case '\\':
+			if i+1 < len(p.input) {
+				i++
+				builder.WriteByte(p.input[i])
+			}
+		case '"':
+			p.pos = i + 1
+
+			return queryNode{kind: queryString, text: builder.String(), items: nil}, nil
+		default:
+			builder.WriteByte(p.input[i])
+		}
+	}
+
+	return queryNode{}, fmt.Errorf("%w: unclosed string at position %d", ErrInvalidQuery, p.pos)
+}
+
+func (p *queryParser) parseRef(prefixLen int) (queryNode, error) {
+	end := strings.Index(p.input[p.pos+prefixLen:], "]]")
+	if end < 0 {
+		return queryNode{}, fmt.Errorf("%w: unclosed page ref at position %d", ErrInvalidQuery, p.pos)
+	}
+
+	text := p.input[p.pos+prefixLen : p.pos+prefixLen+end]
+	p.pos += prefixLen + end + len("]]")
+
+	return queryNode{kind: queryRef, text: text, items: nil}, nil
+}
+
+// queryCompiler turns parsed forms into matchers.
+type queryCompiler struct {
+	opts QueryOptions
+}
+
+func (c queryCompiler) compile(node queryNode) (queryMatcher, error) {
+	switch node.kind {
+	case queryRef:
+		name := strings.ToLower(node.text)
+
+		return func(block *QueryBlock) bool { return containsFold(block.PathRefs, name) }, nil
+	case queryString:
+		needle := strings.ToLower(node.text)
+
+		return func(block *QueryBlock) bool { return strings.Contains(strings.ToLower(block.Content), needle) }, nil
+	case queryMap:
+		return c.compileMap(node)
+	case queryList:
+		return c.compileList(node)
+	case querySymbol:
+		return nil, fmt.Errorf("%w: bare symbol %q (use [[page]] or \"text\")", ErrUnsupportedQuery, node.text)
+	}
+
+	return nil, fmt.Errorf("%w: unknown form", ErrInvalidQuery)
+}
+
+// compileMap handles {:title "..." :query (...)} by compiling its :query value.
+func (c queryCompiler) compileMap(node queryNode) (queryMatcher, error) {
+	for i := 0; i+1 < len(node.items); i += 2 {
+		if node.items[i].kind == querySymbol && node.items[i].text == ":query" {
+			return c.compile(node.items[i+1])
+		}
+	}
+
+	return nil, fmt.Errorf("%w: query map without :query", ErrInvalidQuery)
+}
+
+func (c queryCompiler) compileList(node queryNode) (queryMatcher, error) {
+	if len(node.items) == 0 || node.items[0].kind != querySymbol {
+		return nil, fmt.Errorf("%w: a list must start with a filter name", ErrInvalidQuery)
+	}
+
+	name := strings.ToLower(node.items[0].text)
+	args := node.items[1:]
+
+	switch name {
+	case "and", "or", "not":
+		return c.compileBoolean(name, args)
+	case "task", "todo":
+		return compileTask(args), nil
+	case "priority":
+		return compilePriority(args), nil
+	case "property":
+		return compileProperty(args)
+	case "between":
+		return c.compileBetween(args)
+	case "page":
+		return compilePage(args), nil
+	}
+
+	return nil, fmt.Errorf("%w: (%s ...) is not supported", ErrUnsupportedQuery, name)
+}
+
+func (c queryCompiler) compileBoolean(name string, args []queryNode) (queryMatcher, error) {
+	matchers := make([]queryMatcher, 0, len(args))
+
+	for _, arg := range args {
+		match, err := c.compile(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, match)
+	}
+
+	switch name {
+	case "and":
+		return func(block *QueryBlock) bool {
+			for _, match := range matchers {
+				if !match(block) {
+					return false
+				}
+			}
+
+			return true
+		}, nil
+	case "or":
+		return func(block *QueryBlock) bool {
+			for _, match := range matchers {
+				if match(block) {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	default: // "not" matches blocks that match none of its arguments.
+		return func(block *QueryBlock) bool {
+			for _, match := range matchers {
+				if match(block) {
+					return false
+				}
+			}
+
+			return true
+		}, nil
+	}
+}
+
+func compileTask(args []queryNode) queryMatcher {
+	markers := make([]string, 0, len(args))
+	for _, arg := range args {
+		markers = append(markers, strings.ToUpper(arg.text))
+	}
+
+	return func(block *QueryBlock) bool { return containsFold(markers, block.Marker) }
+}
+
+func compilePriority(args []queryNode) queryMatcher {
+	priorities := make([]string, 0, len(args))
+	for _, arg := range args {
+		priorities = append(priorities, strings.ToUpper(arg.text))
+	}
+
+	return func(block *QueryBlock) bool { return block.Priority != "" && containsFold(priorities, block.Priority) }
+}
+
+func compileProperty(args []queryNode) (queryMatcher, error) {
+	if len(args) == 0 || len(args) > 2 { //nolint:mnd
+		return nil, fmt.Errorf("%w: (property key [value]) expects one or two arguments", ErrInvalidQuery)
+	}
+
+	key := strings.ToLower(strings.TrimPrefix(args[0].text, ":"))
+
+	if len(args) == 1 {
+		return func(block *QueryBlock) bool {
+			_, ok := block.Properties[key]
+
+			return ok
+		}, nil
+	}
+
+	want := normalizePropertyValue(args[1].text)
+
+	return func(block *QueryBlock) bool {
+		value, ok := block.Properties[key]
+		if !ok {
+			return false
+		}
+
+		// Multi-value properties (tags:: a, [[b]]) match if any of their values match.
+		for _, item := range strings.Split(value, ",") {
+			if normalizePropertyValue(item) == want {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+// normalizePropertyValue makes "[[Page]]", "#page" and "page" compare equal.
+func normalizePropertyValue(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "#")
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "[["), "]]")
+
+	return strings.ToLower(strings.Trim(value, `"`))
+}
+
+func compilePage(args []queryNode) queryMatcher {
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		names = append(names, strings.ToLower(arg.text))
+	}
+
+	return func(block *QueryBlock) bool { return containsFold(names, block.PageName) }
+}
+
+// compileBetween matches blocks on journal pages whose date falls within the range, both ends included.
+func (c queryCompiler) compileBetween(args []queryNode) (queryMatcher, error) {
+	if len(args) != 2 { //nolint:mnd
+		return nil, fmt.Errorf("%w: (between start end) expects two dates", ErrInvalidQuery)
+	}
+
+	start, err := c.resolveDate(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := c.resolveDate(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return func(block *QueryBlock) bool {
+		return block.JournalDay > 0 && block.JournalDay >= start && block.JournalDay <= end
+	}, nil
+}
+
+// relativeDateRe matches relative dates such as -7d, +2w, 1m or -1y.
+var relativeDateRe = regexp.MustCompile(`^([+-]?)(\d+)([dwmy])$`)
+
+// resolveDate converts a "between" argument into a YYYYMMDD date.
+// It accepts today/yesterday/tomorrow/now, relative dates, ISO dates and journal titles.
+func (c queryCompiler) resolveDate(node queryNode) (int, error) {
+	text := strings.TrimSpace(node.text)
+	today := c.opts.Today
+
+	switch strings.ToLower(text) {
+	case "today", "now":
+		return DateYYYYMMDD(today), nil
+	case "yesterday":
+		return DateYYYYMMDD(today.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return DateYYYYMMDD(today.AddDate(0, 0, 1)), nil
+	}
+
+	if match := relativeDateRe.FindStringSubmatch(strings.ToLower(text)); match != nil {
+		amount, _ := strconv.Atoi(match[2])
+		if match[1] != "+" {
+			amount = -amount
+		}
+
+		switch match[3] {
+		case "d":
+			return DateYYYYMMDD(today.AddDate(0, 0, amount)), nil
+		case "w":
+			return DateYYYYMMDD(today.AddDate(0, 0, 7*amount)), nil //nolint:mnd
+		case "m":
+			return DateYYYYMMDD(today.AddDate(0, amount, 0)), nil
+		default:
+			return DateYYYYMMDD(today.AddDate(amount, 0, 0)), nil
+		}
+	}
+
+	for _, layout := range []string{time.DateOnly, "20060102"} {
+		if date, err := time.Parse(layout, text); err == nil {
+			return DateYYYYMMDD(date), nil
+		}
+	}
+
+	date, err := ParseJournalTitle(text, c.opts.JournalTitleFormat)
+	if err != nil {
+		return 0, fmt.Errorf("%w: cannot parse date %q: %w", ErrInvalidQuery, text, err)
+	}
+
+	return DateYYYYMMDD(date), nil
+}
+
+// containsFold reports whether items contains value, ignoring case.
+func containsFold(items []string, value string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
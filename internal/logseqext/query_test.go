@@ -0,0 +1,117 @@
+package logseqext_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSimpleQuery_Match(t *testing.T) {
+	opts := logseqext.QueryOptions{
+		Today:              time.Date(2025, time.April, 13, 0, 0, 0, 0, time.UTC),
+		JournalTitleFormat: "EEEE, dd.MM.yyyy",
+	}
+	block := logseqext.QueryBlock{
+		Content:    "TODO [#A] Pay the bills #finance\npayment-method:: [[Credit card]], cash",
+		Marker:     "TODO",
+		Priority:   "A",
+		PageName:   "saturday, 12.04.2025",
+		JournalDay: 20250412,
+		PathRefs:   []string{"saturday, 12.04.2025", "finance", "home"},
+		Properties: map[string]string{"payment-method": "[[Credit card]], cash"},
+	}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"[[finance]]", true},
+		{"#Home", true},
+		{"[[work]]", false},
+		{"(and [[finance]] (task TODO DOING))", true},
+		{"(and [[finance]] (task DONE))", false},
+		{"(or [[work]] [[home]])", true},
+		{"(not [[work]])", true},
+		{"(not [[work]] [[home]])", false},
+		{"(task todo)", true},
+		{"(priority a b)", true},
+		{"(priority c)", false},
+		{"(property :payment-method [[credit card]])", true},
+		{"(property payment-method cash)", true},
+		{"(property payment-method cheque)", false},
+		{"(property payment-method)", true},
+		{"(property owner)", false},
+		{`"pay the"`, true},
+		{`(page "Saturday, 12.04.2025")`, true},
+		{"(page [[home]])", false},
+		{"(between -7d today)", true},
+		{"(between today +7d)", false},
+		{"(between -1w yesterday)", true},
+		{"(between [[Friday, 11.04.2025]] [[Saturday, 12.04.2025]])", true},
+		{"(between 2025-04-01 20250411)", false},
+		{"[[finance]] (task TODO)", true},
+		{"{:title \"Bills\"\n  :query (and [[finance]] (priority A))\n  :collapsed? false}", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := logseqext.ParseSimpleQuery(test.query, opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, query.Match(block))
+		})
+	}
+}
+
+func TestParseSimpleQuery_BetweenSkipsRegularPages(t *testing.T) {
+	opts := logseqext.QueryOptions{Today: time.Now(), JournalTitleFormat: "yyyy-MM-dd"}
+
+	query, err := logseqext.ParseSimpleQuery("(between -7d +7d)", opts)
+	require.NoError(t, err)
+	assert.False(t, query.Match(logseqext.QueryBlock{PageName: "home"})) //nolint:exhaustruct
+}
+
+func TestParseSimpleQuery_Errors(t *testing.T) {
+	opts := logseqext.QueryOptions{Today: time.Now(), JournalTitleFormat: "EEE do, MMM yyyy"}
+
+	tests := []struct {
+		query string
+		err   error
+	}{
+		{"", logseqext.ErrInvalidQuery},
+		{"(and [[a]]", logseqext.ErrInvalidQuery},
+		{"[[a", logseqext.ErrInvalidQuery},
+		{`"unclosed`, logseqext.ErrInvalidQuery},
+		{")", logseqext.ErrInvalidQuery},
+		{"()", logseqext.ErrInvalidQuery},
+		{"(between today)", logseqext.ErrInvalidQuery},
+		{"(between [[not a date]] today)", logseqext.ErrInvalidQuery},
+		{"{:title \"no query\"}", logseqext.ErrInvalidQuery},
+		{"(page-tags book)", logseqext.ErrUnsupportedQuery},
+		{"(sort-by created-at)", logseqext.ErrUnsupportedQuery},
+		{"(and [[a]] (all-page-tags))", logseqext.ErrUnsupportedQuery},
+		{"word", logseqext.ErrUnsupportedQuery},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := logseqext.ParseSimpleQuery(test.query, opts)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestParseJournalTitle(t *testing.T) {
+	expected := time.Date(2025, time.April, 13, 0, 0, 0, 0, time.UTC)
+
+	for _, format := range []string{"EEE do, MMM yyyy", "EEEE, dd.MM.yyyy", "MMM do, yyyy", "yyyy-MM-dd"} {
+		t.Run(format, func(t *testing.T) {
+			title := logseqext.FormatJournalTitle(expected, format)
+			date, err := logseqext.ParseJournalTitle(title, format)
+			require.NoError(t, err)
+			assert.Equal(t, expected, date)
+		})
+	}
+}
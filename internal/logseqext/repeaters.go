@@ -0,0 +1,126 @@
+package logseqext
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Kinds of repeaters, written before the interval of a task date like in org-mode.
+const (
+	RepeatFromDate  = "+"  // the next date is one interval after the current one, even if still in the past
+	RepeatToFuture  = "++" // the next date is the first one after today, keeping the rhythm of the current one
+	RepeatFromToday = ".+" // the next date is one interval after the day the task is done
+)
+
+const (
+	repeatUnitDay    = 'd'
+	repeatUnitWeek   = 'w'
+	repeatUnitMonth  = 'm'
+	repeatUnitYear   = 'y'
+	daysPerWeek      = 7
+	monthsPerYear    = 12
+	maxOccurrenceRun = 100000 // stops a series that never reaches the target day
+)
+
+// repeaterRe matches a repeater inside the brackets of a task date, e.g. ".+1w" in "<2025-01-06 Mon .+1w>".
+// Hourly repeaters are left out: task dates have no time here.
+var repeaterRe = regexp.MustCompile(`(\.\+|\+\+|\+)([1-9]\d*)([dwmy])\b`)
+
+// Repeater is the repeat rule of a SCHEDULED or DEADLINE date, e.g. ".+1w". The zero value repeats nothing.
+type Repeater struct {
+	Kind  string // RepeatFromDate, RepeatToFuture or RepeatFromToday
+	Count int    // how many units between two dates
+	Unit  byte   // 'd', 'w', 'm' or 'y'
+}
+
+// ParseRepeater returns the first repeater found in a text, e.g. in the "2025-01-06 Mon .+1w" of a task date.
+func ParseRepeater(text string) (Repeater, bool) {
+	match := repeaterRe.FindStringSubmatch(text)
+	if match == nil {
+		return Repeater{}, false //nolint:exhaustruct // no repeater
+	}
+
+	count, err := strconv.Atoi(match[2])
+	if err != nil {
+		return Repeater{}, false //nolint:exhaustruct // too big to be a count
+	}
+
+	return Repeater{Kind: match[1], Count: count, Unit: match[3][0]}, true
+}
+
+// IsZero tells if the date doesn't repeat.
+func (r Repeater) IsZero() bool {
+	return r.Count == 0
+}
+
+// String returns the repeater the way Logseq writes it, e.g. ".+1w", or "" for the zero value.
+func (r Repeater) String() string {
+	if r.IsZero() {
+		return ""
+	}
+
+	return r.Kind + strconv.Itoa(r.Count) + string(r.Unit)
+}
+
+// Next returns the date a repeating task gets when it is done on today, the way Logseq moves it.
+func (r Repeater) Next(date, today time.Time) time.Time {
+	switch r.Kind {
+	case RepeatFromToday:
+		return r.add(sameDay(today, date), 1)
+	case RepeatToFuture:
+		return r.firstFrom(date, sameDay(today, date).AddDate(0, 0, 1), 1)
+	}
+
+	return r.add(date, 1)
+}
+
+// Occurrence returns the first date of the series of a repeating date that is on today or later,
+// to show and sort a task by. The past dates of the series are occurrences missed: the task is still overdue.
+func (r Repeater) Occurrence(date, today time.Time) time.Time {
+	return r.firstFrom(date, sameDay(today, date), 0)
+}
+
+// firstFrom returns the first date of the series, from the interval number start on, that is on the target or later.
+func (r Repeater) firstFrom(date, target time.Time, start int) time.Time {
+	for n := start; n < maxOccurrenceRun; n++ {
+		if next := r.add(date, n); !next.Before(target) {
+			return next
+		}
+	}
+
+	return date
+}
+
+// add moves a date by n intervals. Months and years keep the day of the month,
+// or use the last day of a shorter month: Jan 31 plus one month is Feb 28.
+func (r Repeater) add(date time.Time, n int) time.Time {
+	count := r.Count * n
+
+	switch r.Unit {
+	case repeatUnitDay:
+		return date.AddDate(0, 0, count)
+	case repeatUnitWeek:
+		return date.AddDate(0, 0, count*daysPerWeek)
+	case repeatUnitMonth:
+		return addMonths(date, count)
+	case repeatUnitYear:
+		return addMonths(date, count*monthsPerYear)
+	}
+
+	return date
+}
+
+// addMonths adds months to a date without overflowing into the month after.
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	return firstOfMonth.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
+
+// sameDay returns the calendar day of today at midnight in the location of a task date,
+// so they compare as days whatever the time zones.
+func sameDay(today, date time.Time) time.Time {
+	return time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, date.Location())
+}
@@ -0,0 +1,99 @@
+package logseqext_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+func day(year int, month time.Month, dayOfMonth int) time.Time {
+	return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRepeater(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected logseqext.Repeater
+		ok       bool
+	}{
+		{"2025-01-06 Mon +1d", logseqext.Repeater{Kind: logseqext.RepeatFromDate, Count: 1, Unit: 'd'}, true},
+		{"2025-01-06 Mon ++2w", logseqext.Repeater{Kind: logseqext.RepeatToFuture, Count: 2, Unit: 'w'}, true},
+		{"2025-01-06 Mon .+3m", logseqext.Repeater{Kind: logseqext.RepeatFromToday, Count: 3, Unit: 'm'}, true},
+		{" +10y", logseqext.Repeater{Kind: logseqext.RepeatFromDate, Count: 10, Unit: 'y'}, true},
+		{"2025-01-06 Mon", logseqext.Repeater{}, false},     //nolint:exhaustruct
+		{"2025-01-06 Mon +1h", logseqext.Repeater{}, false}, //nolint:exhaustruct
+		{"2025-01-06 Mon +0d", logseqext.Repeater{}, false}, //nolint:exhaustruct
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			repeater, ok := logseqext.ParseRepeater(test.text)
+
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, repeater)
+			assert.Equal(t, !ok, repeater.IsZero())
+		})
+	}
+}
+
+func TestRepeater_String(t *testing.T) {
+	repeater, ok := logseqext.ParseRepeater(".+1w")
+	require.True(t, ok)
+
+	assert.Equal(t, ".+1w", repeater.String())
+	assert.Empty(t, logseqext.Repeater{}.String()) //nolint:exhaustruct
+}
+
+func TestRepeater_Next(t *testing.T) {
+	date := day(2025, time.April, 1)
+	today := day(2025, time.April, 13)
+
+	tests := []struct {
+		repeater string
+		expected time.Time
+	}{
+		{"+1w", day(2025, time.April, 8)},   // one interval after the date, still in the past
+		{"++1w", day(2025, time.April, 15)}, // the first date of the series after today
+		{".+1w", day(2025, time.April, 20)}, // one interval after today
+		{"++2d", day(2025, time.April, 15)},
+		{".+1m", day(2025, time.May, 13)},
+		{"+1y", day(2026, time.April, 1)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.repeater, func(t *testing.T) {
+			repeater, _ := logseqext.ParseRepeater(test.repeater)
+
+			assert.Equal(t, test.expected, repeater.Next(date, today))
+		})
+	}
+}
+
+func TestRepeater_Next_OnTheDay(t *testing.T) {
+	today := time.Date(2025, time.April, 13, 18, 30, 0, 0, time.UTC)
+	repeater, _ := logseqext.ParseRepeater("++1w")
+
+	assert.Equal(t, day(2025, time.April, 20), repeater.Next(day(2025, time.April, 13), today),
+		"a task done on its day moves to the next interval")
+}
+
+func TestRepeater_Occurrence(t *testing.T) {
+	repeater, _ := logseqext.ParseRepeater("+1w")
+	today := day(2025, time.April, 13)
+
+	assert.Equal(t, day(2025, time.April, 15), repeater.Occurrence(day(2025, time.April, 1), today))
+	assert.Equal(t, today, repeater.Occurrence(day(2025, time.March, 30), today), "an occurrence on today is kept")
+	assert.Equal(t, day(2025, time.May, 1), repeater.Occurrence(day(2025, time.May, 1), today), "a future date is kept")
+}
+
+func TestRepeater_MonthEnd(t *testing.T) {
+	monthly, _ := logseqext.ParseRepeater("+1m")
+	yearly, _ := logseqext.ParseRepeater("+1y")
+
+	assert.Equal(t, day(2025, time.February, 28), monthly.Next(day(2025, time.January, 31), day(2025, time.January, 1)))
+	assert.Equal(t, day(2025, time.February, 28), yearly.Next(day(2024, time.February, 29), day(2024, time.January, 1)))
+}
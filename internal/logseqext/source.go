@@ -0,0 +1,115 @@
+package logseqext
+
+import (
+	"strings"
+)
+
+// PreambleDepth is the SourceBlock depth of the page-level lines before the first bullet.
+const PreambleDepth = -1
+
+// SourceBlock is a block as it appears in the Markdown source of a page.
+// Start and End delimit the bytes of the block's own lines (child blocks excluded),
+// so the SourceBlocks returned by SplitSourceBlocks tile the whole file.
+type SourceBlock struct {
+	Depth int
+	Start int
+	End   int
+	// Content holds the block text without the bullet and the continuation indentation,
+	// the same way Logseq stores it in :block/content.
+	Content string
+}
+
+// SplitSourceBlocks splits the Markdown source of a Logseq page into its blocks, in file order.
+// Page-level lines before the first bullet (usually page properties) become a block with PreambleDepth.
+// Bullets inside fenced code blocks are not treated as new blocks.
+func SplitSourceBlocks(text string) []SourceBlock {
+	var blocks []SourceBlock
+
+	current := SourceBlock{Depth: PreambleDepth, Start: 0, End: 0, Content: ""}
+
+	var lines []string
+
+	flush := func(end int) {
+		if current.Depth == PreambleDepth && end == current.Start {
+			return
+		}
+
+		current.End = end
+		current.Content = strings.Join(lines, "\n")
+		blocks = append(blocks, current)
+	}
+
+	inFence := false
+
+	for offset := 0; offset < len(text); {
+		lineEnd := len(text)
+		if idx := strings.IndexByte(text[offset:], '\n'); idx >= 0 {
+			lineEnd = offset + idx + 1
+		}
+
+		line := strings.TrimRight(text[offset:lineEnd], "\r\n")
+
+		var contentLine string
+
+		if depth, rest, ok := parseBulletLine(line); ok && !inFence {
+			flush(offset)
+
+			current = SourceBlock{Depth: depth, Start: offset, End: 0, Content: ""}
+			lines = nil
+			contentLine = rest
+		} else {
+			contentLine = stripContinuationIndent(line, current.Depth)
+		}
+
+		lines = append(lines, contentLine)
+
+		if strings.HasPrefix(strings.TrimSpace(contentLine), "```") {
+			inFence = !inFence
+		}
+
+		offset = lineEnd
+	}
+
+	flush(len(text))
+
+	return blocks
+}
+
+// parseBulletLine reports whether line starts a block ("- text" or a bare "-"),
+// returning its depth and the text after the bullet.
+// Logseq indents with tabs; two spaces are also accepted as one level.
+func parseBulletLine(line string) (int, string, bool) {
+	trimmed := strings.TrimLeft(line, "\t ")
+	indent := line[:len(line)-len(trimmed)]
+
+	if trimmed != "-" && !strings.HasPrefix(trimmed, "- ") {
+		return 0, "", false
+	}
+
+	depth := strings.Count(indent, "\t") + strings.Count(indent, " ")/2 //nolint:mnd
+
+	return depth, strings.TrimPrefix(strings.TrimPrefix(trimmed, "-"), " "), true
+}
+
+// stripContinuationIndent removes the indentation Logseq adds to the non-bullet lines of a block:
+// one tab per depth level followed by two spaces.
+func stripContinuationIndent(line string, depth int) string {
+	if depth == PreambleDepth {
+		return line
+	}
+
+	for range depth {
+		line = strings.TrimPrefix(line, "\t")
+	}
+
+	return strings.TrimPrefix(line, "  ")
+}
+
+// SourceIndent returns the indentation of the continuation lines (e.g. properties) of a block at depth.
+func SourceIndent(depth int) string {
+	if depth == PreambleDepth {
+		return ""
+	}
+
+	return strings.Repeat("\t", depth) + "  "
+}
@@ -0,0 +1,57 @@
+package logseqext_test
+
+import (
+	"testing"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSourceBlocks(t *testing.T) {
+	text := "title:: My page\n" +
+		"- TODO First task\n" +
+		"  id:: 1234\n" +
+		"\t- Child with code\n" +
+		"\t  ```\n" +
+		"\t  - not a bullet\n" +
+		"\t  ```\n" +
+		"- Last block"
+
+	blocks := logseqext.SplitSourceBlocks(text)
+	require.Len(t, blocks, 4)
+
+	assert.Equal(t, logseqext.PreambleDepth, blocks[0].Depth)
+	assert.Equal(t, "title:: My page", blocks[0].Content)
+
+	assert.Equal(t, 0, blocks[1].Depth)
+	assert.Equal(t, "TODO First task\nid:: 1234", blocks[1].Content)
+
+	assert.Equal(t, 1, blocks[2].Depth)
+	assert.Equal(t, "Child with code\n```\n- not a bullet\n```", blocks[2].Content)
+
+	assert.Equal(t, "Last block", blocks[3].Content)
+
+	// The blocks tile the whole file.
+	assert.Equal(t, 0, blocks[0].Start)
+
+	for i := 1; i < len(blocks); i++ {
+		assert.Equal(t, blocks[i-1].End, blocks[i].Start)
+	}
+
+	assert.Equal(t, len(text), blocks[3].End)
+}
+
+func TestSplitSourceBlocks_EmptyBulletAndNoPreamble(t *testing.T) {
+	blocks := logseqext.SplitSourceBlocks("-\n- text\n")
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "", blocks[0].Content)
+	assert.Equal(t, "text", blocks[1].Content)
+	assert.Equal(t, "- text\n", "-\n- text\n"[blocks[1].Start:blocks[1].End])
+}
+
+func TestSourceIndent(t *testing.T) {
+	assert.Equal(t, "", logseqext.SourceIndent(logseqext.PreambleDepth))
+	assert.Equal(t, "  ", logseqext.SourceIndent(0))
+	assert.Equal(t, "\t\t  ", logseqext.SourceIndent(2))
+}
@@ -138,23 +138,17 @@ type SectionedUUID struct {
 
 // ExtractSectionedBlockRefUUIDs scans a backlog page and returns every block-ref
 // UUID together with whether it is in the ranked area (above all section dividers)
-// or the unranked area (under any divider whose text matches one of
-// unrankedSectionTexts, case-insensitive substring).
+// or the unranked area (under any top-level block whose text isUnrankedSection accepts).
 //
 // The ranked area is defined as top-level blocks that are NOT section-header
 // blocks and NOT descendants of any section-header block.
-func ExtractSectionedBlockRefUUIDs(page logseq.Page, unrankedSectionTexts []string) []SectionedUUID {
+func ExtractSectionedBlockRefUUIDs(page logseq.Page, isUnrankedSection func(text string) bool) []SectionedUUID {
 	// First pass: find all section-header blocks.
 	sectionHeaders := make(map[*content.Block]bool)
 
 	for _, block := range page.Blocks() {
-		text := BlockContentText(block)
-		for _, headerText := range unrankedSectionTexts {
-			if strings.Contains(strings.ToLower(text), strings.ToLower(headerText)) {
-				sectionHeaders[block] = true
-
-				break
-			}
+		if isUnrankedSection(BlockContentText(block)) {
+			sectionHeaders[block] = true
 		}
 	}
 
@@ -221,3 +215,77 @@ func isDescendantBlock(block, ancestor *content.Block) bool {
 
 	return false
 }
+
+// HasDirectTag tells if a block links to a page, with a hashtag or a [[page ref]] written in the block itself.
+// Tags inherited from the page or from parent blocks don't count.
+func HasDirectTag(block *content.Block, tag string) bool {
+	found := block.Content().FindDeep(func(node content.Node) bool {
+		switch typedNode := node.(type) {
+		case *content.Hashtag:
+			return strings.EqualFold(typedNode.To, tag)
+		case *content.PageLink:
+			return strings.EqualFold(typedNode.To, tag)
+		}
+
+		return false
+	})
+
+	return found != nil
+}
+
+// AddTag appends a hashtag to the first line of a block, unless the block already links to the page.
+func AddTag(block *content.Block, tag string) error {
+	if HasDirectTag(block, tag) {
+		return nil
+	}
+
+	var firstContainer content.HasChildren
+
+	for _, node := range block.Content() {
+		switch typedNode := node.(type) {
+		case *content.Paragraph:
+			firstContainer = typedNode
+		case *content.Heading:
+			firstContainer = typedNode
+		default:
+			continue
+		}
+
+		break
+	}
+
+	if firstContainer == nil {
+		return ErrNoParagraph
+	}
+
+	if last, ok := firstContainer.LastChild().(*content.Text); !ok || !strings.HasSuffix(last.Value, " ") {
+		firstContainer.AddChild(content.NewText(" "))
+	}
+
+	firstContainer.AddChild(content.NewHashtag(tag))
+
+	return nil
+}
+
+// RemoveTag removes the hashtags of a page from a block, with the space before each of them.
+// [[Page refs]] are left alone, they are usually part of a sentence. Returns true if a hashtag was removed.
+func RemoveTag(block *content.Block, tag string) bool {
+	hashtags := block.Content().FilterDeep(func(node content.Node) bool {
+		hashtag, ok := node.(*content.Hashtag)
+
+		return ok && strings.EqualFold(hashtag.To, tag)
+	})
+
+	for _, node := range hashtags {
+		if before, ok := node.PreviousSibling().(*content.Text); ok {
+			before.Value = strings.TrimRight(before.Value, " ")
+			if before.Value == "" {
+				before.RemoveSelf()
+			}
+		}
+
+		node.RemoveSelf()
+	}
+
+	return len(hashtags) > 0
+}
@@ -1,10 +1,13 @@
 package logseqext_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	logseq "github.com/andreoliwa/logseq-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCleanTaskName(t *testing.T) {
@@ -99,3 +102,26 @@ func TestExtractDirectTags_EmptyString(t *testing.T) {
 	result := logseqext.ExtractDirectTags("")
 	assert.Nil(t, result)
 }
+
+func TestAddTag(t *testing.T) {
+	block := parseBlock(t, "TODO Fix the config #work")
+
+	require.NoError(t, logseqext.AddTag(block, "home"))
+	require.NoError(t, logseqext.AddTag(block, "Work"))
+
+	out, err := logseq.AsString(block)
+	require.NoError(t, err)
+	assert.Equal(t, "TODO Fix the config #work #home", strings.TrimSpace(out))
+}
+
+func TestRemoveTag(t *testing.T) {
+	block := parseBlock(t, "TODO Fix the #work config with [[work]] #work")
+
+	assert.True(t, logseqext.RemoveTag(block, "work"))
+	assert.False(t, logseqext.RemoveTag(block, "home"))
+
+	out, err := logseq.AsString(block)
+	require.NoError(t, err)
+	assert.Equal(t, "TODO Fix the config with [[work]]", strings.TrimSpace(out))
+	assert.True(t, logseqext.HasDirectTag(block, "work"))
+}
@@ -8,15 +8,24 @@ import (
 	"github.com/andreoliwa/logseq-go/content"
 )
 
+// Transaction opens pages and saves them together.
+// *logseq.Transaction satisfies it, and so do wrappers that change how pages are written.
+type Transaction interface {
+	OpenPage(title string) (logseq.Page, error)
+	OpenJournal(date time.Time) (logseq.Page, error)
+	Save() error
+}
+
 // AddTaskOptions contains options for adding a task to Logseq.
 type AddTaskOptions struct {
-	Graph     *logseq.Graph
-	Date      time.Time
-	Page      string           // Page name to add the task to (empty = journal)
-	BlockText string           // Partial text to search for in parent blocks
-	Key       string           // Unique key to search for existing task (case-insensitive)
-	Name      string           // Short name of the task
-	TimeNow   func() time.Time // For testing
+	Graph       *logseq.Graph
+	Transaction Transaction // Transaction to add the task with (nil = a new one from Graph)
+	Date        time.Time
+	Page        string           // Page name to add the task to (empty = journal)
+	BlockText   string           // Partial text to search for in parent blocks
+	Key         string           // Unique key to search for existing task (case-insensitive)
+	Name        string           // Short name of the task
+	TimeNow     func() time.Time // For testing
 }
 
 // AddTask adds a task to Logseq.
@@ -25,7 +34,10 @@ type AddTaskOptions struct {
 // If Page is provided, adds to that page. Otherwise, adds to journal for Date.
 // If BlockText is provided, adds as a child of the first block containing that text.
 func AddTask(opts *AddTaskOptions) error {
-	transaction := opts.Graph.NewTransaction()
+	transaction := opts.Transaction
+	if transaction == nil {
+		transaction = opts.Graph.NewTransaction()
+	}
 
 	var targetPage logseq.Page
 
@@ -10,6 +10,7 @@ import (
 	"github.com/andreoliwa/logseq-go/content"
 
 	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 )
 
 // ErrPageIsNil is returned when a page is nil.
@@ -36,7 +37,14 @@ func InsertMarkdown(opts *InsertMarkdownOptions) error {
 		return nil
 	}
 
-	transaction := opts.Graph.NewTransaction()
+	return persist.RetryOnConflict(func() error { //nolint:wrapcheck
+		return insertMarkdown(opts)
+	})
+}
+
+// insertMarkdown opens the target page and inserts the content; it is run again if Logseq saves the page meanwhile.
+func insertMarkdown(opts *InsertMarkdownOptions) error {
+	transaction := persist.NewTransaction(opts.Graph)
 
 	var targetPage logseq.Page
 
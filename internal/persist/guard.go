@@ -0,0 +1,173 @@
+package persist
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrConflict is returned by Transaction.Save when a file changed on disk after the transaction opened it,
+// usually because it was edited in Logseq meanwhile. Nothing is written: the change must be made again
+// on the new contents, see RetryOnConflict.
+var ErrConflict = errors.New("changed on disk since it was opened (edited in Logseq?)")
+
+// conflictAttempts is how many times RetryOnConflict runs a change before giving up.
+const conflictAttempts = 3
+
+// fingerprint identifies the contents of a file when it was opened.
+// The modification time and size are a shortcut: the hash is only compared when one of them changed.
+type fingerprint struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// takeFingerprint reads a file and returns its fingerprint. An empty path is a page without a file yet.
+func takeFingerprint(path string) (fingerprint, error) {
+	var fp fingerprint
+
+	if path == "" {
+		return fp, nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fp, nil
+	}
+
+	if err != nil {
+		return fp, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // graph files chosen by the user
+	if err != nil {
+		return fp, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	fp.exists = true
+	fp.modTime = info.ModTime()
+	fp.size = info.Size()
+	fp.hash = sha256.Sum256(data)
+
+	return fp, nil
+}
+
+// unchanged tells if a file still holds the contents it had when the fingerprint was taken.
+func (f fingerprint) unchanged(path string) (bool, error) {
+	if path == "" {
+		return !f.exists, nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return !f.exists, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+
+	if !f.exists {
+		return false, nil
+	}
+
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return true, nil
+	}
+
+	current, err := takeFingerprint(path)
+	if err != nil {
+		return false, err
+	}
+
+	return current.exists && current.hash == f.hash, nil
+}
+
+// holds tells if the fingerprint was taken from a file with these contents.
+func (f fingerprint) holds(contents string) bool {
+	return f.exists && f.hash == sha256.Sum256([]byte(contents))
+}
+
+// written remembers the files saved by this process, to tell its own saves from the edits made in Logseq.
+//
+//nolint:gochecknoglobals // shared by all the transactions of a run
+var written = &ownWrites{}
+
+type ownWrites struct {
+	mu    sync.Mutex
+	files map[string]fingerprint
+}
+
+// remember takes the fingerprint of a file just written by this process.
+func (o *ownWrites) remember(path string) error {
+	fp, err := takeFingerprint(path)
+	if err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.files == nil {
+		o.files = make(map[string]fingerprint)
+	}
+
+	o.files[abs] = fp
+
+	return nil
+}
+
+// lastWrite returns the fingerprint of the last write of a file by this process.
+func (o *ownWrites) lastWrite(path string) (fingerprint, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fingerprint{}, false //nolint:exhaustruct
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	fp, ok := o.files[abs]
+
+	return fp, ok
+}
+
+// OwnWrite tells if a file still holds what this process last wrote to it (or is still gone, after a removal),
+// so a watcher can tell its own saves from the edits made in Logseq.
+func OwnWrite(path string) bool {
+	own, ok := written.lastWrite(path)
+	if !ok {
+		return false
+	}
+
+	same, err := own.unchanged(path)
+
+	return err == nil && same
+}
+
+// RetryOnConflict runs change until it does not fail with ErrConflict, a few times at most.
+// change must open its pages with a new Transaction on each call, so it is applied again on the files
+// as Logseq left them.
+func RetryOnConflict(change func() error) error {
+	var err error
+
+	for range conflictAttempts {
+		err = change()
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,175 @@
+package persist_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func openTestGraph(t *testing.T, pageContents string) (*logseq.Graph, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	config, err := os.ReadFile(filepath.Join("..", "testdata", "graph-template", "logseq", "config.edn"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logseq"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pages"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), config, 0o600))
+
+	path := filepath.Join(dir, "pages", "guarded.md")
+	require.NoError(t, os.WriteFile(path, []byte(pageContents), 0o600))
+
+	graph, err := logseq.Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, persist.Cleanup()) })
+
+	return graph, path
+}
+
+func TestTransaction_SaveRefusesConcurrentEdit(t *testing.T) {
+	graph, path := openTestGraph(t, "- first\n")
+
+	transaction := persist.NewTransaction(graph)
+
+	page, err := transaction.OpenPage("guarded")
+	require.NoError(t, err)
+
+	// Logseq saves the page while the change is being made.
+	require.NoError(t, os.WriteFile(path, []byte("- first\n- typed in Logseq\n"), 0o600))
+
+	page.AddBlock(content.NewBlock(content.NewText("second")))
+
+	err = transaction.Save()
+	require.ErrorIs(t, err, persist.ErrConflict)
+	assert.Contains(t, err.Error(), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- first\n- typed in Logseq\n", string(data), "the edit made in Logseq is kept")
+}
+
+func TestTransaction_SaveAfterOwnWrite(t *testing.T) {
+	graph, path := openTestGraph(t, "- first\n")
+
+	require.NoError(t, persist.WriteFile(path, []byte("- first\n- written by lqd\n"), 0o600))
+
+	transaction := persist.NewTransaction(graph)
+
+	page, err := transaction.OpenPage("guarded")
+	require.NoError(t, err)
+
+	page.AddBlock(content.NewBlock(content.NewText("second")))
+	require.NoError(t, transaction.Save(), "a write made by lqd before the page was opened is not a conflict")
+}
+
+func TestTransaction_SaveAfterAnotherTransaction(t *testing.T) {
+	graph, path := openTestGraph(t, "- first\n")
+
+	change := func(transaction *persist.Transaction, text string) error {
+		page, err := transaction.OpenPage("guarded")
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		page.AddBlock(content.NewBlock(content.NewText(text)))
+
+		return transaction.Save() //nolint:wrapcheck
+	}
+
+	earlier := persist.NewTransaction(graph)
+	later := persist.NewTransaction(graph)
+
+	pageOfLater, err := later.OpenPage("guarded")
+	require.NoError(t, err)
+
+	require.NoError(t, change(earlier, "second"))
+
+	pageOfLater.AddBlock(content.NewBlock(content.NewText("third")))
+	require.ErrorIs(t, later.Save(), persist.ErrConflict, "the page was saved by lqd after it was opened")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- first\n- second\n", string(data), "the earlier save is kept")
+
+	require.NoError(t, persist.RetryOnConflict(func() error {
+		return change(persist.NewTransaction(graph), "third")
+	}))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- first\n- second\n- third\n", string(data))
+}
+
+func TestTransaction_SaveRemovesTheStagingGraph(t *testing.T) {
+	graph, path := openTestGraph(t, "- first\n")
+
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	transaction := persist.NewTransaction(graph)
+
+	page, err := transaction.OpenPage("guarded")
+	require.NoError(t, err)
+
+	staged, err := filepath.Glob(filepath.Join(tempDir, "lqd-staging-*"))
+	require.NoError(t, err)
+	require.Len(t, staged, 1, "the page is opened from a staging copy")
+
+	page.AddBlock(content.NewBlock(content.NewText("second")))
+	require.NoError(t, transaction.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- first\n- second\n", string(data))
+	assert.NoDirExists(t, staged[0], "removed once saved, not when the command ends")
+}
+
+func TestOwnWrite(t *testing.T) {
+	_, path := openTestGraph(t, "- first\n")
+
+	assert.False(t, persist.OwnWrite(path), "written by someone else")
+
+	require.NoError(t, persist.WriteFile(path, []byte("- first\n- second\n"), 0o600))
+	assert.True(t, persist.OwnWrite(path))
+
+	require.NoError(t, os.WriteFile(path, []byte("- first\n- typed in Logseq\n"), 0o600))
+	assert.False(t, persist.OwnWrite(path), "edited after the own write")
+
+	require.NoError(t, persist.Remove(path))
+	assert.True(t, persist.OwnWrite(path), "removed by this process")
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	calls := 0
+
+	err := persist.RetryOnConflict(func() error {
+		calls++
+		if calls < 2 {
+			return persist.ErrConflict
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+
+	err = persist.RetryOnConflict(func() error {
+		calls++
+
+		return persist.ErrConflict
+	})
+	require.ErrorIs(t, err, persist.ErrConflict)
+	assert.Equal(t, 3, calls, "gives up after a few attempts")
+}
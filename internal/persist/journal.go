@@ -0,0 +1,365 @@
+package persist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownRun is returned when a run ID is not in the undo journal.
+var ErrUnknownRun = errors.New("unknown run")
+
+// ErrNothingToUndo is returned when the undo journal has no run left to undo.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrAlreadyUndone is returned when undoing a run twice.
+var ErrAlreadyUndone = errors.New("run already undone")
+
+// ErrChangedSinceRun is returned when a file was modified after the run wrote it, so undoing would lose that edit.
+var ErrChangedSinceRun = errors.New("file changed after the run")
+
+// runFile is the name of the file describing a run, inside its directory.
+const runFile = "run.json"
+
+// Run is one lqd command recorded in the undo journal, with the files it changed.
+type Run struct {
+	ID      string       `json:"id"`
+	Time    time.Time    `json:"time"`
+	Command string       `json:"command"`
+	Files   []FileChange `json:"files"`
+	// UndoneBy is the ID of the run that undid this one ("-" when it was undone without a journal).
+	UndoneBy string `json:"undone_by,omitempty"`
+	// Undoes is the ID of the run this one undid.
+	Undoes string `json:"undoes,omitempty"`
+}
+
+// FileChange holds the contents of a file before the first write of a run and after its last one.
+type FileChange struct {
+	Path    string `json:"path"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Created bool   `json:"created,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// journal records the writes of the current process.
+//
+//nolint:gochecknoglobals // one journal per run, started by the root command
+var journal = &recorder{}
+
+type recorder struct {
+	mu   sync.Mutex
+	dir  string
+	keep int
+	run  *Run
+}
+
+// StartJournal records every following write in the undo journal kept in dir, under a new run ID.
+// command is the command line shown by "lqd history". Only the newest keep runs are kept.
+func StartJournal(dir string, keep int, command string) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	now := time.Now()
+
+	journal.dir = dir
+	journal.keep = keep
+	journal.run = &Run{ID: newRunID(now), Time: now, Command: command} //nolint:exhaustruct
+}
+
+// NextRun starts a new run in the undo journal, with the directory, limit and command of the current one.
+// A command that keeps running (e.g. lqd backlog --watch) records each round of writes as a run of its own,
+// so "lqd undo" restores the last round rather than everything since the command started.
+func NextRun() {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if journal.run == nil {
+		return
+	}
+
+	now := time.Now()
+	journal.run = &Run{ID: newRunID(now), Time: now, Command: journal.run.Command} //nolint:exhaustruct
+}
+
+// RunID returns the ID of the current run, or an empty string if the journal was not started.
+func RunID() string {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if journal.run == nil {
+		return ""
+	}
+
+	return journal.run.ID
+}
+
+// active tells if writes are being recorded.
+func (r *recorder) active() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.run != nil && r.dir != ""
+}
+
+// newRunID returns an ID made of the start time and a random suffix, short enough to type.
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 2) //nolint:mnd
+	_, _ = rand.Read(suffix)
+
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// record adds a write to the current run. The first write of a file keeps its original contents.
+// Writes that change nothing are not recorded.
+func (r *recorder) record(path string, before, after string, existed, exists bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.run == nil || r.dir == "" {
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	index := -1
+
+	for i, file := range r.run.Files {
+		if file.Path == abs {
+			index = i
+
+			break
+		}
+	}
+
+	if index < 0 {
+		if before == after && existed == exists {
+			return nil
+		}
+
+		r.run.Files = append(r.run.Files, FileChange{Path: abs, Before: before, After: "", Created: !existed}) //nolint:exhaustruct,lll
+		index = len(r.run.Files) - 1
+	}
+
+	r.run.Files[index].After = after
+	r.run.Files[index].Deleted = !exists
+
+	if len(r.run.Files) == 1 && index == 0 {
+		r.prune()
+	}
+
+	return writeRun(r.dir, r.run)
+}
+
+// prune removes the oldest runs, leaving room for the current one.
+func (r *recorder) prune() {
+	if r.keep <= 0 {
+		return
+	}
+
+	runs, err := Runs(r.dir)
+	if err != nil {
+		return
+	}
+
+	for i := r.keep - 1; i < len(runs); i++ {
+		_ = os.RemoveAll(filepath.Join(r.dir, runs[i].ID))
+	}
+}
+
+// writeRun saves the description of a run to the journal.
+func writeRun(dir string, run *Run) error {
+	runDir := filepath.Join(dir, run.ID)
+
+	err := os.MkdirAll(runDir, dirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create the undo journal: %w", err)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %w", run.ID, err)
+	}
+
+	err = os.WriteFile(filepath.Join(runDir, runFile), data, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to write the undo journal: %w", err)
+	}
+
+	return nil
+}
+
+// Runs returns the runs of the undo journal kept in dir, newest first.
+func Runs(dir string) ([]Run, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the undo journal: %w", err)
+	}
+
+	runs := make([]Run, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		run, err := ReadRun(dir, entry.Name())
+		if err != nil {
+			continue // a run interrupted before its first write, or a stray directory
+		}
+
+		runs = append(runs, *run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Time.After(runs[j].Time) })
+
+	return runs, nil
+}
+
+// ReadRun returns a run of the undo journal kept in dir.
+func ReadRun(dir, id string) (*Run, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRun, id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, id, runFile)) //nolint:gosec // the ID was checked above
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRun, id)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", id, err)
+	}
+
+	var run Run
+
+	err = json.Unmarshal(data, &run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode run %s: %w", id, err)
+	}
+
+	return &run, nil
+}
+
+// Undo restores the files of a run to their contents before it, and returns the run.
+// An empty id undoes the newest run that was not undone yet, skipping the runs of "lqd undo" itself
+// (undoing one of them by its ID redoes what it undid).
+// Files modified after the run are not touched and ErrChangedSinceRun is returned, unless force is set.
+// The restored files are written like any other change: they are recorded in the journal of the current run,
+// and only printed in dry-run mode.
+func Undo(dir, id string, force bool) (*Run, error) {
+	run, err := runToUndo(dir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if run.UndoneBy != "" && !force {
+		return nil, fmt.Errorf("%w: %s (by %s)", ErrAlreadyUndone, run.ID, run.UndoneBy)
+	}
+
+	if !force {
+		changed := changedSinceRun(run)
+		if len(changed) > 0 {
+			return nil, fmt.Errorf("%w: %s (use --force to undo anyway)", ErrChangedSinceRun, strings.Join(changed, ", "))
+		}
+	}
+
+	if !DryRun() {
+		journal.mu.Lock()
+		if journal.run != nil {
+			journal.run.Undoes = run.ID
+		}
+		journal.mu.Unlock()
+	}
+
+	for _, file := range run.Files {
+		err = restore(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if DryRun() {
+		return run, nil
+	}
+
+	run.UndoneBy = RunID()
+	if run.UndoneBy == "" {
+		run.UndoneBy = "-"
+	}
+
+	return run, writeRun(dir, run)
+}
+
+// runToUndo returns the run with the given ID, or the newest run that is not an undo and was not undone yet.
+func runToUndo(dir, id string) (*Run, error) {
+	if id != "" {
+		return ReadRun(dir, id)
+	}
+
+	runs, err := Runs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	current := RunID()
+
+	for _, run := range runs {
+		if run.UndoneBy == "" && run.Undoes == "" && run.ID != current {
+			return &run, nil
+		}
+	}
+
+	return nil, ErrNothingToUndo
+}
+
+// changedSinceRun lists the files of a run that no longer hold what the run left in them.
+func changedSinceRun(run *Run) []string {
+	var changed []string
+
+	for _, file := range run.Files {
+		data, err := ReadFile(file.Path)
+		exists := err == nil
+
+		if exists == file.Deleted || (exists && string(data) != file.After) {
+			changed = append(changed, file.Path)
+		}
+	}
+
+	return changed
+}
+
+// restore writes back the contents of a file before the run.
+func restore(file FileChange) error {
+	if file.Created {
+		err := Remove(file.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	perm := os.FileMode(filePerm)
+	if info, err := os.Stat(file.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	return WriteFile(file.Path, []byte(file.Before), perm)
+}
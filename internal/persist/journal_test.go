@@ -0,0 +1,158 @@
+package persist_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func startJournal(t *testing.T, command string) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "undo")
+
+	persist.StartJournal(dir, 10, command)
+	t.Cleanup(func() { persist.StartJournal("", 0, "") })
+
+	return dir
+}
+
+func TestJournal_RecordsFirstAndLastContents(t *testing.T) {
+	dir := startJournal(t, "lqd backlog")
+
+	graphDir := t.TempDir()
+	page := filepath.Join(graphDir, "page.md")
+	created := filepath.Join(graphDir, "new.md")
+	require.NoError(t, os.WriteFile(page, []byte("- original\n"), 0o600))
+
+	require.NoError(t, persist.WriteFile(page, []byte("- first\n"), 0o600))
+	require.NoError(t, persist.WriteFile(page, []byte("- second\n"), 0o600))
+	require.NoError(t, persist.WriteFile(created, []byte("- new\n"), 0o600))
+
+	runs, err := persist.Runs(dir)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, persist.RunID(), runs[0].ID)
+	assert.Equal(t, "lqd backlog", runs[0].Command)
+	assert.Equal(t, []persist.FileChange{
+		{Path: page, Before: "- original\n", After: "- second\n", Created: false, Deleted: false},
+		{Path: created, Before: "", After: "- new\n", Created: true, Deleted: false},
+	}, runs[0].Files)
+}
+
+func TestJournal_UnchangedWriteIsNotARun(t *testing.T) {
+	dir := startJournal(t, "lqd tidy-up")
+
+	page := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(page, []byte("- same\n"), 0o600))
+	require.NoError(t, persist.WriteFile(page, []byte("- same\n"), 0o600))
+
+	runs, err := persist.Runs(dir)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestJournal_NextRun(t *testing.T) {
+	dir := startJournal(t, "lqd backlog --watch")
+
+	page := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(page, []byte("- original\n"), 0o600))
+	require.NoError(t, persist.WriteFile(page, []byte("- first round\n"), 0o600))
+
+	firstRun := persist.RunID()
+
+	persist.NextRun()
+	require.NoError(t, persist.WriteFile(page, []byte("- second round\n"), 0o600))
+
+	runs, err := persist.Runs(dir)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.NotEqual(t, firstRun, runs[0].ID)
+	assert.Equal(t, persist.RunID(), runs[0].ID)
+	assert.Equal(t, "lqd backlog --watch", runs[0].Command)
+	assert.Equal(t, []persist.FileChange{
+		{Path: page, Before: "- first round\n", After: "- second round\n", Created: false, Deleted: false},
+	}, runs[0].Files, "the new run starts from the file as the previous one left it")
+	assert.Equal(t, firstRun, runs[1].ID)
+	assert.Equal(t, "- first round\n", runs[1].Files[0].After)
+}
+
+func TestUndo(t *testing.T) {
+	dir := startJournal(t, "lqd md")
+
+	graphDir := t.TempDir()
+	page := filepath.Join(graphDir, "page.md")
+	created := filepath.Join(graphDir, "new.md")
+	require.NoError(t, os.WriteFile(page, []byte("- original\n"), 0o600))
+	require.NoError(t, persist.WriteFile(page, []byte("- changed\n"), 0o600))
+	require.NoError(t, persist.WriteFile(created, []byte("- new\n"), 0o600))
+
+	runID := persist.RunID()
+
+	// The undo is a run of its own.
+	persist.StartJournal(dir, 10, "lqd undo")
+
+	run, err := persist.Undo(dir, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, runID, run.ID)
+
+	data, err := os.ReadFile(page)
+	require.NoError(t, err)
+	assert.Equal(t, "- original\n", string(data))
+	assert.NoFileExists(t, created)
+
+	undone, err := persist.ReadRun(dir, runID)
+	require.NoError(t, err)
+	assert.Equal(t, persist.RunID(), undone.UndoneBy)
+
+	_, err = persist.Undo(dir, runID, false)
+	require.ErrorIs(t, err, persist.ErrAlreadyUndone)
+
+	// The undo run itself is skipped when no ID is given.
+	_, err = persist.Undo(dir, "", false)
+	require.ErrorIs(t, err, persist.ErrNothingToUndo)
+}
+
+func TestUndo_RefusesFilesChangedAfterTheRun(t *testing.T) {
+	dir := startJournal(t, "lqd groom")
+
+	page := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(page, []byte("- original\n"), 0o600))
+	require.NoError(t, persist.WriteFile(page, []byte("- groomed\n"), 0o600))
+
+	runID := persist.RunID()
+
+	// An edit made in Logseq after the run.
+	require.NoError(t, os.WriteFile(page, []byte("- edited in Logseq\n"), 0o600))
+
+	persist.StartJournal(dir, 10, "lqd undo")
+
+	_, err := persist.Undo(dir, runID, false)
+	require.ErrorIs(t, err, persist.ErrChangedSinceRun)
+
+	data, err := os.ReadFile(page)
+	require.NoError(t, err)
+	assert.Equal(t, "- edited in Logseq\n", string(data))
+
+	_, err = persist.Undo(dir, runID, true)
+	require.NoError(t, err)
+
+	data, err = os.ReadFile(page)
+	require.NoError(t, err)
+	assert.Equal(t, "- original\n", string(data))
+}
+
+func TestUndo_UnknownRun(t *testing.T) {
+	dir := startJournal(t, "lqd undo")
+
+	_, err := persist.Undo(dir, "nope", false)
+	require.ErrorIs(t, err, persist.ErrUnknownRun)
+
+	_, err = persist.Undo(dir, "../escape", false)
+	require.ErrorIs(t, err, persist.ErrUnknownRun)
+}
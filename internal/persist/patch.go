@@ -0,0 +1,63 @@
+package persist
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+// Patch returns what to write to a page file: after, the page as logseq-go saved it, with each block the command
+// did not change put back byte for byte as it was in before. Only the changed blocks are rewritten,
+// keeping version-controlled graphs free of formatting noise.
+//
+// rendered is before as logseq-go saves it without any change: the blocks of after found unchanged in rendered
+// are the ones the command left alone, and rendered also tells which block of before each one comes from.
+func Patch(before, rendered, after string) string {
+	original := logseqext.SplitSourceBlocks(before)
+	unchanged := logseqext.SplitSourceBlocks(rendered)
+	final := logseqext.SplitSourceBlocks(after)
+
+	originalSources, _ := sourceBlocks(before)
+	unchangedSources, _ := sourceBlocks(rendered)
+	finalSources, _ := sourceBlocks(after)
+
+	// source maps the blocks of rendered to the blocks of before they were rendered from.
+	// Blocks rewritten by the rendering one for one (a replaced span of the same length) are mapped too.
+	source := make(map[int]int, len(unchanged))
+
+	for _, code := range difflib.NewMatcherWithJunk(originalSources, unchangedSources, false, nil).GetOpCodes() {
+		if code.Tag == 'e' || (code.Tag == 'r' && code.I2-code.I1 == code.J2-code.J1) {
+			for k := range code.J2 - code.J1 {
+				source[code.J1+k] = code.I1 + k
+			}
+		}
+	}
+
+	var (
+		builder     strings.Builder
+		lineStarted bool // the last chunk written did not end with a line break
+	)
+
+	for _, code := range difflib.NewMatcherWithJunk(unchangedSources, finalSources, false, nil).GetOpCodes() {
+		for j := code.J1; j < code.J2; j++ {
+			chunk := after[final[j].Start:final[j].End]
+
+			if i, ok := source[code.I1+j-code.J1]; ok && code.Tag == 'e' {
+				chunk = before[original[i].Start:original[i].End]
+			}
+
+			// The last line of the file may have no line break, and be followed by a block now.
+			if lineStarted {
+				builder.WriteString("\n")
+			}
+
+			builder.WriteString(chunk)
+
+			lineStarted = chunk != "" && !strings.HasSuffix(chunk, "\n")
+		}
+	}
+
+	return builder.String()
+}
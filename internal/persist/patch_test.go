@@ -0,0 +1,61 @@
+package persist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		rendered string
+		after    string
+		want     string
+	}{
+		{
+			name:     "only the changed block is rewritten",
+			before:   "title:: Page\n\n- a [link]\n- b\n\t- c\n",
+			rendered: "title:: Page\n\n- a \\[link\\]\n- b\n\t- c\n",
+			after:    "title:: Page\n\n- a \\[link\\]\n- DONE b\n\t- c\n",
+			want:     "title:: Page\n\n- a [link]\n- DONE b\n\t- c\n",
+		},
+		{
+			name:     "block added after a last line without line break",
+			before:   "- a [link]\n- b",
+			rendered: "- a \\[link\\]\n- b\n",
+			after:    "- a \\[link\\]\n- b\n- new\n",
+			want:     "- a [link]\n- b\n- new\n",
+		},
+		{
+			name:     "block removed",
+			before:   "- a  spaced\n- b\n",
+			rendered: "- a spaced\n- b\n",
+			after:    "- b\n",
+			want:     "- b\n",
+		},
+		{
+			name:     "indentation of untouched blocks is kept",
+			before:   "- a\n  - child\n- b\n",
+			rendered: "- a\n\t- child\n- b\n",
+			after:    "- a\n\t- child\n- b\n- c\n",
+			want:     "- a\n  - child\n- b\n- c\n",
+		},
+		{
+			name:     "nothing changed",
+			before:   "- a [link]\n",
+			rendered: "- a \\[link\\]\n",
+			after:    "- a \\[link\\]\n",
+			want:     "- a [link]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, persist.Patch(tt.before, tt.rendered, tt.after))
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Package persist writes changes to the graph files.
+// Commands save pages through a Transaction and write raw files through WriteFile,
+// so a whole run can be previewed with --dry-run: nothing reaches the graph, and a unified diff
+// of each file is printed instead.
+package persist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ErrDryRun is returned by operations that cannot be simulated in dry-run mode, like asking Logseq to write a file.
+var ErrDryRun = errors.New("not written in dry-run mode")
+
+const (
+	// diffContext is the number of unchanged lines shown around each change.
+	diffContext = 3
+
+	dirPerm  = 0o700
+	filePerm = 0o600
+)
+
+// state holds the dry-run mode of the current process.
+//
+//nolint:gochecknoglobals // the dry-run mode is set once per run by the --dry-run flag
+var state = &dryRun{}
+
+// dryRun keeps the scratch copy of the files changed during a dry run.
+// Each file written is kept under root at its absolute path, and each file removed in removed;
+// later reads in the same run see the previous changes.
+type dryRun struct {
+	mu      sync.Mutex // the dashboard serves requests concurrently
+	out     io.Writer
+	root    string
+	removed map[string]bool // scratch paths of the files removed by the dry run
+}
+
+// SetDryRun turns the dry-run mode on, writing the diffs to out. A nil out turns it off.
+func SetDryRun(out io.Writer) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.out = out
+}
+
+// DryRun tells if changes are printed instead of written.
+func DryRun() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.out != nil
+}
+
+// Cleanup removes the temporary files of the run: the staging copies of the saved pages,
+// and the scratch copy of the files changed during a dry run.
+func Cleanup() error {
+	err := staging.removeAll()
+	if err != nil {
+		return err
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.root == "" {
+		return nil
+	}
+
+	err = os.RemoveAll(state.root)
+	if err != nil {
+		return fmt.Errorf("failed to remove the dry-run files: %w", err)
+	}
+
+	state.root = ""
+	state.removed = nil
+
+	return nil
+}
+
+// ReadFile reads the named file, like os.ReadFile.
+// In dry-run mode, it returns the contents the dry run left, so changes build on each other.
+func ReadFile(path string) ([]byte, error) {
+	if DryRun() {
+		state.mu.Lock()
+		scratch, err := state.scratchPath(path)
+		removed := state.removed[scratch]
+		state.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if removed {
+			return nil, fmt.Errorf("failed to read %s: %w", path, fs.ErrNotExist)
+		}
+
+		data, err := os.ReadFile(scratch)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // graph files chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// WriteFile writes data to the named file, like os.WriteFile, recording the change in the undo journal.
+// In dry-run mode, it prints the diff between the current and the new contents instead.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if !DryRun() {
+		before, existed := readForJournal(path)
+
+		err := os.WriteFile(path, data, perm)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		err = written.remember(path)
+		if err != nil {
+			return err
+		}
+
+		return journal.record(path, before, string(data), existed, true)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	scratch, err := state.scratchPath(path)
+	if err != nil {
+		return err
+	}
+
+	before, err := state.read(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(scratch), dirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create the dry-run directory: %w", err)
+	}
+
+	err = os.WriteFile(scratch, data, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to write the dry-run copy of %s: %w", path, err)
+	}
+
+	delete(state.removed, scratch)
+
+	return state.printDiff(path, before, string(data))
+}
+
+// Remove removes the named file, like os.Remove, recording the change in the undo journal.
+// In dry-run mode, it prints the diff of the whole file being deleted instead,
+// and later reads in the same run find the file missing.
+func Remove(path string) error {
+	if !DryRun() {
+		before, existed := readForJournal(path)
+
+		err := os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+
+		err = written.remember(path)
+		if err != nil {
+			return err
+		}
+
+		return journal.record(path, before, "", existed, false)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	before, err := state.read(path)
+	if err != nil {
+		return err
+	}
+
+	scratch, err := state.scratchPath(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(scratch)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove the dry-run copy of %s: %w", path, err)
+	}
+
+	if state.removed == nil {
+		state.removed = make(map[string]bool)
+	}
+
+	state.removed[scratch] = true
+
+	return state.printDiff(path, before, "")
+}
+
+// removedByDryRun tells if the dry run removed a file, see Remove.
+func removedByDryRun(path string) bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	scratch, err := state.scratchPath(path)
+
+	return err == nil && state.removed[scratch]
+}
+
+// readForJournal returns the contents of a file about to be written, when the undo journal needs them.
+func readForJournal(path string) (string, bool) {
+	if !journal.active() {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // graph files chosen by the user
+
+	return string(data), err == nil
+}
+
+// scratchPath returns where the dry-run copy of a file is kept, creating the scratch directory if needed.
+func (d *dryRun) scratchPath(path string) (string, error) {
+	if d.root == "" {
+		root, err := os.MkdirTemp("", "lqd-dry-run-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create the dry-run directory: %w", err)
+		}
+
+		d.root = root
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	return filepath.Join(d.root, strings.TrimPrefix(abs, filepath.VolumeName(abs))), nil
+}
+
+// read returns the contents of a file as the dry run left it: the scratch copy if there is one,
+// otherwise the file itself. A missing file, or one the dry run removed, is empty.
+func (d *dryRun) read(path string) (string, error) {
+	scratch, err := d.scratchPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if d.removed[scratch] {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(scratch)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = os.ReadFile(path) //nolint:gosec // graph files chosen by the user
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return string(data), nil
+}
+
+// printDiff prints a unified diff of a file, if its contents changed.
+func (d *dryRun) printDiff(path, before, after string) error {
+	if before == after {
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{ //nolint:exhaustruct
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: path,
+		ToFile:   path,
+		Context:  diffContext,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", path, err)
+	}
+
+	_, err = io.WriteString(d.out, diff)
+	if err != nil {
+		return fmt.Errorf("failed to print the diff of %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// splitLines splits text into lines that keep their line break, as the diff expects.
+// A missing break on the last line is added, so it doesn't show up as a change of its own.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n"
+
+	return lines
+}
@@ -0,0 +1,140 @@
+package persist_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreoliwa/logseq-go"
+	"github.com/andreoliwa/logseq-go/content"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func startDryRun(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	persist.SetDryRun(&out)
+	t.Cleanup(func() {
+		persist.SetDryRun(nil)
+		require.NoError(t, persist.Cleanup())
+	})
+
+	return &out
+}
+
+func TestWriteFile_DryRun(t *testing.T) {
+	out := startDryRun(t)
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(path, []byte("- one\n- two\n"), 0o600))
+
+	require.NoError(t, persist.WriteFile(path, []byte("- one\n- 2\n"), 0o600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- one\n- two\n", string(data), "the file is not written")
+	assert.Equal(t, "--- "+path+"\n+++ "+path+"\n@@ -1,2 +1,2 @@\n - one\n-- two\n+- 2\n", out.String())
+
+	// Later changes build on the dry-run contents.
+	out.Reset()
+
+	changed, err := persist.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- one\n- 2\n", string(changed))
+
+	require.NoError(t, persist.WriteFile(path, []byte("- one\n- 2\n- three\n"), 0o600))
+	assert.Contains(t, out.String(), "@@ -1,2 +1,3 @@\n - one\n - 2\n+- three\n")
+}
+
+func TestWriteFile_NoChangeNoDiff(t *testing.T) {
+	out := startDryRun(t)
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(path, []byte("- same\n"), 0o600))
+
+	require.NoError(t, persist.WriteFile(path, []byte("- same\n"), 0o600))
+	assert.Empty(t, out.String())
+}
+
+func TestRemove_DryRun(t *testing.T) {
+	out := startDryRun(t)
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(path, []byte("- gone\n"), 0o600))
+
+	require.NoError(t, persist.Remove(path))
+	assert.FileExists(t, path)
+	assert.Contains(t, out.String(), "@@ -1 +0,0 @@\n-- gone\n")
+}
+
+func TestWriteFile_Writes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+
+	require.NoError(t, persist.WriteFile(path, []byte("- written\n"), 0o600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- written\n", string(data))
+}
+
+func TestTransaction_DryRun(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := os.ReadFile(filepath.Join("..", "testdata", "graph-template", "logseq", "config.edn"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logseq"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pages"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), config, 0o600))
+
+	path := filepath.Join(dir, "pages", "dry run.md")
+	require.NoError(t, os.WriteFile(path, []byte("- first\n"), 0o600))
+
+	graph, err := logseq.Open(context.Background(), dir)
+	require.NoError(t, err)
+
+	out := startDryRun(t)
+
+	transaction := persist.NewTransaction(graph)
+
+	page, err := transaction.OpenPage("dry run")
+	require.NoError(t, err)
+
+	page.AddBlock(content.NewBlock(content.NewText("second")))
+	require.NoError(t, transaction.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- first\n", string(data), "the page is not written")
+	assert.Contains(t, out.String(), "+++ "+path+"\n")
+	assert.Contains(t, out.String(), "+- second")
+}
+
+func TestRemove_DryRunLaterReadsSeeNoFile(t *testing.T) {
+	out := startDryRun(t)
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	require.NoError(t, os.WriteFile(path, []byte("- gone\n"), 0o600))
+
+	require.NoError(t, persist.WriteFile(path, []byte("- changed\n"), 0o600))
+	require.NoError(t, persist.Remove(path))
+	assert.Contains(t, out.String(), "@@ -1 +0,0 @@\n-- changed\n", "the removal builds on the dry-run contents")
+
+	_, err := persist.ReadFile(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Writing the file again creates it from nothing.
+	out.Reset()
+	require.NoError(t, persist.WriteFile(path, []byte("- back\n"), 0o600))
+	assert.Contains(t, out.String(), "@@ -0,0 +1 @@\n+- back\n")
+
+	data, err := persist.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- back\n", string(data))
+}
@@ -0,0 +1,129 @@
+package persist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andreoliwa/logseq-go"
+)
+
+// staging keeps the directories where transactions open and save their pages before the graph is written.
+//
+//nolint:gochecknoglobals // removed by Cleanup when the command ends
+var staging = &stagingDirs{}
+
+type stagingDirs struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+// newStagingGraph creates an empty copy of a graph, with its config only.
+// Pages are copied to it when a transaction opens them, so logseq-go renders them there
+// and the result can be checked before it reaches the graph.
+func newStagingGraph(graph *logseq.Graph) (*logseq.Graph, error) {
+	dir, err := os.MkdirTemp("", "lqd-staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the staging directory: %w", err)
+	}
+
+	staging.mu.Lock()
+	staging.dirs = append(staging.dirs, dir)
+	staging.mu.Unlock()
+
+	for _, sub := range []string{"logseq", "pages", "journals"} {
+		err = os.MkdirAll(filepath.Join(dir, sub), dirPerm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the staging graph: %w", err)
+		}
+	}
+
+	config, err := ReadFile(filepath.Join(graph.Directory(), "logseq", "config.edn"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), config, filePerm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy the graph config: %w", err)
+		}
+	}
+
+	stagingGraph, err := logseq.Open(context.Background(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the staging graph: %w", err)
+	}
+
+	return stagingGraph, nil
+}
+
+// stagedPath returns where the copy of a graph file is kept in the staging graph.
+// Files outside the graph directory are kept apart, under a name made unique by their path.
+func stagedPath(graphDir, stagingDir, path string) (string, error) {
+	absGraph, err := filepath.Abs(graphDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the graph directory: %w", err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(absGraph, abs)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join(stagingDir, rel), nil
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+
+	return filepath.Join(stagingDir, "external", hex.EncodeToString(sum[:4])+"-"+filepath.Base(abs)), nil
+}
+
+// graphPath returns the graph file a file of the staging graph stands for.
+func graphPath(graphDir, stagingDir, staged string) (string, error) {
+	rel, err := filepath.Rel(stagingDir, staged)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", staged, err)
+	}
+
+	return filepath.Join(graphDir, rel), nil
+}
+
+// remove deletes a staging directory; one that cannot be deleted is left for removeAll.
+func (s *stagingDirs) remove(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if os.RemoveAll(dir) == nil {
+		s.dirs = slices.DeleteFunc(s.dirs, func(kept string) bool { return kept == dir })
+	}
+}
+
+// removeAll deletes the staging directories.
+func (s *stagingDirs) removeAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	for _, dir := range s.dirs {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove the staging directory: %w", err))
+		}
+	}
+
+	s.dirs = nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,519 @@
+package persist
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+	"github.com/andreoliwa/logseq-go"
+)
+
+// pagePerm is the mode of the page files created by a transaction.
+const pagePerm = 0o644
+
+// Transaction wraps a logseq.Transaction, remembering the files it opens.
+// The pages are opened from a staging copy of the graph, where logseq-go saves them;
+// Save then checks the result before writing it to the graph with WriteFile, so a dry run prints it instead.
+// Save refuses to overwrite a file that changed on disk after it was opened (ErrConflict),
+// or to rewrite blocks the command did not change (ErrUnintendedRewrite).
+// Save ends the transaction: the staging copy is removed, and the pages opened before are not saved again.
+type Transaction struct {
+	original *logseq.Graph
+	staging  *logseq.Graph
+	tx       *logseq.Transaction
+	files    []*openedFile
+}
+
+// openedFile is a page opened by a transaction.
+type openedFile struct {
+	// locate returns the graph file of the page, or an empty string if it has no file yet.
+	locate func() (string, error)
+	// stage returns the copy of the page in the staging graph, once it has one.
+	stage    func() (string, error)
+	opened   fingerprint
+	before   string // the contents of the graph file when it was opened
+	rendered string // the same contents, as logseq-go writes them back without any change
+}
+
+// NewTransaction starts a transaction on the graph.
+// The underlying logseq.Transaction is only created when the first page is opened.
+func NewTransaction(graph *logseq.Graph) *Transaction {
+	return &Transaction{original: graph} //nolint:exhaustruct
+}
+
+// begin creates the staging graph and the underlying transaction on it.
+func (t *Transaction) begin() error {
+	if t.tx != nil {
+		return nil
+	}
+
+	stagingGraph, err := newStagingGraph(t.original)
+	if err != nil {
+		return err
+	}
+
+	t.staging = stagingGraph
+	t.tx = stagingGraph.NewTransaction()
+
+	return nil
+}
+
+// OpenPage opens a page by its title.
+func (t *Transaction) OpenPage(title string) (logseq.Page, error) {
+	err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.track(
+		func() (string, error) { return locatePage(t.original.Directory(), title) },
+		func() (string, error) { return pageFile(t.staging.Directory(), title) },
+		func(tx *logseq.Transaction) error {
+			_, err := tx.OpenPage(title)
+
+			return err //nolint:wrapcheck
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tx.OpenPage(title) //nolint:wrapcheck
+}
+
+// OpenJournal opens the journal page of a date.
+func (t *Transaction) OpenJournal(date time.Time) (logseq.Page, error) {
+	err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	journalPath := func(graph *logseq.Graph) (string, error) {
+		path, err := graph.JournalPath(date)
+		if err != nil {
+			return "", fmt.Errorf("failed to find the journal of %s: %w", date.Format(time.DateOnly), err)
+		}
+
+		return path, nil
+	}
+
+	err = t.track(
+		func() (string, error) {
+			path, err := journalPath(t.original)
+			if err != nil {
+				return "", err
+			}
+
+			return existingFile(path)
+		},
+		func() (string, error) { return journalPath(t.staging) },
+		func(tx *logseq.Transaction) error {
+			_, err := tx.OpenJournal(date)
+
+			return err //nolint:wrapcheck
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tx.OpenJournal(date) //nolint:wrapcheck
+}
+
+// OpenViaPath opens a page by the path of its file.
+func (t *Transaction) OpenViaPath(path string) (logseq.Page, error) {
+	err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := stagedPath(t.original.Directory(), t.staging.Directory(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.track(
+		func() (string, error) { return existingFile(path) },
+		func() (string, error) { return staged, nil },
+		func(tx *logseq.Transaction) error {
+			_, err := tx.OpenViaPath(staged)
+
+			return err //nolint:wrapcheck
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tx.OpenViaPath(staged) //nolint:wrapcheck
+}
+
+// track copies a page about to be opened to the staging graph, remembering the fingerprint of its file
+// and how logseq-go renders it untouched: open renders it in a transaction of its own.
+func (t *Transaction) track(locate, stage func() (string, error), open func(*logseq.Transaction) error) error {
+	file := &openedFile{locate: locate, stage: stage} //nolint:exhaustruct
+
+	path, err := locate()
+	if err != nil {
+		return err
+	}
+
+	file.opened, err = takeFingerprint(path)
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		staged, err := stagedPath(t.original.Directory(), t.staging.Directory(), path)
+		if err != nil {
+			return err
+		}
+
+		// The graph file may have been written by this run already: stage what it holds now.
+		data, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		file.before = string(data)
+		file.stage = func() (string, error) { return staged, nil }
+
+		file.rendered, err = t.render(staged, file.before, open)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.files = append(t.files, file)
+
+	return nil
+}
+
+// render copies a file to the staging graph and returns it as logseq-go saves it without any change.
+// The staged copy is then put back as it was, for the transaction to open it.
+func (t *Transaction) render(staged, contents string, open func(*logseq.Transaction) error) (string, error) {
+	err := os.MkdirAll(filepath.Dir(staged), dirPerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the staging directory: %w", err)
+	}
+
+	err = os.WriteFile(staged, []byte(contents), filePerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to stage %s: %w", staged, err)
+	}
+
+	probe := t.staging.NewTransaction()
+
+	err = open(probe)
+	if err != nil {
+		return "", err
+	}
+
+	err = probe.Save()
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", staged, err)
+	}
+
+	data, err := os.ReadFile(staged) //nolint:gosec // a file of the staging graph
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", staged, err)
+	}
+
+	err = os.WriteFile(staged, []byte(contents), filePerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to stage %s: %w", staged, err)
+	}
+
+	return string(data), nil
+}
+
+// conflicts lists the opened files that changed on disk since.
+func (t *Transaction) conflicts() ([]string, error) {
+	var changed []string
+
+	for _, file := range t.files {
+		path, conflict, err := file.conflict()
+		if err != nil {
+			return nil, err
+		}
+
+		if conflict && !slices.Contains(changed, path) {
+			changed = append(changed, path)
+		}
+	}
+
+	return changed, nil
+}
+
+// conflict tells if the file changed on disk since it was opened, and returns its path.
+func (f *openedFile) conflict() (string, bool, error) {
+	path, err := f.locate()
+	if err != nil {
+		return "", false, err
+	}
+
+	same, err := f.opened.unchanged(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	// A file saved by this process after it was opened is a conflict too: Patch would build on
+	// the contents opened, and undo that save. Only a save of the very contents opened is not.
+	if !same {
+		if own, ok := written.lastWrite(path); ok && own.holds(f.before) {
+			same, err = own.unchanged(path)
+			if err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	return path, !same, nil
+}
+
+// pendingWrite is a page saved in the staging graph, about to be written to the graph.
+type pendingWrite struct {
+	file   *openedFile
+	path   string
+	after  string // what to write: only the changed blocks of the page as logseq-go saved it, see Patch
+	exists bool
+}
+
+// Save writes the opened pages to the graph, recording them in the undo journal.
+// Only the blocks changed by the command are written, the rest of each file is kept as it was.
+// It writes nothing and fails with ErrConflict if one of them changed on disk after it was opened,
+// or with ErrUnintendedRewrite, listing the blocks, if logseq-go would also rewrite blocks the command
+// did not change (unless SetAllowRewrites was called). Each page is checked for changes again just before
+// it is written, so one changed while the others were written is left alone.
+// In dry-run mode, it prints the diff of each page instead.
+func (t *Transaction) Save() error {
+	if t.tx == nil {
+		return nil
+	}
+
+	defer t.end()
+
+	err := t.tx.Save()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	pending, err := t.pendingWrites()
+	if err != nil {
+		return err
+	}
+
+	var report strings.Builder
+
+	for _, write := range pending {
+		if !write.exists || rewrites.allowed() {
+			continue
+		}
+
+		found := UnintendedRewrites(write.file.before, write.file.rendered, write.after)
+		report.WriteString(formatRewrites(write.path, found))
+	}
+
+	if report.Len() > 0 {
+		return fmt.Errorf("%w:\n%s", ErrUnintendedRewrite, report.String())
+	}
+
+	// Checked once the pages are rendered, as close to the writes as possible.
+	if !DryRun() {
+		changed, conflictsErr := t.conflicts()
+		if conflictsErr != nil {
+			return conflictsErr
+		}
+
+		if len(changed) > 0 {
+			return fmt.Errorf("%s: %w", strings.Join(changed, ", "), ErrConflict)
+		}
+	}
+
+	for _, write := range pending {
+		err = write.save()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save writes the page to the graph, checking again just before that it did not change on disk
+// while the previous pages were written.
+func (w pendingWrite) save() error {
+	if !DryRun() {
+		_, conflict, err := w.file.conflict()
+		if err != nil {
+			return err
+		}
+
+		if conflict {
+			return fmt.Errorf("%s: %w", w.path, ErrConflict)
+		}
+	}
+
+	return WriteFile(w.path, []byte(w.after), fileMode(w.path))
+}
+
+// end removes the staging graph once Save is over, so a long-running command (e.g. lqd backlog --watch)
+// doesn't keep a copy of each page it saved until it exits. Cleanup removes it then if it cannot be removed now.
+func (t *Transaction) end() {
+	staging.remove(t.staging.Directory())
+
+	t.staging = nil
+	t.tx = nil
+	t.files = nil
+}
+
+// pendingWrites returns the pages saved in the staging graph that differ from their graph file, once each.
+func (t *Transaction) pendingWrites() ([]pendingWrite, error) {
+	var pending []pendingWrite
+
+	seen := make(map[string]bool)
+
+	for _, file := range t.files {
+		staged, err := file.stage()
+		if err != nil {
+			return nil, err
+		}
+
+		if staged == "" || seen[staged] {
+			continue
+		}
+
+		seen[staged] = true
+
+		data, err := os.ReadFile(staged) //nolint:gosec // a file of the staging graph
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // a new page left empty
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", staged, err)
+		}
+
+		path, err := file.locate()
+		if err != nil {
+			return nil, err
+		}
+
+		exists := path != ""
+		if !exists {
+			path, err = graphPath(t.original.Directory(), t.staging.Directory(), staged)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		after := string(data)
+		if exists {
+			after = Patch(file.before, file.rendered, after)
+		}
+
+		if exists && after == file.before {
+			continue
+		}
+
+		pending = append(pending, pendingWrite{file: file, path: path, after: after, exists: exists})
+	}
+
+	return pending, nil
+}
+
+// fileMode returns the mode of a file, or the mode of a new page if it doesn't exist.
+func fileMode(path string) os.FileMode {
+	info, err := os.Stat(path)
+	if err != nil {
+		return pagePerm
+	}
+
+	return info.Mode().Perm()
+}
+
+// existingFile returns path if the file exists, otherwise an empty string.
+// In dry-run mode, files created by the dry run exist too.
+func existingFile(path string) (string, error) {
+	var err error
+	if DryRun() {
+		_, err = ReadFile(path)
+	} else {
+		_, err = os.Stat(path)
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// locatePage returns the file of a page in the graph, like pageFile.
+// In dry-run mode, pages created by the dry run are found too, and pages it removed are not.
+func locatePage(graphDir, title string) (string, error) {
+	path, err := pageFile(graphDir, title)
+	if err != nil || !DryRun() {
+		return path, err
+	}
+
+	state.mu.Lock()
+	scratchDir, err := state.scratchPath(graphDir)
+	state.mu.Unlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	scratch, err := pageFile(scratchDir, title)
+	if err != nil {
+		return "", err
+	}
+
+	if scratch != "" {
+		return filepath.Join(graphDir, "pages", filepath.Base(scratch)), nil
+	}
+
+	if path != "" && removedByDryRun(path) {
+		return "", nil
+	}
+
+	return path, nil
+}
+
+// pageFile returns the file of a page in the graph directory, or an empty string if the page has no file yet.
+// Titles are matched case-insensitively, like Logseq does.
+func pageFile(graphDir, title string) (string, error) {
+	dir := filepath.Join(graphDir, "pages")
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to list the pages of %s: %w", graphDir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+
+		if strings.EqualFold(logseqext.PageTitleFromFileName(entry.Name()), title) {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+	}
+
+	return "", nil
+}
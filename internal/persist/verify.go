@@ -0,0 +1,139 @@
+package persist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/andreoliwa/logseq-doctor/internal/logseqext"
+)
+
+// ErrUnintendedRewrite is returned by Transaction.Save when logseq-go would also rewrite blocks the command
+// left alone, e.g. escaping brackets or dropping part of a property value. Nothing is written.
+var ErrUnintendedRewrite = errors.New(
+	"saving would rewrite blocks the command did not change (use --allow-rewrites to save anyway)")
+
+// rewrites tells if Save may write pages with unintended rewrites.
+//
+//nolint:gochecknoglobals // set once per run by the --allow-rewrites flag
+var rewrites = &rewritePolicy{}
+
+type rewritePolicy struct {
+	mu    sync.Mutex
+	allow bool
+}
+
+// SetAllowRewrites lets Save write pages even when logseq-go rewrites blocks the command did not change.
+func SetAllowRewrites(allow bool) {
+	rewrites.mu.Lock()
+	defer rewrites.mu.Unlock()
+
+	rewrites.allow = allow
+}
+
+func (r *rewritePolicy) allowed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.allow
+}
+
+// Rewrite is a span of blocks that a save changes although the command did not touch them.
+type Rewrite struct {
+	// Line is where the blocks start in the original file, counting from 1.
+	Line   int
+	Before string
+	After  string
+}
+
+// UnintendedRewrites compares a page file block by block, as it was (before), as logseq-go writes it back
+// without any change (rendered), and as the command left it (after).
+// Blocks that logseq-go renders differently from the file, and that the command did not change, are returned:
+// saving after would rewrite them for no reason.
+func UnintendedRewrites(before, rendered, after string) []Rewrite {
+	original, lines := sourceBlocks(before)
+	unchanged, _ := sourceBlocks(rendered)
+	final, _ := sourceBlocks(after)
+
+	// Blocks of rendered that the command left as they are.
+	kept := make(map[int]bool, len(unchanged))
+
+	for _, match := range difflib.NewMatcherWithJunk(unchanged, final, false, nil).GetMatchingBlocks() {
+		for i := range match.Size {
+			kept[match.A+i] = true
+		}
+	}
+
+	var result []Rewrite
+
+	for _, code := range difflib.NewMatcherWithJunk(original, unchanged, false, nil).GetOpCodes() {
+		if code.Tag == 'e' {
+			continue
+		}
+
+		// Blocks dropped by the rendering are lost whatever the command did.
+		unintended := code.J1 == code.J2
+
+		for j := code.J1; j < code.J2; j++ {
+			unintended = unintended || kept[j]
+		}
+
+		if !unintended {
+			continue
+		}
+
+		line := strings.Count(before, "\n") + 1 // blocks added at the end
+		if code.I1 < len(lines) {
+			line = lines[code.I1]
+		}
+
+		result = append(result, Rewrite{
+			Line:   line,
+			Before: strings.Join(original[code.I1:code.I2], "\n"),
+			After:  strings.Join(unchanged[code.J1:code.J2], "\n"),
+		})
+	}
+
+	return result
+}
+
+// sourceBlocks returns the source lines of each block of a page, without the trailing line breaks,
+// and the line number where each block starts.
+func sourceBlocks(text string) ([]string, []int) {
+	blocks := logseqext.SplitSourceBlocks(text)
+	sources := make([]string, 0, len(blocks))
+	lines := make([]int, 0, len(blocks))
+
+	for _, block := range blocks {
+		sources = append(sources, strings.TrimRight(text[block.Start:block.End], "\r\n"))
+		lines = append(lines, strings.Count(text[:block.Start], "\n")+1)
+	}
+
+	return sources, lines
+}
+
+// formatRewrites describes the rewrites of a file like a diff, for the error returned by Save.
+func formatRewrites(path string, found []Rewrite) string {
+	var builder strings.Builder
+
+	for _, rewrite := range found {
+		fmt.Fprintf(&builder, "%s:%d\n", path, rewrite.Line)
+		writePrefixed(&builder, "  - ", rewrite.Before)
+		writePrefixed(&builder, "  + ", rewrite.After)
+	}
+
+	return builder.String()
+}
+
+func writePrefixed(builder *strings.Builder, prefix, text string) {
+	if text == "" {
+		return
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		builder.WriteString(prefix + line + "\n")
+	}
+}
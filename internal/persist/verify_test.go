@@ -0,0 +1,61 @@
+package persist_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
+)
+
+func TestUnintendedRewrites(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		rendered string
+		after    string
+		want     []persist.Rewrite
+	}{
+		{
+			name:     "faithful rendering",
+			before:   "- one\n- two\n",
+			rendered: "- one\n- two\n",
+			after:    "- one\n- 2\n",
+			want:     nil,
+		},
+		{
+			name:     "escaped brackets in a block left alone",
+			before:   "title:: Page\n\n- a [link] here\n- two\n",
+			rendered: "title:: Page\n\n- a \\[link\\] here\n- two\n",
+			after:    "title:: Page\n\n- a \\[link\\] here\n- 2\n",
+			want:     []persist.Rewrite{{Line: 3, Before: "- a [link] here", After: "- a \\[link\\] here"}},
+		},
+		{
+			name:     "property value dropped",
+			before:   "- task\n  owner:: Ann Lee\n- other\n",
+			rendered: "- task\n  owner:: Ann\n- other\n",
+			after:    "- task\n  owner:: Ann\n- other\n- new\n",
+			want:     []persist.Rewrite{{Line: 1, Before: "- task\n  owner:: Ann Lee", After: "- task\n  owner:: Ann"}},
+		},
+		{
+			name:     "the command changed the block itself",
+			before:   "- a [link] here\n- two\n",
+			rendered: "- a \\[link\\] here\n- two\n",
+			after:    "- DONE a \\[link\\] here\n- two\n",
+			want:     nil,
+		},
+		{
+			name:     "the command removed the block",
+			before:   "- a [link] here\n- two\n",
+			rendered: "- a \\[link\\] here\n- two\n",
+			after:    "- two\n",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, persist.UnintendedRewrites(tt.before, tt.rendered, tt.after))
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -19,6 +20,7 @@ var (
 	ErrCannotConnect    = errors.New("cannot connect to PocketBase")
 	ErrAuthFailed       = errors.New("PocketBase authentication failed")
 	ErrUnexpectedStatus = errors.New("unexpected status from PocketBase")
+	ErrSchemaMismatch   = errors.New("collection does not match the schema")
 )
 
 // Client is a minimal PocketBase HTTP client.
@@ -79,6 +81,99 @@ func (c *Client) CollectionExists(name string) (bool, error) {
 	return true, nil
 }
 
+// CollectionFields returns the fields of a collection as PocketBase describes them.
+// The boolean is false when the collection does not exist.
+func (c *Client) CollectionFields(name string) ([]map[string]any, bool, error) {
+	resp, err := c.doRequest(http.MethodGet, "/api/collections/"+name, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get collection: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("%w: status %d getting collection %s", ErrUnexpectedStatus, resp.StatusCode, name)
+	}
+
+	var collection struct {
+		Fields []map[string]any `json:"fields"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&collection)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode collection %s: %w", name, err)
+	}
+
+	return collection.Fields, true, nil
+}
+
+// AddMissingFields adds the fields of a schema that its existing collection does not have yet,
+// e.g. fields added to LqdTasksSchema() after the collection was created, and returns their names.
+// Fields of another type are not changed: the collection has to be recreated, so it fails with ErrSchemaMismatch.
+func (c *Client) AddMissingFields(schema map[string]any) ([]string, error) {
+	name, _ := schema["name"].(string)
+
+	fields, exists, err := c.CollectionFields(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: collection %s not found", ErrUnexpectedStatus, name)
+	}
+
+	missing := MissingFields(schema, fields)
+	fields = append(fields, missing...)
+
+	mismatches := SchemaMismatches(schema, fields)
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("%w: collection %s: %s", ErrSchemaMismatch, name, strings.Join(mismatches, ", "))
+	}
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	// PocketBase replaces the field list: the existing fields keep their ids, so they and their data are kept.
+	err = c.updateCollection(name, map[string]any{"fields": fields})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(missing))
+	for _, field := range missing {
+		fieldName, _ := field["name"].(string)
+		names = append(names, fieldName)
+	}
+
+	return names, nil
+}
+
+func (c *Client) updateCollection(name string, data map[string]any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection: %w", err)
+	}
+
+	resp, err := c.doRequest(http.MethodPatch, "/api/collections/"+name, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("%w: status %d updating collection %s, body: %s",
+			ErrUnexpectedStatus, resp.StatusCode, name, respBody)
+	}
+
+	return nil
+}
+
 // CreateCollection creates a new collection with the given schema.
 func (c *Client) CreateCollection(schema map[string]any) error {
 	body, err := json.Marshal(schema)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/andreoliwa/logseq-doctor/internal/pocketbase"
@@ -249,3 +250,110 @@ func TestDeleteRecord(t *testing.T) {
 	err := client.DeleteRecord("lqd_tasks", "abc-123")
 	require.NoError(t, err)
 }
+
+func TestCollectionFields(t *testing.T) {
+	client, server := newTestClient(t, func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/api/collections/lqd_tasks", request.URL.Path)
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		_, err := writer.Write([]byte(`{"name":"lqd_tasks","fields":[{"name":"rank","type":"number"}]}`))
+		assert.NoError(t, err)
+	})
+	defer server.Close()
+
+	fields, exists, err := client.CollectionFields("lqd_tasks")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, []map[string]any{{"name": "rank", "type": "number"}}, fields)
+}
+
+// collectionWithout serves the tasks collection as an older version created it, without the given fields.
+func collectionWithout(t *testing.T, absent []string, patched *[]map[string]any) http.HandlerFunc {
+	t.Helper()
+
+	fields, ok := pocketbase.LqdTasksSchema()["fields"].([]map[string]any)
+	require.True(t, ok)
+
+	existing := make([]map[string]any, 0, len(fields))
+
+	for _, field := range fields {
+		name, _ := field["name"].(string)
+		if !slices.Contains(absent, name) {
+			existing = append(existing, map[string]any{"id": "field_" + name, "name": name, "type": field["type"],
+				"values": field["values"]})
+		}
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/api/collections/lqd_tasks", request.URL.Path)
+
+		if request.Method == http.MethodPatch {
+			var body struct {
+				Fields []map[string]any `json:"fields"`
+			}
+
+			assert.NoError(t, json.NewDecoder(request.Body).Decode(&body))
+			*patched = body.Fields
+
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(writer).Encode(map[string]any{"name": "lqd_tasks", "fields": existing}))
+	}
+}
+
+func TestAddMissingFields_ExistingCollection(t *testing.T) {
+	tests := []struct {
+		name   string
+		absent []string
+	}{
+		{"before graph profiles", []string{"graph"}},
+		{"before due soon", []string{"due_soon"}},
+		{"before repeating tasks", []string{"repeat"}},
+		{"before backlog icons", []string{"backlog_icon"}},
+		{"before all of them", []string{"due_soon", "repeat", "backlog_icon", "graph"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched []map[string]any
+
+			client, server := newTestClient(t, collectionWithout(t, tt.absent, &patched))
+			defer server.Close()
+
+			added, err := client.AddMissingFields(pocketbase.LqdTasksSchema())
+			require.NoError(t, err)
+			assert.Equal(t, tt.absent, added)
+			assert.Empty(t, pocketbase.SchemaMismatches(pocketbase.LqdTasksSchema(), patched))
+			assert.Equal(t, "field_name", patched[2]["id"], "existing fields keep their id, so their data is kept")
+		})
+	}
+}
+
+func TestAddMissingFields_UpToDate(t *testing.T) {
+	var patched []map[string]any
+
+	client, server := newTestClient(t, collectionWithout(t, nil, &patched))
+	defer server.Close()
+
+	added, err := client.AddMissingFields(pocketbase.LqdTasksSchema())
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Nil(t, patched, "an up-to-date collection is not updated")
+}
+
+func TestAddMissingFields_TypeMismatch(t *testing.T) {
+	client, server := newTestClient(t, func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, http.MethodGet, request.Method, "a collection with another field type is not updated")
+
+		_, err := writer.Write([]byte(`{"name":"lqd_tasks","fields":[{"name":"rank","type":"text"}]}`))
+		assert.NoError(t, err)
+	})
+	defer server.Close()
+
+	_, err := client.AddMissingFields(pocketbase.LqdTasksSchema())
+	require.ErrorIs(t, err, pocketbase.ErrSchemaMismatch)
+	assert.Contains(t, err.Error(), "field rank is text, want number")
+}
@@ -1,6 +1,8 @@
 package pocketbase
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/andreoliwa/logseq-go/content"
@@ -17,6 +19,22 @@ var taskStatusValues = []string{
 // DateFormat is the ISO date format used for PocketBase date/datetime record fields.
 const DateFormat = "2006-01-02 15:04:05.000Z"
 
+// GraphFilter restricts a record filter to the tasks of a graph profile.
+// Without a profile name the filter is returned as is, so single-graph setups keep working
+// on collections created before the graph field existed.
+func GraphFilter(filter, graph string) string {
+	if graph == "" {
+		return filter
+	}
+
+	graphFilter := "graph='" + graph + "'"
+	if filter == "" {
+		return graphFilter
+	}
+
+	return "(" + filter + ") && " + graphFilter
+}
+
 // FormatDateLocal parses a PocketBase UTC datetime string and returns it in local time
 // as "YYYY-MM-DD HH:MM". Returns the raw string if parsing fails.
 func FormatDateLocal(utcStr string) string {
@@ -28,14 +46,23 @@ func FormatDateLocal(utcStr string) string {
 	return t.Local().Format("2006-01-02 15:04") //nolint:gosmopolitan
 }
 
+// TasksCollection is the default name of the PocketBase collection that holds the synced tasks.
+// Graph profiles can use another collection (pocketbase_collection).
+const TasksCollection = "lqd_tasks"
+
 // idMaxLength is UUID (36) + underscore (1) + backlog name (up to 50) = 87.
 const idMaxLength = float64(87)
 
 // LqdTasksSchema returns the PocketBase collection schema for lqd_tasks.
 // Go code is the source of truth — not PB migrations.
 func LqdTasksSchema() map[string]any {
+	return TasksSchema(TasksCollection)
+}
+
+// TasksSchema returns the tasks collection schema under another collection name.
+func TasksSchema(collection string) map[string]any {
 	return map[string]any{
-		"name":   "lqd_tasks",
+		"name":   collection,
 		"type":   "base",
 		"fields": lqdTasksFields(),
 	}
@@ -80,12 +107,89 @@ func lqdTasksDataFields() []map[string]any {
 		{"name": "scheduled", "type": "date"},
 		{"name": "deadline", "type": "date"},
 		{"name": "overdue", "type": "bool"},
+		// due_soon tells if the deadline is within the lead days of the Due soon section, see api.TaskDueSoon.
+		{"name": "due_soon", "type": "bool"},
+		// repeat is the repeater of a repeating task, e.g. ".+1w"; scheduled and deadline then hold the next occurrence.
+		{"name": "repeat", "type": "text"},
 		{"name": "backlog_name", "type": "text"},
+		// backlog_icon is the emoji of the backlog, see backlog.SingleBacklogConfig.Icon.
+		{"name": "backlog_icon", "type": "text"},
 		{"name": "backlog_index", "type": "number"},
 		{"name": "section", "type": "number"},
 		{"name": "rank", "type": "number"},
 		{"name": "sort_date", "type": "date"},
 		{"name": "groomed", "type": "date"},
 		{"name": "priority", "type": "text"},
+		// graph is the name of the graph profile the task was synced from; empty without profiles.
+		{"name": "graph", "type": "text"},
+	}
+}
+
+// SchemaMismatches lists how the fields of a collection differ from a schema such as LqdTasksSchema():
+// missing fields, fields of another type, and select fields with other values. Extra fields are ignored.
+func SchemaMismatches(schema map[string]any, fields []map[string]any) []string {
+	wanted, _ := schema["fields"].([]map[string]any)
+
+	actual := make(map[string]map[string]any, len(fields))
+	for _, field := range fields {
+		name, _ := field["name"].(string)
+		actual[name] = field
+	}
+
+	var mismatches []string
+
+	for _, want := range wanted {
+		name, _ := want["name"].(string)
+
+		got, ok := actual[name]
+		if !ok {
+			mismatches = append(mismatches, "missing field "+name)
+
+			continue
+		}
+
+		if got["type"] != want["type"] {
+			mismatches = append(mismatches, fmt.Sprintf("field %s is %v, want %v", name, got["type"], want["type"]))
+
+			continue
+		}
+
+		if values, ok := want["values"].([]string); ok && !sameValues(values, got["values"]) {
+			mismatches = append(mismatches, fmt.Sprintf("field %s has values %v, want %v", name, got["values"], values))
+		}
 	}
+
+	return mismatches
+}
+
+// MissingFields returns the fields of a schema such as LqdTasksSchema() that a collection does not have.
+func MissingFields(schema map[string]any, fields []map[string]any) []map[string]any {
+	wanted, _ := schema["fields"].([]map[string]any)
+
+	var missing []map[string]any
+
+	for _, want := range wanted {
+		if !slices.ContainsFunc(fields, func(field map[string]any) bool { return field["name"] == want["name"] }) {
+			missing = append(missing, want)
+		}
+	}
+
+	return missing
+}
+
+// sameValues tells if the select values decoded from PocketBase are the wanted ones, in any order.
+func sameValues(want []string, got any) bool {
+	list, _ := got.([]any)
+	if len(list) != len(want) {
+		return false
+	}
+
+	for _, value := range list {
+		text, _ := value.(string)
+		if !slices.Contains(want, text) {
+			return false
+		}
+	}
+
+	return true
 }
@@ -1,6 +1,7 @@
 package pocketbase_test
 
 import (
+	"maps"
 	"testing"
 	"time"
 
@@ -27,12 +28,23 @@ func TestLqdTasksSchema_HasRequiredFields(t *testing.T) {
 	}
 
 	expectedFields := []string{"name", "status", "tags", "journal", "scheduled", "deadline",
-		"overdue", "backlog_name", "backlog_index", "section", "rank", "sort_date", "groomed", "priority"}
+		"overdue", "due_soon", "repeat", "backlog_name", "backlog_icon", "backlog_index", "section", "rank", "sort_date",
+		"groomed", "priority", "graph"}
 	for _, expected := range expectedFields {
 		assert.Contains(t, fieldNames, expected, "missing field: %s", expected)
 	}
 }
 
+func TestTasksSchema_CustomCollection(t *testing.T) {
+	assert.Equal(t, "work_tasks", pocketbase.TasksSchema("work_tasks")["name"])
+}
+
+func TestGraphFilter(t *testing.T) {
+	assert.Equal(t, "status='TODO'", pocketbase.GraphFilter("status='TODO'", ""))
+	assert.Equal(t, "graph='work'", pocketbase.GraphFilter("", "work"))
+	assert.Equal(t, "(a=1 || b=2) && graph='work'", pocketbase.GraphFilter("a=1 || b=2", "work"))
+}
+
 func TestLqdTasksSchema_IDPattern(t *testing.T) {
 	schema := pocketbase.LqdTasksSchema()
 
@@ -109,3 +121,51 @@ func TestFormatDateLocal(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaMismatches(t *testing.T) {
+	fields := []map[string]any{
+		{"name": "id", "type": "text", "system": true},
+		{"name": "name", "type": "text"},
+		{"name": "status", "type": "select", "values": []any{"TODO", "DONE"}},
+		{"name": "rank", "type": "text"},
+		{"name": "extra", "type": "bool"},
+	}
+
+	schema := map[string]any{"fields": []map[string]any{
+		{"name": "name", "type": "text"},
+		{"name": "status", "type": "select", "values": []string{"TODO", "DOING", "DONE"}},
+		{"name": "rank", "type": "number"},
+		{"name": "graph", "type": "text"},
+	}}
+
+	assert.Equal(t, []string{
+		"field status has values [TODO DONE], want [TODO DOING DONE]",
+		"field rank is text, want number",
+		"missing field graph",
+	}, pocketbase.SchemaMismatches(schema, fields))
+}
+
+func TestSchemaMismatches_LqdTasksSchemaMatchesItself(t *testing.T) {
+	schema := pocketbase.LqdTasksSchema()
+	fields, ok := schema["fields"].([]map[string]any)
+	require.True(t, ok)
+
+	// Select values come back from the JSON API as []any.
+	decoded := make([]map[string]any, 0, len(fields))
+
+	for _, field := range fields {
+		copied := maps.Clone(field)
+		if values, ok := field["values"].([]string); ok {
+			list := make([]any, 0, len(values))
+			for _, value := range values {
+				list = append(list, value)
+			}
+
+			copied["values"] = list
+		}
+
+		decoded = append(decoded, copied)
+	}
+
+	assert.Empty(t, pocketbase.SchemaMismatches(schema, decoded))
+}
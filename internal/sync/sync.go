@@ -16,6 +16,7 @@ import (
 // RankInfo holds backlog rank data for a task.
 type RankInfo struct {
 	BacklogName  string
+	BacklogIcon  string
 	BacklogIndex int
 	Section      int // backlog.SectionRanked, SectionUnranked, or SectionOrphan
 	Rank         int
@@ -95,7 +96,8 @@ func yyyymmddToLocalISO(dateInt int) string {
 func syncUpdateFields() []string {
 	return []string{
 		"task_uuid", "name", "status", "tags", "journal", "scheduled", "deadline",
-		"overdue", "backlog_name", "backlog_index", "section", "sort_date", "groomed", "priority",
+		"overdue", "due_soon", "repeat", "backlog_name", "backlog_icon", "backlog_index", "section", "sort_date", "groomed",
+		"priority", "graph",
 	}
 }
 
@@ -189,14 +191,23 @@ func TaskToRecord(
 	task logseqapi.TaskJSON, rank *RankInfo, enrichedTags string, currentTime func() time.Time,
 ) map[string]any {
 	journalISO := yyyymmddToDateOnly(task.Page.JournalDay)
-	scheduledISO := yyyymmddToLocalISO(task.Scheduled)
-	deadlineISO := yyyymmddToLocalISO(task.Deadline)
+	scheduledDay, deadlineDay := logseqapi.TaskDates(task, currentTime) // next occurrences of repeating dates
+	scheduledISO := yyyymmddToLocalISO(scheduledDay)
+	deadlineISO := yyyymmddToLocalISO(deadlineDay)
 	today := currentTime().Format("2006-01-02")
 	sortDate := determineSortDate(scheduledISO, deadlineISO, today)
-	overdue := isOverdue(scheduledISO, deadlineISO, currentTime)
+	// A repeating task with a missed occurrence is overdue, like in Logseq: check the dates as written.
+	overdue := isOverdue(yyyymmddToLocalISO(task.Scheduled), yyyymmddToLocalISO(task.Deadline), currentTime)
+	dueSoon := logseqapi.TaskDueSoon(task, currentTime, backlog.Headers().DueSoonLeadDays())
 	groomedISO := parseGroomedDate(task)
 
 	backlogName, backlogIndex, section, rankValue := extractRankFields(rank)
+
+	backlogIcon := ""
+	if rank != nil {
+		backlogIcon = rank.BacklogIcon
+	}
+
 	priority := priorityLetter(logseqext.ParsePriorityFromContent(task.Content))
 
 	// Composite record ID: uuid_backlogname (backlog name lowercased to satisfy
@@ -218,7 +229,10 @@ func TaskToRecord(
 		"scheduled":     scheduledISO,
 		"deadline":      deadlineISO,
 		"overdue":       overdue,
+		"due_soon":      dueSoon,
+		"repeat":        logseqapi.TaskRepeater(task).String(),
 		"backlog_name":  backlogName,
+		"backlog_icon":  backlogIcon,
 		"backlog_index": backlogIndex,
 		"section":       section,
 		"rank":          rankValue * rankSeedFactor,
@@ -272,8 +286,13 @@ func indexRecordsByID(records []map[string]any) map[string]map[string]any {
 }
 
 // recordChanged checks if any sync-relevant fields differ between two records.
+// Fields the desired record does not set (e.g. graph, without graph profiles) are not compared.
 func recordChanged(existing, desired map[string]any) bool {
 	for _, field := range syncUpdateFields() {
+		if _, ok := desired[field]; !ok {
+			continue
+		}
+
 		if fmt.Sprint(existing[field]) != fmt.Sprint(desired[field]) {
 			return true
 		}
@@ -281,3 +300,40 @@ func recordChanged(existing, desired map[string]any) bool {
 
 	return false
 }
+
+// SetGraph tags the records with the name of the graph profile they come from.
+// Nothing is set without a profile name, so collections created before the graph field existed keep working.
+func SetGraph(records []map[string]any, graph string) {
+	if graph == "" {
+		return
+	}
+
+	for _, record := range records {
+		record["graph"] = graph
+	}
+}
+
+// ScopeToGraph keeps the existing records that a sync of the graph profile owns: its own records, and the untagged
+// ones left over from syncs without profiles. Only the unnamed sync (an empty graph) owns all the untagged records;
+// a named profile only claims those it syncs again, by ID, and tags them. The others may come from another graph
+// sharing the collection, so DiffRecords never deletes them, nor the records of other graphs.
+func ScopeToGraph(existing, desired []map[string]any, graph string) []map[string]any {
+	desiredIDs := make(map[string]bool, len(desired))
+	for _, record := range desired {
+		id, _ := record["id"].(string)
+		desiredIDs[id] = true
+	}
+
+	scoped := make([]map[string]any, 0, len(existing))
+
+	for _, record := range existing {
+		owner, _ := record["graph"].(string)
+		id, _ := record["id"].(string)
+
+		if owner == graph || (owner == "" && desiredIDs[id]) {
+			scoped = append(scoped, record)
+		}
+	}
+
+	return scoped
+}
@@ -175,18 +175,51 @@ func TestTaskToRecord_WithRankAndDates(t *testing.T) {
 		Deadline:  20250412,
 	}
 
-	rank := &lqdsync.RankInfo{BacklogName: "fun", BacklogIndex: 3, Section: 1, Rank: 5}
+	rank := &lqdsync.RankInfo{BacklogName: "fun", BacklogIcon: "🎉", BacklogIndex: 3, Section: 1, Rank: 5}
 	now := func() time.Time { return time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC) }
 	record := lqdsync.TaskToRecord(task, rank, "#travel", now)
 
 	assert.Equal(t, "DOING", record["status"])
 	assert.Equal(t, "fun", record["backlog_name"])
+	assert.Equal(t, "🎉", record["backlog_icon"])
 	assert.Equal(t, 3, record["backlog_index"])
 	assert.Equal(t, 5000, record["rank"]) // rank is seeded as position × 1000
 	assert.Equal(t, true, record["overdue"])
+	assert.Equal(t, false, record["due_soon"], "an overdue task is not due soon")
 	assert.True(t, strings.HasPrefix(fmt.Sprint(record["sort_date"]), "2025-03-05"), "sort_date should be 2025-03-05")
 }
 
+func TestTaskToRecord_DueSoon(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC) }
+
+	soon := logseqapi.TaskJSON{
+		UUID: "a", Marker: "TODO", Content: "TODO x", Page: testPageJSON(20250101), Deadline: 20250420,
+	}
+	later := logseqapi.TaskJSON{
+		UUID: "b", Marker: "TODO", Content: "TODO x", Page: testPageJSON(20250101), Deadline: 20250421,
+	}
+
+	assert.Equal(t, true, lqdsync.TaskToRecord(soon, nil, "", now)["due_soon"])
+	assert.Equal(t, false, lqdsync.TaskToRecord(later, nil, "", now)["due_soon"])
+}
+
+func TestTaskToRecord_Repeating(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC) }
+
+	task := logseqapi.TaskJSON{
+		UUID: "a", Marker: "TODO", Content: "TODO Water plants\nSCHEDULED: <2025-04-01 Tue .+1w>",
+		Page: testPageJSON(20250101), Scheduled: 20250401,
+	}
+	record := lqdsync.TaskToRecord(task, nil, "", now)
+
+	assert.Equal(t, ".+1w", record["repeat"])
+	assert.True(t, strings.HasPrefix(fmt.Sprint(record["scheduled"]), "2025-04-15"), "the next occurrence is synced")
+	assert.Equal(t, true, record["overdue"], "a missed occurrence makes a repeating task overdue")
+
+	plain := logseqapi.TaskJSON{UUID: "b", Marker: "TODO", Content: "TODO x", Page: testPageJSON(20250101)}
+	assert.Empty(t, lqdsync.TaskToRecord(plain, nil, "", now)["repeat"])
+}
+
 func TestTaskToRecord_WithGroomed(t *testing.T) {
 	task := logseqapi.TaskJSON{
 		UUID:    "ghi-789",
@@ -275,3 +308,66 @@ func TestDiffRecords(t *testing.T) {
 	assert.Len(t, toDelete, 1)
 	assert.Equal(t, "will-delete", toDelete[0])
 }
+
+func TestDiffRecords_GraphScope(t *testing.T) {
+	existing := []map[string]any{
+		{"id": "legacy", "name": "Legacy", "status": "TODO", "graph": ""},
+		{"id": "legacy-elsewhere", "name": "Legacy elsewhere", "status": "TODO", "graph": ""},
+		{"id": "mine", "name": "Mine", "status": "TODO", "graph": "work"},
+		{"id": "mine-done", "name": "Mine done", "status": "DONE", "graph": "work"},
+		{"id": "other", "name": "Other", "status": "TODO", "graph": "personal"},
+	}
+
+	desired := []map[string]any{
+		{"id": "legacy", "name": "Legacy", "status": "TODO"},
+		{"id": "mine", "name": "Mine", "status": "TODO"},
+	}
+	lqdsync.SetGraph(desired, "work")
+
+	toCreate, toUpdate, toDelete := lqdsync.DiffRecords(lqdsync.ScopeToGraph(existing, desired, "work"), desired)
+
+	assert.Empty(t, toCreate)
+	require.Len(t, toUpdate, 1, "the legacy record is claimed by the graph")
+	assert.Equal(t, "legacy", toUpdate[0]["id"])
+	assert.Equal(t, []string{"mine-done"}, toDelete,
+		"untagged records not synced by the graph and records of other graphs are left alone")
+}
+
+func TestScopeToGraph_UnnamedSyncOwnsUntaggedRecords(t *testing.T) {
+	existing := []map[string]any{
+		{"id": "legacy", "graph": ""},
+		{"id": "untagged"},
+		{"id": "other", "graph": "personal"},
+	}
+
+	scoped := lqdsync.ScopeToGraph(existing, nil, "")
+
+	require.Len(t, scoped, 2)
+	assert.Equal(t, "legacy", scoped[0]["id"])
+	assert.Equal(t, "untagged", scoped[1]["id"])
+}
+
+func TestDiffRecords_NoGraphField(t *testing.T) {
+	// Without graph profiles the graph field is not set, so records of a collection
+	// created before the field existed are not updated on every sync.
+	existing := []map[string]any{{"id": "a", "name": "A", "status": "TODO"}}
+	desired := []map[string]any{{"id": "a", "name": "A", "status": "TODO"}}
+	lqdsync.SetGraph(desired, "")
+
+	_, toUpdate, _ := lqdsync.DiffRecords(lqdsync.ScopeToGraph(existing, desired, ""), desired)
+	assert.Empty(t, toUpdate)
+}
+
+func TestDiffRecords_FieldMissingFromRecord(t *testing.T) {
+	// Sync adds backlog_icon to collections created before it existed, so a record without it gets updated.
+	existing := []map[string]any{{"id": "a", "name": "A", "status": "TODO"}}
+	desired := []map[string]any{{"id": "a", "name": "A", "status": "TODO", "backlog_icon": "💼"}}
+
+	_, toUpdate, _ := lqdsync.DiffRecords(existing, desired)
+	assert.Len(t, toUpdate, 1)
+
+	existing[0]["backlog_icon"] = "💼"
+
+	_, toUpdate, _ = lqdsync.DiffRecords(existing, desired)
+	assert.Empty(t, toUpdate)
+}
@@ -139,8 +139,9 @@ func (f *TaskFixture) FakeBacklog(t *testing.T, configPage, caseDirName string)
 }
 
 // FakeBacklogWithUUIDPages creates a backlog.Backlog like FakeBacklog, but also registers
-// UUID-to-page-name mappings in the mock API for FindBlockByUUID calls (used by directives).
-// uuidPageNames maps slug -> page name (e.g. "home-clean-windows" -> "home").
+// UUID-to-page-name mappings in the mock API for FindBlockByUUID calls (used by directives and the Done section).
+// uuidPageNames maps slug -> page name (e.g. "home-clean-windows" -> "home"). The task marker of the slug
+// is returned too.
 func (f *TaskFixture) FakeBacklogWithUUIDPages(
 	t *testing.T, configPage, caseDirName string,
 	uuidPageNames map[string]string,
@@ -156,7 +157,15 @@ func (f *TaskFixture) FakeBacklogWithUUIDPages(
 			continue
 		}
 
-		resp := `[[{"uuid":"` + uuid + `","page":{"id":1,"original-name":"` + pageName + `"}}]]`
+		marker := ""
+
+		for _, block := range f.blocks {
+			if block.Slug == slug {
+				marker = block.Marker
+			}
+		}
+
+		resp := `[[{"uuid":"` + uuid + `","marker":"` + marker + `","page":{"id":1,"original-name":"` + pageName + `"}}]]`
 		api.WithUUIDPageResponse(uuid, resp)
 	}
 
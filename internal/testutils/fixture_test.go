@@ -215,3 +215,20 @@ func TestBuildAPIResponse_GroomedProperty(t *testing.T) {
 	assert.Contains(t, props["groomed"], "[[")
 	assert.Contains(t, props["groomed"], "2025")
 }
+
+func TestBuildAPIResponse_ExtraPropsExpandSlugs(t *testing.T) {
+	now := time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC)
+	blocks := []testutils.Block{
+		testutils.Task("blocker", "TODO", "Buy paint"),
+		testutils.Task("blocked", "TODO", "Paint the wall",
+			testutils.WithExtraProps(map[string]string{"blocked-by": "(( blocker ))"})),
+	}
+	s2u, _ := testutils.ExportBuildSlugMap(blocks)
+	resp := testutils.ExportBuildAPIResponse(blocks, s2u, now)
+
+	var parsed []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(resp), &parsed))
+	props, ok := parsed[1]["propertiesTextValues"].(map[string]any)
+	require.True(t, ok, "propertiesTextValues must be a map")
+	assert.Equal(t, "(("+s2u["blocker"]+"))", props["blocked-by"])
+}
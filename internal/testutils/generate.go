@@ -93,7 +93,10 @@ func buildBlockContent(block Block, uuid string) string {
 }
 
 // buildBlockProperties constructs the properties map and order slice for a block.
-func buildBlockProperties(block Block, uuid string, now time.Time) (map[string]string, []string) {
+// Block refs to slugs in extra properties, like blocked-by:: (( slug )), are expanded to UUIDs.
+func buildBlockProperties(
+	block Block, uuid string, now time.Time, slugToUUIDMap map[string]string,
+) (map[string]string, []string) {
 	props := map[string]string{"id": uuid}
 	propsOrder := []string{"id"}
 
@@ -107,7 +110,7 @@ func buildBlockProperties(block Block, uuid string, now time.Time) (map[string]s
 	}
 
 	for k, v := range block.ExtraProps {
-		props[k] = v
+		props[k] = expandSlugs(v, slugToUUIDMap)
 		propsOrder = append(propsOrder, k)
 	}
 
@@ -194,7 +197,7 @@ func buildAPIResponse(blocks []Block, slugToUUIDMap map[string]string, now time.
 		pageID := pageIDBase + i
 
 		content := buildBlockContent(block, uuid)
-		props, propsOrder := buildBlockProperties(block, uuid, now)
+		props, propsOrder := buildBlockProperties(block, uuid, now, slugToUUIDMap)
 		page := buildJournalPage(block, now, pageID)
 		refs, pathRefs := buildBlockRefs(block, tagIDs, pageID)
 
@@ -2,6 +2,8 @@ package internal
 
 import (
 	"fmt"
+	"github.com/andreoliwa/logseq-doctor/internal/config"
+	"github.com/andreoliwa/logseq-doctor/internal/persist"
 	"github.com/andreoliwa/logseq-go"
 	"github.com/andreoliwa/logseq-go/content"
 	"log"
@@ -11,7 +13,8 @@ import (
 	"strings"
 )
 
-func TidyUpOneFile(graph *logseq.Graph, path string) int { //nolint:cyclop,funlen
+// Pages and tags listed in forbiddenRefs are reported by the forbidden references check.
+func TidyUpOneFile(graph *logseq.Graph, path string, forbiddenRefs []string) int { //nolint:cyclop,funlen
 	if !IsValidMarkdownFile(path) {
 		fmt.Printf("%s: skipping, not a Markdown file\n", path)
 
@@ -22,7 +25,7 @@ func TidyUpOneFile(graph *logseq.Graph, path string) int { //nolint:cyclop,funle
 
 	// Some fixes still need modifications directly on the file contents.
 	// We will do them first, and apply each function on top of the previously modified contents.
-	bytes, err := os.ReadFile(path)
+	bytes, err := persist.ReadFile(path)
 	if err != nil {
 		log.Fatalf("%s: error reading file contents: %s\n", path, err)
 	}
@@ -51,14 +54,14 @@ func TidyUpOneFile(graph *logseq.Graph, path string) int { //nolint:cyclop,funle
 	}
 
 	if write {
-		err := os.WriteFile(path, []byte(currentFileContents), fileInfo.Mode()) //nolint:gosec,lll // path is intentionally user-supplied (CLI argument)
+		err := persist.WriteFile(path, []byte(currentFileContents), fileInfo.Mode())
 		if err != nil {
 			log.Fatalf("%s: error writing file contents: %s\n", path, err)
 		}
 	}
 
 	// Now we will apply the functions that modify the Markdown through a Page and a transaction.
-	transaction := graph.NewTransaction()
+	transaction := persist.NewTransaction(graph)
 	commit := false
 
 	page, err := transaction.OpenViaPath(path)
@@ -71,7 +74,7 @@ func TidyUpOneFile(graph *logseq.Graph, path string) int { //nolint:cyclop,funle
 	}
 
 	for _, f := range []func(logseq.Page) ChangedPage{
-		CheckForbiddenReferences, CheckRunningTasks, RemoveDoubleSpaces, RemoveEmptyBullets,
+		ForbiddenReferencesCheck(forbiddenRefs), CheckRunningTasks, RemoveDoubleSpaces, RemoveEmptyBullets,
 	} {
 		result := f(page)
 		if result.Msg != "" {
@@ -116,45 +119,49 @@ type ChangedPage struct {
 	Changed bool
 }
 
-// CheckForbiddenReferences checks if a page has forbidden references to other pages or tags.
+// CheckForbiddenReferences checks if a page has references to the default forbidden pages or tags
+// (see the tidy-up.forbidden_refs config key).
 func CheckForbiddenReferences(page logseq.Page) ChangedPage {
-	all := make([]string, 0)
+	return ForbiddenReferencesCheck(config.SplitList(config.Default(config.KeyTidyUpForbiddenRefs)))(page)
+}
 
-	for _, block := range page.Blocks() {
-		block.Children().FindDeep(func(n content.Node) bool {
-			var reference string
-			if pageLink, ok := n.(*content.PageLink); ok {
-				reference = pageLink.To
-			} else if tag, ok := n.(*content.Hashtag); ok {
-				reference = tag.To
-			}
+// ForbiddenReferencesCheck returns a check that reports references to any of the forbidden pages or tags,
+// compared case-insensitively.
+func ForbiddenReferencesCheck(forbiddenRefs []string) func(logseq.Page) ChangedPage {
+	forbidden := make(map[string]bool, len(forbiddenRefs))
+	for _, ref := range forbiddenRefs {
+		forbidden[strings.ToLower(ref)] = true
+	}
 
-			// TODO: these values should be read from a config file or env var
-			forbidden := false
+	return func(page logseq.Page) ChangedPage {
+		all := make([]string, 0)
 
-			switch strings.ToLower(reference) {
-			case "quick capture":
-				forbidden = true
-			case "inbox":
-				forbidden = true
-			}
+		for _, block := range page.Blocks() {
+			block.Children().FindDeep(func(n content.Node) bool {
+				var reference string
+				if pageLink, ok := n.(*content.PageLink); ok {
+					reference = pageLink.To
+				} else if tag, ok := n.(*content.Hashtag); ok {
+					reference = tag.To
+				}
 
-			if forbidden {
-				all = append(all, reference)
-			}
+				if forbidden[strings.ToLower(reference)] {
+					all = append(all, reference)
+				}
 
-			return false
-		})
-	}
+				return false
+			})
+		}
 
-	if count := len(all); count > 0 {
-		unique := SortAndRemoveDuplicates(all)
+		if count := len(all); count > 0 {
+			unique := SortAndRemoveDuplicates(all)
 
-		return ChangedPage{fmt.Sprintf("remove %d forbidden references to pages/tags: %s",
-			count, strings.Join(unique, ", ")), false}
-	}
+			return ChangedPage{fmt.Sprintf("remove %d forbidden references to pages/tags: %s",
+				count, strings.Join(unique, ", ")), false}
+		}
 
-	return ChangedPage{"", false}
+		return ChangedPage{"", false}
+	}
 }
 
 func SortAndRemoveDuplicates(elements []string) []string {
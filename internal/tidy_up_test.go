@@ -78,6 +78,14 @@ func TestCheckForbiddenReferences(t *testing.T) {
 	assert.Equal(t, internal.ChangedPage{"", false}, internal.CheckForbiddenReferences(valid))
 }
 
+func TestForbiddenReferencesCheck(t *testing.T) {
+	page := setupPage(t, "forbidden")
+	assert.Equal(t, internal.ChangedPage{"remove 2 forbidden references to pages/tags: Inbox", false},
+		internal.ForbiddenReferencesCheck([]string{"INBOX", "someday"})(page))
+
+	assert.Equal(t, internal.ChangedPage{"", false}, internal.ForbiddenReferencesCheck(nil)(page))
+}
+
 func TestCheckRunningTasks(t *testing.T) {
 	invalid := setupPage(t, "running")
 	assert.Equal(t, internal.ChangedPage{"stop 2 running task(s): DOING, IN-PROGRESS", false},
@@ -0,0 +1,63 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSkipped is the error of the inputs not started because an earlier job failed or the context was canceled.
+var ErrSkipped = errors.New("skipped")
+
+// Result is the outcome of the job of one input.
+type Result[T any] struct {
+	Value   T
+	Err     error
+	Elapsed time.Duration
+}
+
+// Run calls job for each input on at most workers goroutines, and returns the results in the order of the inputs.
+// The first error cancels the context given to the jobs: the inputs not started yet fail with ErrSkipped.
+func Run[I, T any](
+	ctx context.Context, workers int, inputs []I, job func(ctx context.Context, input I) (T, error),
+) []Result[T] {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]Result[T], len(inputs))
+
+	indexes := make(chan int, len(inputs))
+	for index := range inputs {
+		indexes <- index
+	}
+
+	close(indexes)
+
+	var wg sync.WaitGroup
+
+	for range max(1, min(workers, len(inputs))) {
+		wg.Go(func() {
+			for index := range indexes {
+				if ctx.Err() != nil {
+					results[index].Err = fmt.Errorf("%w: %w", ErrSkipped, context.Cause(ctx))
+
+					continue
+				}
+
+				start := time.Now()
+				value, err := job(ctx, inputs[index])
+				results[index] = Result[T]{Value: value, Err: err, Elapsed: time.Since(start)}
+
+				if err != nil {
+					cancel(err)
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return results
+}
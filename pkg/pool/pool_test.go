@@ -0,0 +1,92 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andreoliwa/logseq-doctor/pkg/pool"
+)
+
+var errBoom = errors.New("boom")
+
+func TestRun_KeepsTheOrderOfTheInputs(t *testing.T) {
+	inputs := []int{5, 1, 4, 2, 3}
+
+	results := pool.Run(context.Background(), 3, inputs, func(_ context.Context, input int) (int, error) {
+		time.Sleep(time.Duration(input) * time.Millisecond) // the jobs finish in another order
+
+		return input * 10, nil
+	})
+
+	require.Len(t, results, len(inputs))
+
+	for index, result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, inputs[index]*10, result.Value)
+		assert.Positive(t, result.Elapsed)
+	}
+}
+
+func TestRun_BoundsTheWorkers(t *testing.T) {
+	var running, most atomic.Int32
+
+	pool.Run(context.Background(), 2, make([]int, 10), func(_ context.Context, _ int) (int, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			previous := most.Load()
+			if now <= previous || most.CompareAndSwap(previous, now) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		return 0, nil
+	})
+
+	assert.Equal(t, int32(2), most.Load())
+}
+
+func TestRun_FirstErrorSkipsTheRest(t *testing.T) {
+	results := pool.Run(context.Background(), 1, []string{"ok", "fail", "later"},
+		func(_ context.Context, input string) (string, error) {
+			if input == "fail" {
+				return "", errBoom
+			}
+
+			return input, nil
+		})
+
+	require.NoError(t, results[0].Err)
+	require.ErrorIs(t, results[1].Err, errBoom)
+	require.ErrorIs(t, results[2].Err, pool.ErrSkipped)
+	require.ErrorIs(t, results[2].Err, errBoom, "the cause of the cancellation is kept")
+}
+
+func TestRun_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := pool.Run(ctx, 4, []int{1, 2}, func(_ context.Context, input int) (int, error) {
+		return input, nil
+	})
+
+	for _, result := range results {
+		require.ErrorIs(t, result.Err, pool.ErrSkipped)
+		require.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
+
+func TestRun_NoInputs(t *testing.T) {
+	assert.Empty(t, pool.Run(context.Background(), 4, nil, func(_ context.Context, input int) (int, error) {
+		return input, nil
+	}))
+}